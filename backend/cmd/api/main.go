@@ -2,13 +2,18 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/SaadBeidourii/MediaHub.git/internal/config"
 	"github.com/SaadBeidourii/MediaHub.git/internal/handlers"
+	"github.com/SaadBeidourii/MediaHub.git/internal/media"
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
 	"github.com/SaadBeidourii/MediaHub.git/internal/services"
 	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
 	"github.com/gin-contrib/cors"
@@ -16,6 +21,15 @@ import (
 	_ "github.com/lib/pq"
 )
 
+const (
+	// uploadExpiryInterval is how often abandoned uploads are cleaned up
+	uploadExpiryInterval = 10 * time.Minute
+	// jobWorkers is how many background jobs run at once
+	jobWorkers = 4
+	// trashRetentionInterval is how often expired trash items are purged
+	trashRetentionInterval = time.Hour
+)
+
 func main() {
 	// Load configuration
 	cfg := config.NewConfig()
@@ -50,19 +64,112 @@ func main() {
 		log.Fatalf("Failed to initialize PostgreSQL folder store: %v", err)
 	}
 
-	// Initialize the PostgreSQL storage provider for file content
-	storageProvider, err := storage.NewPostgresStorageProvider(db)
+	blobStore, err := storage.NewPostgresBlobStore(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL blob store: %v", err)
+	}
+
+	renditionStore, err := storage.NewPostgresRenditionStore(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL rendition store: %v", err)
+	}
+
+	searchStore, err := storage.NewPostgresSearchStore(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL search store: %v", err)
+	}
+
+	tagStore, err := storage.NewPostgresTagStore(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL tag store: %v", err)
+	}
+
+	collectionStore, err := storage.NewPostgresCollectionStore(db)
 	if err != nil {
-		log.Fatalf("Failed to initialize PostgreSQL storage provider: %v", err)
+		log.Fatalf("Failed to initialize PostgreSQL collection store: %v", err)
 	}
 
+	trashStore, err := storage.NewPostgresTrashStore(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL trash store: %v", err)
+	}
+
+	jobStore, err := storage.NewPostgresJobStore(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL job store: %v", err)
+	}
+
+	uploadStore, err := storage.NewPostgresUploadStore(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL upload store: %v", err)
+	}
+
+	// Initialize the storage provider for file content
+	storageProvider, err := newStorageProvider(cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage provider: %v", cfg.Storage.Provider, err)
+	}
+	log.Printf("Using %s storage provider", cfg.Storage.Provider)
+
+	// Metadata and content changes share one unit of work
+	unitOfWork := storage.NewPostgresUnitOfWork(db, assetStore, folderStore, blobStore, renditionStore, jobStore, collectionStore, trashStore, storageProvider)
+
+	// Media types accepted for upload
+	mediaTypes := media.NewDefaultRegistry()
+
 	// Initialize services
-	assetService := services.NewAssetService(storageProvider, assetStore)
-	folderService := services.NewFolderService(folderStore, assetStore)
+	renditionService := services.NewRenditionService(storageProvider, assetStore, renditionStore, unitOfWork, mediaTypes)
+	assetService := services.NewAssetService(storageProvider, assetStore, unitOfWork, mediaTypes)
+	jobService := services.NewJobService(jobStore)
+	searchService := services.NewSearchService(storageProvider, assetStore, renditionStore, searchStore, jobStore, mediaTypes)
+	folderService := services.NewFolderService(folderStore, assetStore, unitOfWork)
+	tagService := services.NewTagService(tagStore, assetStore)
+	collectionService := services.NewCollectionService(collectionStore, assetStore, unitOfWork, mediaTypes)
+	trashService := services.NewTrashService(trashStore, unitOfWork, cfg.Trash.Retention)
+	pathService := services.NewPathService(folderStore, assetStore, assetService)
+	reconcileService := services.NewReconcileService(storageProvider, assetStore, blobStore, renditionStore, unitOfWork)
+
+	// Run the reconciler instead of the server: mediahub fsck [-mode=repair]
+	if len(os.Args) > 1 && os.Args[1] == "fsck" {
+		code := runFsck(reconcileService, os.Args[2:])
+		db.Close()
+		os.Exit(code)
+	}
+
+	uploadService, err := services.NewUploadService(uploadStore, assetService, mediaTypes, cfg.Uploads.StagingDir, cfg.Uploads.Expiry)
+	if err != nil {
+		log.Fatalf("Failed to initialize upload service: %v", err)
+	}
+
+	// Clean up abandoned resumable uploads in the background
+	stopExpiry := make(chan struct{})
+	defer close(stopExpiry)
+	go uploadService.RunExpiry(uploadExpiryInterval, stopExpiry)
+
+	// Purge deleted items once they are past their retention period
+	stopRetention := make(chan struct{})
+	defer close(stopRetention)
+	go trashService.RunRetention(trashRetentionInterval, stopRetention)
+
+	// Run the work queued after uploads in the background
+	jobService.Register(models.JobTypeExtractMetadata, services.AssetJob(assetService.ExtractMetadata))
+	jobService.Register(models.JobTypeGenerateRenditions, services.AssetJob(renditionService.Generate))
+	jobService.Register(models.JobTypeIndexText, services.AssetJob(searchService.IndexText))
+	stopJobs := make(chan struct{})
+	defer close(stopJobs)
+	go jobService.Run(jobWorkers, stopJobs)
 
 	// Initialize handlers
-	assetHandler := handlers.NewAssetHandler(assetService)
+	assetHandler := handlers.NewAssetHandler(assetService, renditionService)
 	folderHandler := handlers.NewFolderHandler(folderService)
+	adminHandler := handlers.NewAdminHandler(reconcileService, searchService)
+	uploadHandler := handlers.NewUploadHandler(uploadService)
+	jobHandler := handlers.NewJobHandler(jobService, assetService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	collectionHandler := handlers.NewCollectionHandler(collectionService)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	pathHandler := handlers.NewPathHandler(pathService, folderService, assetService)
 
 	// Initialize Gin router
 	router := gin.Default()
@@ -98,6 +205,9 @@ func main() {
 			// List all assets
 			assets.GET("/", assetHandler.ListAssets)
 
+			// Upload a new asset of any supported media type
+			assets.POST("/", assetHandler.UploadAsset)
+
 			// Upload a new PDF asset
 			assets.POST("/pdf", assetHandler.UploadPDF)
 
@@ -112,12 +222,58 @@ func main() {
 
 			// Download asset
 			assets.GET("/:id/download", assetHandler.DownloadAsset)
+			assets.HEAD("/:id/download", assetHandler.DownloadAsset)
+
+			// Stream asset content for in-browser viewing and playback
+			assets.GET("/:id/content", assetHandler.StreamAsset)
+			assets.HEAD("/:id/content", assetHandler.StreamAsset)
+
+			// Get the cover image embedded in an EPUB or audio file
+			assets.GET("/:id/cover", assetHandler.GetAssetCover)
+			assets.HEAD("/:id/cover", assetHandler.GetAssetCover)
+
+			// List the renditions generated for an asset
+			assets.GET("/:id/renditions", assetHandler.ListAssetRenditions)
+
+			// Get a rendition such as a thumbnail, waveform or text extract
+			assets.GET("/:id/renditions/:kind", assetHandler.GetAssetRendition)
+			assets.HEAD("/:id/renditions/:kind", assetHandler.GetAssetRendition)
+
+			// List the background jobs queued for an asset
+			assets.GET("/:id/jobs", jobHandler.ListAssetJobs)
+
+			// Rename asset
+			assets.PUT("/:id", assetHandler.UpdateAsset)
 
 			// Delete asset
 			assets.DELETE("/:id", assetHandler.DeleteAsset)
 
 			// Move asset to folder
 			assets.PUT("/:id/move", folderHandler.MoveAsset)
+
+			// Add tags to an asset
+			assets.POST("/:id/tags", tagHandler.TagAsset)
+
+			// Remove a tag from an asset
+			assets.DELETE("/:id/tags/:tag", tagHandler.UntagAsset)
+
+			// List the collections an asset belongs to
+			assets.GET("/:id/collections", collectionHandler.ListAssetCollections)
+		}
+
+		tags := api.Group("/tags")
+		{
+			// List tags with the number of assets using each
+			tags.GET("/", tagHandler.ListTags)
+
+			// Rename a tag
+			tags.PUT("/:id", tagHandler.UpdateTag)
+
+			// Merge a tag into another
+			tags.POST("/:id/merge", tagHandler.MergeTag)
+
+			// Delete a tag
+			tags.DELETE("/:id", tagHandler.DeleteTag)
 		}
 
 		folders := api.Group("/folders")
@@ -128,6 +284,9 @@ func main() {
 			// Get all folders
 			folders.GET("/", folderHandler.GetAllFolders)
 
+			// Get the whole folder tree
+			folders.GET("/tree", folderHandler.GetFolderTree)
+
 			// Get folder details
 			folders.GET("/:id", folderHandler.GetFolder)
 
@@ -142,6 +301,117 @@ func main() {
 
 			// Get folder path
 			folders.GET("/:id/path", folderHandler.GetFolderPath)
+
+			// Get the folder tree below a folder
+			folders.GET("/:id/tree", folderHandler.GetFolderSubtree)
+
+			// Get folder statistics, "root" for the whole library
+			folders.GET("/:id/stats", folderHandler.GetFolderStats)
+		}
+
+		collections := api.Group("/collections")
+		{
+			// Create a new collection
+			collections.POST("/", collectionHandler.CreateCollection)
+
+			// Get all collections
+			collections.GET("/", collectionHandler.ListCollections)
+
+			// Get collection details
+			collections.GET("/:id", collectionHandler.GetCollection)
+
+			// Update collection
+			collections.PUT("/:id", collectionHandler.UpdateCollection)
+
+			// Delete collection, leaving its assets alone
+			collections.DELETE("/:id", collectionHandler.DeleteCollection)
+
+			// Get the items of a collection in order
+			collections.GET("/:id/items", collectionHandler.ListItems)
+
+			// Add assets to a collection
+			collections.POST("/:id/items", collectionHandler.AddItems)
+
+			// Put all items of a collection in a new order
+			collections.PUT("/:id/items", collectionHandler.ReorderItems)
+
+			// Move one item to a new position
+			collections.PUT("/:id/items/:assetId", collectionHandler.MoveItem)
+
+			// Remove an asset from a collection
+			collections.DELETE("/:id/items/:assetId", collectionHandler.RemoveItem)
+		}
+
+		trash := api.Group("/trash")
+		{
+			// List deleted assets and folders
+			trash.GET("/", trashHandler.ListTrash)
+
+			// Get a trash item
+			trash.GET("/:id", trashHandler.GetTrashItem)
+
+			// Restore an item to the folder it was deleted from
+			trash.POST("/:id/restore", trashHandler.RestoreTrashItem)
+
+			// Permanently delete an item
+			trash.DELETE("/:id", trashHandler.PurgeTrashItem)
+
+			// Permanently delete everything in the trash
+			trash.DELETE("/", trashHandler.EmptyTrash)
+		}
+
+		fs := api.Group("/fs")
+		{
+			// Resolve a path to a folder listing or an asset, or download
+			// the asset with ?download=true
+			fs.GET("/*path", pathHandler.GetPath)
+			fs.HEAD("/*path", pathHandler.GetPath)
+
+			// Upload a file to a path, creating missing folders
+			fs.PUT("/*path", pathHandler.UploadPath)
+		}
+
+		// Resumable uploads using the tus protocol
+		uploads := api.Group("/uploads", uploadHandler.TusResumable)
+		{
+			// Report the supported protocol version and extensions
+			uploads.OPTIONS("/", uploadHandler.Options)
+
+			// Start a new upload
+			uploads.POST("/", uploadHandler.CreateUpload)
+
+			// Get the current offset to resume from
+			uploads.HEAD("/:id", uploadHandler.GetUploadOffset)
+
+			// Get upload progress and the resulting asset
+			uploads.GET("/:id", uploadHandler.GetUpload)
+
+			// Append a chunk
+			uploads.PATCH("/:id", uploadHandler.WriteChunk)
+
+			// Abort an upload
+			uploads.DELETE("/:id", uploadHandler.TerminateUpload)
+		}
+
+		// Search asset names, metadata and document text
+		api.GET("/search", searchHandler.Search)
+
+		jobs := api.Group("/jobs")
+		{
+			// Get the status of a background job
+			jobs.GET("/:id", jobHandler.GetJob)
+		}
+
+		admin := api.Group("/admin")
+		{
+			// Report inconsistencies between assets and stored content
+			admin.GET("/fsck", adminHandler.Reconcile)
+
+			// Report and repair or quarantine inconsistencies
+			admin.POST("/fsck", adminHandler.Reconcile)
+
+			// Queue the text of every document to be indexed again
+			admin.POST("/reindex", adminHandler.Reindex)
 		}
 	}
 
@@ -152,3 +422,55 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// runFsck runs the reconciler from the command line and returns the exit code:
+// 0 when everything is consistent, 1 when issues were found, 2 on failure
+func runFsck(reconcileService *services.ReconcileService, args []string) int {
+	flags := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	mode := flags.String("mode", string(models.ReconcileModeReport), "report, repair or quarantine")
+	gracePeriod := flags.Duration("grace", services.DefaultReconcileGracePeriod, "ignore unreferenced content newer than this")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	report, err := reconcileService.Reconcile(models.ReconcileMode(*mode), *gracePeriod)
+	if err != nil {
+		log.Printf("Reconcile failed: %v", err)
+		return 2
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Printf("Failed to write report: %v", err)
+		return 2
+	}
+
+	if len(report.Issues) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// newStorageProvider creates the storage provider selected in the configuration
+func newStorageProvider(cfg *config.Config, db *sql.DB) (storage.StorageProvider, error) {
+	switch cfg.Storage.Provider {
+	case "postgres":
+		return storage.NewPostgresStorageProvider(db)
+	case "filesystem":
+		return storage.NewFileSystemStorageProvider(cfg.Storage.RootDir)
+	case "s3":
+		return storage.NewS3StorageProvider(storage.S3Config{
+			Endpoint:        cfg.S3.Endpoint,
+			Region:          cfg.S3.Region,
+			Bucket:          cfg.S3.Bucket,
+			Prefix:          cfg.S3.Prefix,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			UseSSL:          cfg.S3.UseSSL,
+			ForcePathStyle:  cfg.S3.ForcePathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage provider: %s", cfg.Storage.Provider)
+	}
+}
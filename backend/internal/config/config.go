@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config holds the application configuration
@@ -30,6 +32,41 @@ type Config struct {
 		Name     string
 		SSLMode  string
 	}
+
+	// Storage configuration
+	Storage struct {
+		// Provider selects where file content is kept: "postgres", "filesystem" or "s3"
+		Provider string
+		// RootDir is the base directory used by the filesystem provider
+		RootDir string
+	}
+
+	// S3 configuration, used by the s3 storage provider
+	S3 struct {
+		Endpoint        string
+		Region          string
+		Bucket          string
+		Prefix          string
+		AccessKeyID     string
+		SecretAccessKey string
+		UseSSL          bool
+		ForcePathStyle  bool
+	}
+
+	// Resumable upload configuration
+	Uploads struct {
+		// StagingDir holds the chunks of uploads that are still in progress
+		StagingDir string
+		// Expiry is how long an upload may sit idle before it is discarded
+		Expiry time.Duration
+	}
+
+	// Trash configuration
+	Trash struct {
+		// Retention is how long deleted items can be restored before they
+		// are purged for good
+		Retention time.Duration
+	}
 }
 
 // NewConfig creates a new config with default values
@@ -43,8 +80,15 @@ func NewConfig() *Config {
 	// Default CORS configuration
 	cfg.CORS.AllowOrigins = []string{"http://localhost:4200", "http://frontend:4200"}
 	cfg.CORS.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	cfg.CORS.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	cfg.CORS.ExposeHeaders = []string{"Content-Length"}
+	cfg.CORS.AllowHeaders = []string{
+		"Origin", "Content-Type", "Accept", "Authorization",
+		"Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata",
+	}
+	cfg.CORS.ExposeHeaders = []string{
+		"Content-Length", "Location",
+		"Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size",
+		"Upload-Length", "Upload-Offset", "Upload-Expires",
+	}
 	cfg.CORS.AllowCredentials = false
 
 	// Default database configuration
@@ -55,6 +99,27 @@ func NewConfig() *Config {
 	cfg.Database.Name = getEnv("DB_NAME", "assetvault")
 	cfg.Database.SSLMode = getEnv("DB_SSLMODE", "disable")
 
+	// Default storage configuration
+	cfg.Storage.Provider = getEnv("STORAGE_PROVIDER", "postgres")
+	cfg.Storage.RootDir = getEnv("STORAGE_ROOT", "./data/content")
+
+	// Default S3 configuration
+	cfg.S3.Endpoint = getEnv("S3_ENDPOINT", "localhost:9000")
+	cfg.S3.Region = getEnv("S3_REGION", "us-east-1")
+	cfg.S3.Bucket = getEnv("S3_BUCKET", "mediahub")
+	cfg.S3.Prefix = getEnv("S3_PREFIX", "assets")
+	cfg.S3.AccessKeyID = getEnv("S3_ACCESS_KEY_ID", "")
+	cfg.S3.SecretAccessKey = getEnv("S3_SECRET_ACCESS_KEY", "")
+	cfg.S3.UseSSL = getEnvBool("S3_USE_SSL", false)
+	cfg.S3.ForcePathStyle = getEnvBool("S3_FORCE_PATH_STYLE", true)
+
+	// Default resumable upload configuration
+	cfg.Uploads.StagingDir = getEnv("UPLOAD_STAGING_DIR", "./data/uploads")
+	cfg.Uploads.Expiry = getEnvDuration("UPLOAD_EXPIRY", 24*time.Hour)
+
+	// Default trash configuration
+	cfg.Trash.Retention = getEnvDuration("TRASH_RETENTION", 30*24*time.Hour)
+
 	return cfg
 }
 
@@ -65,3 +130,23 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// Helper function to get boolean environment variables with fallback
+func getEnvBool(key string, fallback bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+// Helper function to get duration environment variables with fallback
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles HTTP requests for maintenance operations
+type AdminHandler struct {
+	reconcileService *services.ReconcileService
+	searchService    *services.SearchService
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(reconcileService *services.ReconcileService, searchService *services.SearchService) *AdminHandler {
+	return &AdminHandler{
+		reconcileService: reconcileService,
+		searchService:    searchService,
+	}
+}
+
+// Reconcile handles GET and POST /api/admin/fsck
+func (h *AdminHandler) Reconcile(c *gin.Context) {
+	mode := models.ReconcileMode(c.DefaultQuery("mode", string(models.ReconcileModeReport)))
+
+	// Only a POST may change anything
+	if c.Request.Method == http.MethodGet && mode != models.ReconcileModeReport {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{
+			"error": "Repair and quarantine modes must be requested with POST",
+		})
+		return
+	}
+
+	gracePeriod := services.DefaultReconcileGracePeriod
+	if value := c.Query("gracePeriod"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid gracePeriod, expected a duration such as 30m",
+			})
+			return
+		}
+		gracePeriod = parsed
+	}
+
+	report, err := h.reconcileService.Reconcile(mode, gracePeriod)
+	if err != nil {
+		switch err {
+		case models.ErrInvalidReconcileMode:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid mode, expected report, repair or quarantine",
+			})
+		case services.ErrStorageNotListable:
+			c.JSON(http.StatusNotImplemented, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to reconcile assets: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Reindex handles POST /api/admin/reindex by queueing the extracted text of
+// every document to be indexed again
+func (h *AdminHandler) Reindex(c *gin.Context) {
+	queued, err := h.searchService.Reindex()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to queue reindexing: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"queued": queued,
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxListLimit caps the page size of asset lists
+const maxListLimit = 1000
+
+// parseAssetListQuery reads the filter, sort and page parameters shared by
+// the asset list endpoints:
+//
+//	type, folderId (or root), contentType (such as audio/*), minSize,
+//	maxSize, createdAfter, createdBefore, updatedAfter, updatedBefore
+//	(RFC 3339), name (a prefix), metadata.<key>=<value>, anyTags and
+//	allTags (comma separated tag names),
+//	sort (name, size, createdAt or updatedAt), order (asc or desc),
+//	limit and cursor
+//
+// Lists are sorted newest first by default. Without a limit every match is
+// returned in one page.
+func parseAssetListQuery(c *gin.Context) (*models.AssetListQuery, error) {
+	query := &models.AssetListQuery{
+		AssetFilter: models.AssetFilter{
+			Type:        models.AssetType(c.Query("type")),
+			ContentType: c.Query("contentType"),
+			NamePrefix:  c.Query("name"),
+		},
+		Sort:   models.AssetSort(c.DefaultQuery("sort", string(models.AssetSortCreatedAt))),
+		Cursor: c.Query("cursor"),
+	}
+
+	if folderID := c.Query("folderId"); folderID == "root" {
+		query.RootFolder = true
+	} else if folderID != "" {
+		query.FolderID = &folderID
+	}
+
+	if !query.Sort.IsValid() {
+		return nil, fmt.Errorf("sort must be name, size, createdAt or updatedAt")
+	}
+	switch order := c.Query("order"); order {
+	case "":
+		// Names read best A to Z, everything else newest or largest first
+		query.Descending = query.Sort != models.AssetSortName
+	case "asc", "desc":
+		query.Descending = order == "desc"
+	default:
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	for param, target := range map[string]**int64{"minSize": &query.MinSize, "maxSize": &query.MaxSize} {
+		if value := c.Query(param); value != "" {
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("%s must be a non-negative number of bytes", param)
+			}
+			*target = &size
+		}
+	}
+
+	times := map[string]**time.Time{
+		"createdAfter":  &query.CreatedAfter,
+		"createdBefore": &query.CreatedBefore,
+		"updatedAfter":  &query.UpdatedAfter,
+		"updatedBefore": &query.UpdatedBefore,
+	}
+	for param, target := range times {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an RFC 3339 time such as 2024-01-02T15:04:05Z", param)
+			}
+			*target = &t
+		}
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		query.Limit = limit
+	}
+
+	for param, target := range map[string]*[]string{"anyTags": &query.AnyTags, "allTags": &query.AllTags} {
+		if value := c.Query(param); value != "" {
+			tags, err := models.NormalizeTagNames(strings.Split(value, ","))
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a comma separated list of tag names", param)
+			}
+			*target = tags
+		}
+	}
+
+	for param, values := range c.Request.URL.Query() {
+		if key, ok := strings.CutPrefix(param, "metadata."); ok && key != "" && len(values) > 0 {
+			if query.Metadata == nil {
+				query.Metadata = make(map[string]string)
+			}
+			query.Metadata[key] = values[0]
+		}
+	}
+
+	return query, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/SaadBeidourii/MediaHub.git/internal/media"
 	"github.com/SaadBeidourii/MediaHub.git/internal/models"
 	"github.com/SaadBeidourii/MediaHub.git/internal/services"
 	"github.com/SaadBeidourii/MediaHub.git/pkg/validator"
@@ -11,54 +12,49 @@ import (
 )
 
 type AssetHandler struct {
-	assetService  *services.AssetService
-	mediaHandlers map[models.AssetType]MediaTypeHandler
+	assetService     *services.AssetService
+	renditionService *services.RenditionService
 }
 
-type MediaTypeHandler interface {
-	ValidateFile(fileHeader *gin.Context) error
-	HandleUpload(c *gin.Context, assetService *services.AssetService) (*models.Asset, error)
-}
-
-func NewAssetHandler(assetService *services.AssetService) *AssetHandler {
-	handler := &AssetHandler{
-		assetService:  assetService,
-		mediaHandlers: make(map[models.AssetType]MediaTypeHandler),
+func NewAssetHandler(assetService *services.AssetService, renditionService *services.RenditionService) *AssetHandler {
+	return &AssetHandler{
+		assetService:     assetService,
+		renditionService: renditionService,
 	}
-	handler.mediaHandlers[models.AssetTypePDF] = NewPDFHandler()
-	handler.mediaHandlers[models.AssetTypeEPUB] = NewEPUBHandler()
-	handler.mediaHandlers[models.AssetTypeAUDIO] = NewAudioHandler()
-
-	return handler
 }
 
-// ListAssets handles GET /api/assets
+// ListAssets handles GET /api/assets. See parseAssetListQuery for the
+// filter, sort and page parameters.
 func (h *AssetHandler) ListAssets(c *gin.Context) {
-	// Get all assets from the service
-	assets, err := h.assetService.GetAllAssets()
+	query, err := parseAssetListQuery(c)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	page, err := h.assetService.ListAssets(query)
+	if err != nil {
+		if err == models.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid cursor, it must come from a list with the same sort and order",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve assets",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"assets": assets,
-	})
+	c.JSON(http.StatusOK, page)
 }
 
-// HandleUpload is a generic upload handler for any media type
+// HandleUpload is a generic upload handler for any media type. Without an
+// asset type the media type is detected from the content.
 func (h *AssetHandler) HandleUpload(c *gin.Context, assetType models.AssetType) {
-	mediaHandler, exists := h.mediaHandlers[assetType]
-	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Unsupported media type: %s", assetType),
-		})
-		return
-	}
-
-	_, err := c.FormFile("file")
+	fileHeader, err := c.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "No file provided or invalid file",
@@ -66,36 +62,38 @@ func (h *AssetHandler) HandleUpload(c *gin.Context, assetType models.AssetType)
 		return
 	}
 
-	asset, err := mediaHandler.HandleUpload(c, h.assetService)
+	var asset *models.Asset
+	if assetType == "" {
+		asset, err = h.assetService.CreateDetectedAsset(fileHeader)
+	} else {
+		asset, err = h.assetService.CreateAsset(fileHeader, assetType)
+	}
 	if err != nil {
-		errorStatusCode := http.StatusInternalServerError
-		errorMessage := "Failed to process file: " + err.Error()
-
-		switch err {
-		case validator.ErrFileTooLarge:
-			errorStatusCode = http.StatusRequestEntityTooLarge
-			errorMessage = "File too large. Maximum size exceeded."
-		case validator.ErrInvalidFileType:
-			errorStatusCode = http.StatusBadRequest
-			errorMessage = fmt.Sprintf("Invalid file type. Only %s files are allowed.", assetType)
-		case validator.ErrEmptyFile:
-			errorStatusCode = http.StatusBadRequest
-			errorMessage = "Empty file"
-		}
-
+		errorStatusCode, errorMessage := uploadError(err, assetType)
 		c.JSON(errorStatusCode, gin.H{
 			"error": errorMessage,
 		})
 		return
 	}
 
+	// Let the client know when the same content was already uploaded. This is
+	// informational only, so a failed lookup doesn't fail the upload.
+	duplicates, _ := h.assetService.GetDuplicates(asset)
+
 	// Return success response
 	c.JSON(http.StatusCreated, models.AssetResponse{
-		Asset:  asset,
-		Status: "success",
+		Asset:      asset,
+		Status:     "success",
+		Duplicate:  len(duplicates) > 0,
+		Duplicates: duplicates,
 	})
 }
 
+// UploadAsset handles POST /api/assets
+func (h *AssetHandler) UploadAsset(c *gin.Context) {
+	h.HandleUpload(c, "")
+}
+
 // UploadPDF handles POST /api/assets/pdf
 func (h *AssetHandler) UploadPDF(c *gin.Context) {
 	h.HandleUpload(c, models.AssetTypePDF)
@@ -129,8 +127,28 @@ func (h *AssetHandler) GetAsset(c *gin.Context) {
 	c.JSON(http.StatusOK, asset)
 }
 
-// DownloadAsset handles GET /api/assets/:id/download
+// DownloadAsset handles GET and HEAD /api/assets/:id/download
 func (h *AssetHandler) DownloadAsset(c *gin.Context) {
+	h.serveContent(c, "attachment")
+}
+
+// StreamAsset handles GET and HEAD /api/assets/:id/content. Content is shown
+// inline where that is safe, unless ?disposition=attachment is given.
+func (h *AssetHandler) StreamAsset(c *gin.Context) {
+	disposition := c.DefaultQuery("disposition", "inline")
+	if disposition != "inline" && disposition != "attachment" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid disposition, expected inline or attachment",
+		})
+		return
+	}
+
+	h.serveContent(c, disposition)
+}
+
+// serveContent sends the content of the asset named in the URL with the
+// requested disposition
+func (h *AssetHandler) serveContent(c *gin.Context, disposition string) {
 	// Get the asset ID from the URL
 	assetID := c.Param("id")
 
@@ -143,8 +161,13 @@ func (h *AssetHandler) DownloadAsset(c *gin.Context) {
 		return
 	}
 
-	// Get the file content from storage
-	fileContent, err := h.assetService.GetAssetContent(assetID)
+	serveAsset(c, h.assetService, asset, disposition)
+}
+
+// serveAsset sends the content of an asset with the requested disposition
+func serveAsset(c *gin.Context, assetService *services.AssetService, asset *models.Asset, disposition string) {
+	// Open the content for seeking so byte ranges can be served
+	fileContent, err := assetService.OpenAssetContent(asset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve asset content",
@@ -153,13 +176,150 @@ func (h *AssetHandler) DownloadAsset(c *gin.Context) {
 	}
 	defer fileContent.Close()
 
-	// Set appropriate headers for download
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", asset.Name))
-	c.Header("Content-Type", asset.ContentType)
-	c.Header("Content-Length", fmt.Sprintf("%d", asset.Size))
+	// The stored content type came from the client, so only types that are
+	// known to be harmless in a browser are passed through
+	contentType, inline := safeContentType(asset.ContentType)
+	if !inline {
+		disposition = "attachment"
+	}
+
+	c.Header("Content-Disposition", contentDisposition(disposition, asset.Name))
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("ETag", contentETag(asset))
+
+	// ServeContent handles Range, If-Range, If-None-Match and If-Modified-Since.
+	// Content never changes after upload, so its creation time is the
+	// modification time.
+	http.ServeContent(c.Writer, c.Request, asset.Name, asset.CreatedAt, fileContent)
+}
+
+// GetAssetCover handles GET and HEAD /api/assets/:id/cover
+func (h *AssetHandler) GetAssetCover(c *gin.Context) {
+	h.serveRendition(c, models.RenditionKindCover)
+}
+
+// GetAssetRendition handles GET and HEAD /api/assets/:id/renditions/:kind
+func (h *AssetHandler) GetAssetRendition(c *gin.Context) {
+	h.serveRendition(c, models.RenditionKind(c.Param("kind")))
+}
+
+// ListAssetRenditions handles GET /api/assets/:id/renditions
+func (h *AssetHandler) ListAssetRenditions(c *gin.Context) {
+	// Get the asset ID from the URL
+	assetID := c.Param("id")
+
+	renditions, err := h.renditionService.GetRenditions(assetID)
+	if err != nil {
+		if err == models.ErrAssetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Asset not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get renditions: " + err.Error(),
+		})
+		return
+	}
+
+	if renditions == nil {
+		renditions = []*models.Rendition{}
+	}
+
+	c.JSON(http.StatusOK, renditions)
+}
+
+// serveRendition sends the rendition of the given kind of the asset named
+// in the URL
+func (h *AssetHandler) serveRendition(c *gin.Context, kind models.RenditionKind) {
+	// Get the asset ID from the URL
+	assetID := c.Param("id")
+
+	rendition, err := h.renditionService.GetRendition(assetID, kind)
+	if err != nil {
+		switch err {
+		case models.ErrInvalidRenditionKind:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid rendition kind: " + string(kind),
+			})
+		case models.ErrAssetNotFound:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Asset not found",
+			})
+		case models.ErrRenditionNotProduced:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Rendition kind " + string(kind) + " is not produced for this type of asset",
+			})
+		case models.ErrRenditionNotFound:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Rendition not available: it has not been generated yet or the content has none",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get rendition: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	renditionContent, err := h.renditionService.OpenRendition(rendition)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve rendition",
+		})
+		return
+	}
+	defer renditionContent.Close()
+
+	// Renditions are generated by us, images only after sniffing them as
+	// raster formats, so their type can be trusted
+	c.Header("Content-Type", rendition.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Header("ETag", `"`+rendition.Digest+`"`)
 
-	// Stream the file content to the client
-	c.DataFromReader(http.StatusOK, asset.Size, asset.ContentType, fileContent, nil)
+	http.ServeContent(c.Writer, c.Request, "", rendition.CreatedAt, renditionContent)
+}
+
+// UpdateAsset handles PUT /api/assets/:id
+func (h *AssetHandler) UpdateAsset(c *gin.Context) {
+	// Get the asset ID from the URL
+	assetID := c.Param("id")
+
+	var request models.AssetUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	asset, err := h.assetService.UpdateAsset(assetID, &request)
+	if err != nil {
+		switch err {
+		case models.ErrAssetNotFound:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Asset not found",
+			})
+		case models.ErrInvalidAssetName:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request: name must not be blank",
+			})
+		case models.ErrPathExists:
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "An asset with this name already exists in the folder",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update asset: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, asset)
 }
 
 // DeleteAsset handles DELETE /api/assets/:id
@@ -185,6 +345,36 @@ func (h *AssetHandler) DeleteAsset(c *gin.Context) {
 	// Return success response
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
-		"message": "Asset deleted successfully",
+		"message": "Asset moved to trash",
 	})
 }
+
+// uploadError maps an error from the upload pipeline to a status code and message
+func uploadError(err error, assetType models.AssetType) (int, string) {
+	switch err {
+	case media.ErrUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType, "Unsupported media type"
+	case validator.ErrFileTooLarge:
+		return http.StatusRequestEntityTooLarge, "File too large. Maximum size exceeded."
+	case validator.ErrInvalidFileType:
+		if assetType == "" {
+			return http.StatusBadRequest, "Invalid file type"
+		}
+		return http.StatusBadRequest, fmt.Sprintf("Invalid file type. Only %s files are allowed.", assetType)
+	case validator.ErrEmptyFile:
+		return http.StatusBadRequest, "Empty file"
+	case models.ErrPathExists:
+		return http.StatusConflict, "An asset with this name already exists in the folder"
+	default:
+		return http.StatusInternalServerError, "Failed to process file: " + err.Error()
+	}
+}
+
+// contentETag returns a strong validator for the content of an asset.
+// Deduplicated content is identified by its digest, older content by its asset.
+func contentETag(asset *models.Asset) string {
+	if asset.Digest != "" {
+		return `"` + asset.Digest + `"`
+	}
+	return `"` + asset.ID + `"`
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/media"
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CollectionHandler handles HTTP requests for collections
+type CollectionHandler struct {
+	collectionService *services.CollectionService
+}
+
+// NewCollectionHandler creates a new CollectionHandler
+func NewCollectionHandler(collectionService *services.CollectionService) *CollectionHandler {
+	return &CollectionHandler{
+		collectionService: collectionService,
+	}
+}
+
+// CreateCollection handles POST /api/collections
+func (h *CollectionHandler) CreateCollection(c *gin.Context) {
+	var request models.CollectionCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	collection, err := h.collectionService.CreateCollection(&request)
+	if err != nil {
+		respondCollectionError(c, "Failed to create collection", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+// ListCollections handles GET /api/collections
+func (h *CollectionHandler) ListCollections(c *gin.Context) {
+	collections, err := h.collectionService.GetAllCollections()
+	if err != nil {
+		respondCollectionError(c, "Failed to get collections", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collections)
+}
+
+// GetCollection handles GET /api/collections/:id
+func (h *CollectionHandler) GetCollection(c *gin.Context) {
+	collection, err := h.collectionService.GetCollection(c.Param("id"))
+	if err != nil {
+		respondCollectionError(c, "Failed to get collection", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// UpdateCollection handles PUT /api/collections/:id
+func (h *CollectionHandler) UpdateCollection(c *gin.Context) {
+	var request models.CollectionUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	collection, err := h.collectionService.UpdateCollection(c.Param("id"), &request)
+	if err != nil {
+		respondCollectionError(c, "Failed to update collection", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection handles DELETE /api/collections/:id
+func (h *CollectionHandler) DeleteCollection(c *gin.Context) {
+	if err := h.collectionService.DeleteCollection(c.Param("id")); err != nil {
+		respondCollectionError(c, "Failed to delete collection", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collection deleted successfully",
+	})
+}
+
+// ListItems handles GET /api/collections/:id/items
+func (h *CollectionHandler) ListItems(c *gin.Context) {
+	items, err := h.collectionService.GetItems(c.Param("id"))
+	if err != nil {
+		respondCollectionError(c, "Failed to get collection items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// AddItems handles POST /api/collections/:id/items
+func (h *CollectionHandler) AddItems(c *gin.Context) {
+	var request models.CollectionItemsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	items, err := h.collectionService.AddItems(c.Param("id"), &request)
+	if err != nil {
+		respondCollectionError(c, "Failed to add collection items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// ReorderItems handles PUT /api/collections/:id/items
+func (h *CollectionHandler) ReorderItems(c *gin.Context) {
+	var request models.CollectionOrderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	items, err := h.collectionService.ReorderItems(c.Param("id"), &request)
+	if err != nil {
+		respondCollectionError(c, "Failed to reorder collection items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// MoveItem handles PUT /api/collections/:id/items/:assetId
+func (h *CollectionHandler) MoveItem(c *gin.Context) {
+	var request models.CollectionItemMoveRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	items, err := h.collectionService.MoveItem(c.Param("id"), c.Param("assetId"), *request.Position)
+	if err != nil {
+		respondCollectionError(c, "Failed to move collection item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// RemoveItem handles DELETE /api/collections/:id/items/:assetId
+func (h *CollectionHandler) RemoveItem(c *gin.Context) {
+	items, err := h.collectionService.RemoveItem(c.Param("id"), c.Param("assetId"))
+	if err != nil {
+		respondCollectionError(c, "Failed to remove collection item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// ListAssetCollections handles GET /api/assets/:id/collections
+func (h *CollectionHandler) ListAssetCollections(c *gin.Context) {
+	collections, err := h.collectionService.GetAssetCollections(c.Param("id"))
+	if err != nil {
+		respondCollectionError(c, "Failed to get collections", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collections)
+}
+
+// respondCollectionError maps the errors of collection operations to responses
+func respondCollectionError(c *gin.Context, message string, err error) {
+	switch err {
+	case models.ErrCollectionNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Collection not found",
+		})
+	case models.ErrAssetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Asset not found",
+		})
+	case models.ErrCollectionItemNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Asset is not in the collection",
+		})
+	case models.ErrInvalidCollectionName:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: name must not be blank",
+		})
+	case media.ErrUnsupportedMediaType:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: unknown asset type",
+		})
+	case models.ErrCollectionTypeMismatch:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "The collection only accepts assets of its type",
+		})
+	case models.ErrInvalidCollectionOrder:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: order must list every item of the collection exactly once",
+		})
+	case models.ErrInvalidItemPosition:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: position is outside the collection",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": message + ": " + err.Error(),
+		})
+	}
+}
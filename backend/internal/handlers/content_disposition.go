@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"mime"
+	"strings"
+)
+
+// inlineContentTypes are the content types a browser may render in place,
+// besides audio and video
+var inlineContentTypes = map[string]bool{
+	"application/pdf": true,
+	"text/plain":      true,
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+}
+
+// scriptableContentTypes can run script when a browser opens them, as can
+// any other XML based type
+var scriptableContentTypes = map[string]bool{
+	"text/html":                true,
+	"application/xhtml+xml":    true,
+	"image/svg+xml":            true,
+	"text/xml":                 true,
+	"application/xml":          true,
+	"text/javascript":          true,
+	"application/javascript":   true,
+	"application/x-javascript": true,
+}
+
+// safeContentType normalizes a stored content type and reports whether it may
+// be shown inline. Unparsable and scriptable types are replaced with
+// application/octet-stream so the browser never interprets them.
+func safeContentType(contentType string) (string, bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || scriptableContentTypes[mediaType] || strings.HasSuffix(mediaType, "+xml") {
+		return "application/octet-stream", false
+	}
+
+	inline := inlineContentTypes[mediaType] ||
+		strings.HasPrefix(mediaType, "audio/") ||
+		strings.HasPrefix(mediaType, "video/")
+
+	// Keep only the charset, other parameters have no business here
+	if charset, ok := params["charset"]; ok {
+		return mime.FormatMediaType(mediaType, map[string]string{"charset": charset}), inline
+	}
+	return mediaType, inline
+}
+
+// contentDisposition builds a Content-Disposition header as described in
+// RFC 6266. The filename parameter carries an ASCII fallback for old clients
+// and filename* the exact name, percent-encoded as UTF-8 per RFC 5987.
+func contentDisposition(disposition string, filename string) string {
+	var b strings.Builder
+	b.WriteString(disposition)
+	b.WriteString(`; filename="`)
+	b.WriteString(asciiFilename(filename))
+	b.WriteString(`"; filename*=UTF-8''`)
+	b.WriteString(encodeRFC5987(filename))
+	return b.String()
+}
+
+// asciiFilename replaces everything that can't appear in a quoted ASCII
+// filename parameter with an underscore
+func asciiFilename(filename string) string {
+	var b strings.Builder
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('_')
+		case r < 0x20 || r >= 0x7f:
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// encodeRFC5987 percent-encodes every byte of s outside the attr-char set
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAttrChar(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
+
+// isAttrChar reports whether c may appear unencoded in an RFC 5987 value
+func isAttrChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/SaadBeidourii/MediaHub.git/internal/models"
 	"github.com/SaadBeidourii/MediaHub.git/internal/services"
@@ -32,6 +34,13 @@ func (h *FolderHandler) CreateFolder(c *gin.Context) {
 
 	folder, err := h.folderService.CreateFolder(&request)
 	if err != nil {
+		if err == models.ErrFolderNameExists {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "A folder with this name already exists in the parent",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create folder: " + err.Error(),
 		})
@@ -114,6 +123,13 @@ func (h *FolderHandler) UpdateFolder(c *gin.Context) {
 			return
 		}
 
+		if err == models.ErrFolderNameExists {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "A folder with this name already exists in the parent",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update folder: " + err.Error(),
 		})
@@ -123,31 +139,49 @@ func (h *FolderHandler) UpdateFolder(c *gin.Context) {
 	c.JSON(http.StatusOK, folder)
 }
 
-// DeleteFolder handles DELETE /api/folders/:id
+// DeleteFolder handles DELETE /api/folders/:id. The mode query parameter
+// picks what happens to the folder's contents; without one the folder goes
+// to the trash.
 func (h *FolderHandler) DeleteFolder(c *gin.Context) {
 	folderID := c.Param("id")
+	mode := models.FolderDeleteMode(c.Query("mode"))
 
-	if err := h.folderService.DeleteFolder(folderID); err != nil {
-		if err == models.ErrFolderNotFound {
+	summary, err := h.folderService.DeleteFolder(folderID, mode)
+	if err != nil {
+		switch err {
+		case models.ErrFolderNotFound:
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Folder not found",
 			})
-			return
+		case models.ErrInvalidFolderDeleteMode:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid mode: must be trash, refuse-if-not-empty, move-contents-to-parent or delete-recursively",
+			})
+		case models.ErrFolderNotEmpty:
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Folder is not empty",
+			})
+		case models.ErrFolderNameExists:
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "A subfolder has the same name as a folder in the parent",
+			})
+		case models.ErrPathExists:
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "An asset has the same name as an asset in the parent",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to delete folder: " + err.Error(),
+			})
 		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete folder: " + err.Error(),
-		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Folder deleted successfully",
-	})
+	c.JSON(http.StatusOK, summary)
 }
 
-// GetFolderContents handles GET /api/folders/:id/contents
+// GetFolderContents handles GET /api/folders/:id/contents. Assets take the
+// same filter, sort and page parameters as ListAssets.
 func (h *FolderHandler) GetFolderContents(c *gin.Context) {
 	folderID := c.Param("id")
 
@@ -157,8 +191,16 @@ func (h *FolderHandler) GetFolderContents(c *gin.Context) {
 		folderIDPtr = &folderID
 	}
 
+	query, err := parseAssetListQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
 	// Get folder contents (both assets and subfolders)
-	contents, err := h.folderService.GetFolderContents(folderIDPtr)
+	contents, err := h.folderService.GetFolderContents(folderIDPtr, query)
 	if err != nil {
 		if err == models.ErrFolderNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -166,6 +208,12 @@ func (h *FolderHandler) GetFolderContents(c *gin.Context) {
 			})
 			return
 		}
+		if err == models.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid cursor, it must come from a list with the same sort and order",
+			})
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get folder contents: " + err.Error(),
@@ -200,6 +248,11 @@ func (h *FolderHandler) MoveAsset(c *gin.Context) {
 				"error": "Folder not found",
 			})
 			return
+		} else if err == models.ErrPathExists {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "An asset with this name already exists in the folder",
+			})
+			return
 		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -237,3 +290,100 @@ func (h *FolderHandler) GetFolderPath(c *gin.Context) {
 		"path": path,
 	})
 }
+
+// treeDepth parses the optional depth query parameter, 0 meaning no limit
+func treeDepth(c *gin.Context) (int, error) {
+	value := c.Query("depth")
+	if value == "" {
+		return 0, nil
+	}
+
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 1 {
+		return 0, errors.New("depth must be a positive number")
+	}
+
+	return depth, nil
+}
+
+// GetFolderTree handles GET /api/folders/tree
+func (h *FolderHandler) GetFolderTree(c *gin.Context) {
+	depth, err := treeDepth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	tree, err := h.folderService.GetFolderTree(depth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get folder tree: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"folders": tree,
+	})
+}
+
+// GetFolderSubtree handles GET /api/folders/:id/tree
+func (h *FolderHandler) GetFolderSubtree(c *gin.Context) {
+	folderID := c.Param("id")
+
+	depth, err := treeDepth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	tree, err := h.folderService.GetSubtree(folderID, depth)
+	if err != nil {
+		if err == models.ErrFolderNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Folder not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get folder tree: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+// GetFolderStats handles GET /api/folders/:id/stats. The "root" ID gives
+// the numbers for the whole library.
+func (h *FolderHandler) GetFolderStats(c *gin.Context) {
+	folderID := c.Param("id")
+
+	// For "root" folder, use nil
+	var folderIDPtr *string
+	if folderID != "root" {
+		folderIDPtr = &folderID
+	}
+
+	stats, err := h.folderService.GetFolderStats(folderIDPtr)
+	if err != nil {
+		if err == models.ErrFolderNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Folder not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get folder stats: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
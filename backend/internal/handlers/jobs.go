@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler handles HTTP requests for background jobs
+type JobHandler struct {
+	jobService   *services.JobService
+	assetService *services.AssetService
+}
+
+// NewJobHandler creates a new JobHandler
+func NewJobHandler(jobService *services.JobService, assetService *services.AssetService) *JobHandler {
+	return &JobHandler{
+		jobService:   jobService,
+		assetService: assetService,
+	}
+}
+
+// GetJob handles GET /api/jobs/:id
+func (h *JobHandler) GetJob(c *gin.Context) {
+	jobID := c.Param("id")
+
+	job, err := h.jobService.GetJob(jobID)
+	if err != nil {
+		if err == models.ErrJobNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get job: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ListAssetJobs handles GET /api/assets/:id/jobs
+func (h *JobHandler) ListAssetJobs(c *gin.Context) {
+	assetID := c.Param("id")
+
+	if _, err := h.assetService.GetAsset(assetID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Asset not found",
+		})
+		return
+	}
+
+	jobs, err := h.jobService.GetAssetJobs(assetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get jobs: " + err.Error(),
+		})
+		return
+	}
+
+	if jobs == nil {
+		jobs = []*models.Job{}
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// PathHandler handles HTTP requests that address folders and assets by path
+type PathHandler struct {
+	pathService   *services.PathService
+	folderService *services.FolderService
+	assetService  *services.AssetService
+}
+
+// NewPathHandler creates a new PathHandler
+func NewPathHandler(pathService *services.PathService, folderService *services.FolderService, assetService *services.AssetService) *PathHandler {
+	return &PathHandler{
+		pathService:   pathService,
+		folderService: folderService,
+		assetService:  assetService,
+	}
+}
+
+// GetPath handles GET and HEAD /api/fs/*path. A folder comes back with its
+// contents, which take the same parameters as GetFolderContents. An asset
+// comes back as metadata, or as its content with ?download=true.
+func (h *PathHandler) GetPath(c *gin.Context) {
+	entry, err := h.pathService.Resolve(c.Param("path"))
+	if err != nil {
+		respondPathError(c, "Failed to resolve path", err)
+		return
+	}
+
+	download := c.Query("download") == "true"
+
+	if entry.Type == models.PathEntryTypeAsset {
+		if download {
+			serveAsset(c, h.assetService, entry.Asset, "attachment")
+			return
+		}
+		c.JSON(http.StatusOK, entry)
+		return
+	}
+
+	if download {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Only assets can be downloaded",
+		})
+		return
+	}
+
+	query, err := parseAssetListQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	var folderID *string
+	if entry.Folder != nil {
+		folderID = &entry.Folder.ID
+	}
+
+	entry.Contents, err = h.folderService.GetFolderContents(folderID, query)
+	if err != nil {
+		if err == models.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid cursor, it must come from a list with the same sort and order",
+			})
+			return
+		}
+		respondPathError(c, "Failed to get folder contents", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// UploadPath handles PUT /api/fs/*path, storing the uploaded file at the
+// path and creating any missing folders along it
+func (h *PathHandler) UploadPath(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No file provided or invalid file",
+		})
+		return
+	}
+
+	asset, err := h.pathService.UploadAsset(c.Param("path"), fileHeader)
+	if err != nil {
+		switch err {
+		case models.ErrInvalidPath, models.ErrPathExists, models.ErrFolderNameExists:
+			respondPathError(c, "Failed to upload asset", err)
+		default:
+			errorStatusCode, errorMessage := uploadError(err, "")
+			c.JSON(errorStatusCode, gin.H{
+				"error": errorMessage,
+			})
+		}
+		return
+	}
+
+	// Informational only, as for other uploads
+	duplicates, _ := h.assetService.GetDuplicates(asset)
+
+	c.JSON(http.StatusCreated, models.AssetResponse{
+		Asset:      asset,
+		Status:     "success",
+		Duplicate:  len(duplicates) > 0,
+		Duplicates: duplicates,
+	})
+}
+
+// respondPathError maps path errors to HTTP responses
+func respondPathError(c *gin.Context, message string, err error) {
+	switch err {
+	case models.ErrInvalidPath:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid path",
+		})
+	case models.ErrPathNotFound, models.ErrFolderNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Nothing found at this path",
+		})
+	case models.ErrAmbiguousPath:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "More than one asset has this path, use its ID instead",
+		})
+	case models.ErrPathExists, models.ErrFolderNameExists:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A folder or asset already exists at this path",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": message + ": " + err.Error(),
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/media"
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SearchHandler handles HTTP requests for full-text search
+type SearchHandler struct {
+	searchService *services.SearchService
+}
+
+// NewSearchHandler creates a new SearchHandler
+func NewSearchHandler(searchService *services.SearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// Search handles GET /api/search. The q parameter takes web search syntax;
+// type and folderId narrow the results, with folderId=root for assets
+// outside any folder. Results are paged with limit and offset.
+func (h *SearchHandler) Search(c *gin.Context) {
+	query := &models.SearchQuery{
+		Text: c.Query("q"),
+		Type: models.AssetType(c.Query("type")),
+	}
+
+	if folderID := c.Query("folderId"); folderID == "root" {
+		query.RootFolder = true
+	} else if folderID != "" {
+		query.FolderID = &folderID
+	}
+
+	for param, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid " + param + ", expected a non-negative integer",
+			})
+			return
+		}
+		*target = parsed
+	}
+
+	results, err := h.searchService.Search(query)
+	if err != nil {
+		switch err {
+		case models.ErrEmptySearchQuery:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Missing search query, expected q",
+			})
+		case media.ErrUnsupportedMediaType:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid type: " + string(query.Type),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to search assets: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   query.Text,
+		"results": results,
+		"limit":   query.Limit,
+		"offset":  query.Offset,
+	})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// TagHandler handles HTTP requests for tags
+type TagHandler struct {
+	tagService *services.TagService
+}
+
+// NewTagHandler creates a new TagHandler
+func NewTagHandler(tagService *services.TagService) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+	}
+}
+
+// ListTags handles GET /api/tags
+func (h *TagHandler) ListTags(c *gin.Context) {
+	tags, err := h.tagService.GetAllTags()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get tags: " + err.Error(),
+		})
+		return
+	}
+
+	if tags == nil {
+		tags = []*models.Tag{}
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// TagAsset handles POST /api/assets/:id/tags
+func (h *TagHandler) TagAsset(c *gin.Context) {
+	assetID := c.Param("id")
+
+	var request models.AssetTagsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	asset, err := h.tagService.TagAsset(assetID, request.Tags)
+	if err != nil {
+		respondTagError(c, "Failed to tag asset", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, asset)
+}
+
+// UntagAsset handles DELETE /api/assets/:id/tags/:tag
+func (h *TagHandler) UntagAsset(c *gin.Context) {
+	assetID := c.Param("id")
+
+	asset, err := h.tagService.UntagAsset(assetID, c.Param("tag"))
+	if err != nil {
+		respondTagError(c, "Failed to untag asset", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, asset)
+}
+
+// UpdateTag handles PUT /api/tags/:id
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	tagID := c.Param("id")
+
+	var request models.TagUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	tag, err := h.tagService.RenameTag(tagID, request.Name)
+	if err != nil {
+		respondTagError(c, "Failed to rename tag", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// MergeTag handles POST /api/tags/:id/merge
+func (h *TagHandler) MergeTag(c *gin.Context) {
+	tagID := c.Param("id")
+
+	var request models.TagMergeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	tag, err := h.tagService.MergeTags(tagID, request.TargetID)
+	if err != nil {
+		respondTagError(c, "Failed to merge tags", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag handles DELETE /api/tags/:id
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	tagID := c.Param("id")
+
+	if err := h.tagService.DeleteTag(tagID); err != nil {
+		respondTagError(c, "Failed to delete tag", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag deleted successfully",
+	})
+}
+
+// respondTagError maps the errors of tag operations to responses
+func respondTagError(c *gin.Context, message string, err error) {
+	switch err {
+	case models.ErrAssetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Asset not found",
+		})
+	case models.ErrTagNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Tag not found",
+		})
+	case models.ErrInvalidTagName:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: tag names must be 1 to 64 characters without commas or slashes",
+		})
+	case models.ErrTagExists:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A tag with this name already exists, merge the tags instead",
+		})
+	case models.ErrTagMergeSelf:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request: a tag cannot be merged into itself",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": message + ": " + err.Error(),
+		})
+	}
+}
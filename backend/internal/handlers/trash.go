@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// TrashHandler handles HTTP requests for deleted assets and folders
+type TrashHandler struct {
+	trashService *services.TrashService
+}
+
+// NewTrashHandler creates a new TrashHandler
+func NewTrashHandler(trashService *services.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+// ListTrash handles GET /api/trash
+func (h *TrashHandler) ListTrash(c *gin.Context) {
+	items, err := h.trashService.GetTrash()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get trash: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// GetTrashItem handles GET /api/trash/:id
+func (h *TrashHandler) GetTrashItem(c *gin.Context) {
+	item, err := h.trashService.GetTrashItem(c.Param("id"))
+	if err != nil {
+		respondTrashError(c, "Failed to get trash item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// RestoreTrashItem handles POST /api/trash/:id/restore
+func (h *TrashHandler) RestoreTrashItem(c *gin.Context) {
+	result, err := h.trashService.Restore(c.Param("id"))
+	if err != nil {
+		respondTrashError(c, "Failed to restore trash item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// PurgeTrashItem handles DELETE /api/trash/:id
+func (h *TrashHandler) PurgeTrashItem(c *gin.Context) {
+	item, err := h.trashService.Purge(c.Param("id"))
+	if err != nil {
+		respondTrashError(c, "Failed to purge trash item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Permanently deleted",
+		"item":    item,
+	})
+}
+
+// EmptyTrash handles DELETE /api/trash
+func (h *TrashHandler) EmptyTrash(c *gin.Context) {
+	removed, err := h.trashService.EmptyTrash()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  "Failed to empty trash: " + err.Error(),
+			"purged": removed,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"purged": removed,
+	})
+}
+
+// respondTrashError maps the errors of trash operations to responses
+func respondTrashError(c *gin.Context, message string, err error) {
+	if err == models.ErrTrashItemNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Trash item not found",
+		})
+		return
+	}
+
+	if err == models.ErrFolderNameExists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A folder with this name already exists where the item would be restored",
+		})
+		return
+	}
+
+	if err == models.ErrPathExists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An asset with this name already exists where the item would be restored",
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": message + ": " + err.Error(),
+	})
+}
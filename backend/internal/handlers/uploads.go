@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// tusVersion is the only version of the tus protocol we speak
+	tusVersion = "1.0.0"
+	// tusExtensions lists the tus extensions we support
+	tusExtensions = "creation,termination,expiration"
+	// tusContentType is the required content type of PATCH requests
+	tusContentType = "application/offset+octet-stream"
+)
+
+// UploadHandler handles resumable uploads using the tus protocol
+type UploadHandler struct {
+	uploadService *services.UploadService
+}
+
+// NewUploadHandler creates a new UploadHandler
+func NewUploadHandler(uploadService *services.UploadService) *UploadHandler {
+	return &UploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// TusResumable sets the Tus-Resumable header on every response and rejects
+// tus requests from clients speaking another protocol version
+func (h *UploadHandler) TusResumable(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+
+	switch c.Request.Method {
+	case http.MethodOptions, http.MethodGet:
+		// Discovery and status lookups don't require the header
+	default:
+		if c.GetHeader("Tus-Resumable") != tusVersion {
+			c.Header("Tus-Version", tusVersion)
+			c.AbortWithStatus(http.StatusPreconditionFailed)
+			return
+		}
+	}
+
+	c.Next()
+}
+
+// Options handles OPTIONS /api/uploads
+func (h *UploadHandler) Options(c *gin.Context) {
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(h.uploadService.MaxUploadSize(), 10))
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUpload handles POST /api/uploads
+func (h *UploadHandler) CreateUpload(c *gin.Context) {
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing or invalid Upload-Length header",
+		})
+		return
+	}
+
+	metadata, err := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid Upload-Metadata header",
+		})
+		return
+	}
+
+	upload, err := h.uploadService.CreateUpload(length, metadata)
+	if err != nil {
+		switch err {
+		case models.ErrUploadInvalidLength:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Missing or invalid Upload-Length header",
+			})
+		case models.ErrUploadInvalidType:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Upload-Metadata names an unsupported assetType",
+			})
+		default:
+			errorStatusCode, errorMessage := uploadError(err, models.AssetType(metadata["assetType"]))
+			c.JSON(errorStatusCode, gin.H{
+				"error": errorMessage,
+			})
+		}
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/uploads/%s", upload.ID))
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// GetUploadOffset handles HEAD /api/uploads/:id
+func (h *UploadHandler) GetUploadOffset(c *gin.Context) {
+	upload, err := h.uploadService.GetUpload(c.Param("id"))
+	if err != nil {
+		if err == models.ErrUploadNotFound {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusOK)
+}
+
+// GetUpload handles GET /api/uploads/:id and reports progress, including the
+// asset created once the upload has finished
+func (h *UploadHandler) GetUpload(c *gin.Context) {
+	upload, err := h.uploadService.GetUpload(c.Param("id"))
+	if err != nil {
+		if err == models.ErrUploadNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Upload not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve upload",
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, upload)
+}
+
+// WriteChunk handles PATCH /api/uploads/:id
+func (h *UploadHandler) WriteChunk(c *gin.Context) {
+	if c.ContentType() != tusContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "Content-Type must be " + tusContentType,
+		})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing or invalid Upload-Offset header",
+		})
+		return
+	}
+
+	upload, err := h.uploadService.WriteChunk(c.Param("id"), offset, c.Request.Body)
+	if err != nil {
+		switch err {
+		case models.ErrUploadNotFound:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Upload not found",
+			})
+		case models.ErrUploadOffsetMismatch:
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Upload-Offset does not match the current offset",
+			})
+		case models.ErrUploadComplete:
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Upload is already complete",
+			})
+		case models.ErrUploadTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "Chunk exceeds the declared Upload-Length",
+			})
+		default:
+			var assetType models.AssetType
+			if upload != nil {
+				assetType = upload.Type
+			}
+			errorStatusCode, errorMessage := uploadError(err, assetType)
+			c.JSON(errorStatusCode, gin.H{
+				"error": errorMessage,
+			})
+		}
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusNoContent)
+}
+
+// TerminateUpload handles DELETE /api/uploads/:id
+func (h *UploadHandler) TerminateUpload(c *gin.Context) {
+	if err := h.uploadService.TerminateUpload(c.Param("id")); err != nil {
+		if err == models.ErrUploadNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Upload not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to terminate upload: " + err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// setUploadHeaders describes the state of an upload in tus response headers
+func setUploadHeaders(c *gin.Context, upload *models.Upload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// parseUploadMetadata decodes an Upload-Metadata header, a comma separated
+// list of keys each optionally followed by a space and a base64 value
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		switch len(fields) {
+		case 1:
+			metadata[fields[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			metadata[fields[0]] = string(value)
+		default:
+			return nil, fmt.Errorf("invalid metadata entry: %q", pair)
+		}
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,111 @@
+package media
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"mime/multipart"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/audio"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/thumbnail"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/validator"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// AudioMediaType handles audio files
+type AudioMediaType struct{}
+
+// NewAudioMediaType creates a new AudioMediaType
+func NewAudioMediaType() *AudioMediaType {
+	return &AudioMediaType{}
+}
+
+// AssetType implements MediaType
+func (m *AudioMediaType) AssetType() models.AssetType {
+	return models.AssetTypeAUDIO
+}
+
+// MaxSize implements MediaType
+func (m *AudioMediaType) MaxSize() int64 {
+	return validator.MaxAudioSize
+}
+
+// Matches implements MediaType
+func (m *AudioMediaType) Matches(mime *mimetype.MIME) bool {
+	return validator.IsAudioMIME(mime)
+}
+
+// Validate implements MediaType
+func (m *AudioMediaType) Validate(file multipart.File, size int64) error {
+	return validator.ValidateAudio(file, size)
+}
+
+// ExtractMetadata implements MediaType by reading the tags and stream
+// headers of the file
+func (m *AudioMediaType) ExtractMetadata(file multipart.File, size int64) (map[string]interface{}, error) {
+	meta, err := audio.Read(file, size)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]interface{})
+	setString(metadata, "audioFormat", meta.Format)
+	setString(metadata, "title", meta.Title)
+	setString(metadata, "artist", meta.Artist)
+	setString(metadata, "album", meta.Album)
+	setString(metadata, "albumArtist", meta.AlbumArtist)
+	setString(metadata, "genre", meta.Genre)
+	setInt(metadata, "year", meta.Year)
+	setInt(metadata, "trackNumber", meta.Track)
+	setInt(metadata, "trackTotal", meta.TrackTotal)
+	setInt(metadata, "discNumber", meta.Disc)
+	setInt(metadata, "discTotal", meta.DiscTotal)
+	setInt(metadata, "bitrate", meta.Bitrate)
+	setInt(metadata, "sampleRate", meta.SampleRate)
+	setInt(metadata, "channels", meta.Channels)
+	if meta.Duration > 0 {
+		// Seconds, rounded to the millisecond
+		metadata["duration"] = math.Round(meta.Duration.Seconds()*1000) / 1000
+	}
+
+	return metadata, nil
+}
+
+// ExtractCover implements CoverExtractor with the artwork embedded in the tags
+func (m *AudioMediaType) ExtractCover(file multipart.File, size int64) (*Cover, error) {
+	meta, err := audio.Read(file, size)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Picture == nil {
+		return nil, nil
+	}
+
+	return newCover(meta.Picture.Data)
+}
+
+// ExtractWaveform implements WaveformExtractor for uncompressed audio.
+// Compressed formats return no waveform rather than an error.
+func (m *AudioMediaType) ExtractWaveform(file multipart.File, size int64) ([]float64, error) {
+	peaks, err := audio.Waveform(file, size, waveformPoints)
+	if errors.Is(err, audio.ErrUnsupportedFormat) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Three decimals are plenty to draw with and keep the JSON small
+	for i, peak := range peaks {
+		peaks[i] = math.Round(peak*1000) / 1000
+	}
+	return peaks, nil
+}
+
+// Placeholder implements PlaceholderDrawer with a blue page for audio
+// without artwork
+func (m *AudioMediaType) Placeholder(size int) image.Image {
+	return thumbnail.Placeholder(size, color.RGBA{R: 0x19, G: 0x76, B: 0xd2, A: 0xff})
+}
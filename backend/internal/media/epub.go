@@ -0,0 +1,116 @@
+package media
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"mime/multipart"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/epub"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/thumbnail"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/validator"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// EPUBMediaType handles EPUB books
+type EPUBMediaType struct{}
+
+// NewEPUBMediaType creates a new EPUBMediaType
+func NewEPUBMediaType() *EPUBMediaType {
+	return &EPUBMediaType{}
+}
+
+// AssetType implements MediaType
+func (m *EPUBMediaType) AssetType() models.AssetType {
+	return models.AssetTypeEPUB
+}
+
+// MaxSize implements MediaType
+func (m *EPUBMediaType) MaxSize() int64 {
+	return validator.MaxEPUBSize
+}
+
+// Matches implements MediaType
+func (m *EPUBMediaType) Matches(mime *mimetype.MIME) bool {
+	return validator.IsEPUBMIME(mime)
+}
+
+// Validate implements MediaType
+func (m *EPUBMediaType) Validate(file multipart.File, size int64) error {
+	return validator.ValidateEPUB(file, size)
+}
+
+// ExtractMetadata implements MediaType by reading the OPF package document
+func (m *EPUBMediaType) ExtractMetadata(file multipart.File, size int64) (map[string]interface{}, error) {
+	book, err := epub.Open(file, size)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := book.Metadata()
+	metadata := make(map[string]interface{})
+	setString(metadata, "epubVersion", meta.Version)
+	setString(metadata, "title", meta.Title)
+	setStrings(metadata, "creators", meta.Creators)
+	setString(metadata, "language", meta.Language)
+	setString(metadata, "publisher", meta.Publisher)
+	setString(metadata, "isbn", meta.ISBN)
+	setString(metadata, "publicationDate", meta.Date)
+	setStrings(metadata, "subjects", meta.Subjects)
+	setString(metadata, "series", meta.Series)
+	if meta.Series != "" && meta.SeriesIndex != nil {
+		metadata["seriesIndex"] = *meta.SeriesIndex
+	}
+	metadata["spineLength"] = meta.SpineLength
+
+	if len(meta.Identifiers) > 0 {
+		identifiers := make([]map[string]string, 0, len(meta.Identifiers))
+		for _, identifier := range meta.Identifiers {
+			entry := map[string]string{"value": identifier.Value}
+			if identifier.Scheme != "" {
+				entry["scheme"] = identifier.Scheme
+			}
+			identifiers = append(identifiers, entry)
+		}
+		metadata["identifiers"] = identifiers
+	}
+
+	return metadata, nil
+}
+
+// ExtractCover implements CoverExtractor with the cover image declared in
+// the manifest
+func (m *EPUBMediaType) ExtractCover(file multipart.File, size int64) (*Cover, error) {
+	book, err := epub.Open(file, size)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _, err := book.Cover()
+	if errors.Is(err, epub.ErrNoCover) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newCover(data)
+}
+
+// ExtractText implements TextExtractor with the text of the content
+// documents in reading order
+func (m *EPUBMediaType) ExtractText(file multipart.File, size int64) (string, error) {
+	book, err := epub.Open(file, size)
+	if err != nil {
+		return "", err
+	}
+
+	return book.Text(maxTextSize)
+}
+
+// Placeholder implements PlaceholderDrawer with a green page for books
+// without a cover
+func (m *EPUBMediaType) Placeholder(size int) image.Image {
+	return thumbnail.Placeholder(size, color.RGBA{R: 0x38, G: 0x8e, B: 0x3c, A: 0xff})
+}
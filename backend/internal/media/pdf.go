@@ -0,0 +1,104 @@
+package media
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"mime/multipart"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/pdf"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/thumbnail"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/validator"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// PDFMediaType handles PDF documents
+type PDFMediaType struct{}
+
+// NewPDFMediaType creates a new PDFMediaType
+func NewPDFMediaType() *PDFMediaType {
+	return &PDFMediaType{}
+}
+
+// AssetType implements MediaType
+func (m *PDFMediaType) AssetType() models.AssetType {
+	return models.AssetTypePDF
+}
+
+// MaxSize implements MediaType
+func (m *PDFMediaType) MaxSize() int64 {
+	return validator.MaxPDFSize
+}
+
+// Matches implements MediaType
+func (m *PDFMediaType) Matches(mime *mimetype.MIME) bool {
+	return validator.IsPDFMIME(mime)
+}
+
+// Validate implements MediaType
+func (m *PDFMediaType) Validate(file multipart.File, size int64) error {
+	return validator.ValidatePDF(file, size)
+}
+
+// ExtractMetadata implements MediaType by reading the document information
+// dictionary, the XMP packet and the page tree
+func (m *PDFMediaType) ExtractMetadata(file multipart.File, size int64) (map[string]interface{}, error) {
+	document, err := pdf.Open(file, size)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := document.Metadata()
+	metadata := make(map[string]interface{})
+	setString(metadata, "pdfVersion", meta.Version)
+	metadata["pageCount"] = meta.PageCount
+	metadata["encrypted"] = meta.Encrypted
+	metadata["linearized"] = meta.Linearized
+	setString(metadata, "title", meta.Title)
+	setString(metadata, "author", meta.Author)
+	setString(metadata, "subject", meta.Subject)
+	setString(metadata, "keywords", meta.Keywords)
+	setString(metadata, "creator", meta.Creator)
+	setString(metadata, "producer", meta.Producer)
+	setTime(metadata, "creationDate", meta.CreationDate)
+	setTime(metadata, "modificationDate", meta.ModDate)
+
+	if xmp := meta.XMP; xmp != nil {
+		packet := make(map[string]interface{})
+		setString(packet, "title", xmp.Title)
+		setStrings(packet, "creators", xmp.Creators)
+		setString(packet, "description", xmp.Description)
+		setStrings(packet, "subjects", xmp.Subjects)
+		setString(packet, "keywords", xmp.Keywords)
+		setString(packet, "producer", xmp.Producer)
+		setString(packet, "creatorTool", xmp.CreatorTool)
+		setTime(packet, "createDate", xmp.CreateDate)
+		setTime(packet, "modifyDate", xmp.ModifyDate)
+		if len(packet) > 0 {
+			metadata["xmp"] = packet
+		}
+	}
+
+	return metadata, nil
+}
+
+// ExtractText implements TextExtractor with the text shown on each page
+func (m *PDFMediaType) ExtractText(file multipart.File, size int64) (string, error) {
+	document, err := pdf.Open(file, size)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := document.Text(maxTextSize)
+	if errors.Is(err, pdf.ErrEncrypted) {
+		return "", nil
+	}
+	return text, err
+}
+
+// Placeholder implements PlaceholderDrawer with a red page, as pages
+// aren't rendered
+func (m *PDFMediaType) Placeholder(size int) image.Image {
+	return thumbnail.Placeholder(size, color.RGBA{R: 0xd3, G: 0x2f, B: 0x2f, A: 0xff})
+}
@@ -0,0 +1,160 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"sync"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+var (
+	// ErrUnsupportedMediaType is returned for content no registered media type accepts
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// MediaType is a plugin that teaches the upload pipeline about one kind of
+// asset. Registering a MediaType is all it takes to accept a new format.
+type MediaType interface {
+	// AssetType is the type given to assets of this media type
+	AssetType() models.AssetType
+
+	// MaxSize is the largest accepted upload in bytes
+	MaxSize() int64
+
+	// Matches reports whether sniffed content belongs to this media type
+	Matches(mime *mimetype.MIME) bool
+
+	// Validate checks opened content of the given size, returning one of the
+	// validator errors when it is rejected
+	Validate(file multipart.File, size int64) error
+
+	// ExtractMetadata reads format specific metadata from the content. The
+	// returned entries are merged into the asset metadata.
+	ExtractMetadata(file multipart.File, size int64) (map[string]interface{}, error)
+}
+
+// Registry holds the media types accepted for upload
+type Registry struct {
+	mu    sync.RWMutex
+	types []MediaType
+}
+
+// NewRegistry creates a new Registry with the given media types
+func NewRegistry(types ...MediaType) *Registry {
+	r := &Registry{}
+	for _, mediaType := range types {
+		r.Register(mediaType)
+	}
+	return r
+}
+
+// NewDefaultRegistry creates a Registry with every built-in media type
+func NewDefaultRegistry() *Registry {
+	return NewRegistry(
+		NewPDFMediaType(),
+		NewEPUBMediaType(),
+		NewAudioMediaType(),
+	)
+}
+
+// Register adds a media type, replacing any registered for the same asset type.
+// Detection tries media types in registration order.
+func (r *Registry) Register(mediaType MediaType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.types {
+		if existing.AssetType() == mediaType.AssetType() {
+			r.types[i] = mediaType
+			return
+		}
+	}
+	r.types = append(r.types, mediaType)
+}
+
+// Get returns the media type registered for an asset type
+func (r *Registry) Get(assetType models.AssetType) (MediaType, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, mediaType := range r.types {
+		if mediaType.AssetType() == assetType {
+			return mediaType, nil
+		}
+	}
+	return nil, ErrUnsupportedMediaType
+}
+
+// Detect sniffs the content of a file and returns the media type it belongs
+// to along with the detected MIME type. The file is rewound afterwards.
+func (r *Registry) Detect(file multipart.File) (MediaType, *mimetype.MIME, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	mime, err := mimetype.DetectReader(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to detect content type: %w", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, mediaType := range r.types {
+		if mediaType.Matches(mime) {
+			return mediaType, mime, nil
+		}
+	}
+	return nil, mime, ErrUnsupportedMediaType
+}
+
+// MaxSize returns the largest upload any registered media type accepts
+func (r *Registry) MaxSize() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	maxSize := int64(0)
+	for _, mediaType := range r.types {
+		if mediaType.MaxSize() > maxSize {
+			maxSize = mediaType.MaxSize()
+		}
+	}
+	return maxSize
+}
+
+// setString adds a metadata entry unless the value is empty
+func setString(metadata map[string]interface{}, key string, value string) {
+	if value != "" {
+		metadata[key] = value
+	}
+}
+
+// setStrings adds a list metadata entry unless the list is empty
+func setStrings(metadata map[string]interface{}, key string, values []string) {
+	if len(values) > 0 {
+		metadata[key] = values
+	}
+}
+
+// setInt adds a numeric metadata entry unless it is zero
+func setInt(metadata map[string]interface{}, key string, value int) {
+	if value != 0 {
+		metadata[key] = value
+	}
+}
+
+// setTime adds an RFC 3339 timestamp metadata entry unless the time is zero
+func setTime(metadata map[string]interface{}, key string, value time.Time) {
+	if !value.IsZero() {
+		metadata[key] = value.Format(time.RFC3339)
+	}
+}
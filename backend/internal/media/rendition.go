@@ -0,0 +1,80 @@
+package media
+
+import (
+	"fmt"
+	"image"
+	"mime/multipart"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+const (
+	// maxCoverSize caps the size of a stored cover image
+	maxCoverSize = 10 << 20
+	// maxTextSize caps extracted text, which is also about as much as a
+	// PostgreSQL text search vector can hold
+	maxTextSize = 1 << 20
+	// waveformPoints is the number of peaks in a waveform
+	waveformPoints = 1000
+)
+
+// coverMIMETypes are the image formats served as covers. SVG is left out
+// as it can carry scripts.
+var coverMIMETypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"image/bmp",
+}
+
+// Cover is an image embedded in an asset, such as a book or album cover
+type Cover struct {
+	ContentType string
+	Data        []byte
+}
+
+// CoverExtractor is implemented by media types whose content may embed a
+// cover image
+type CoverExtractor interface {
+	// ExtractCover returns the embedded cover, or nil if there is none
+	ExtractCover(file multipart.File, size int64) (*Cover, error)
+}
+
+// TextExtractor is implemented by media types whose content is mostly text
+type TextExtractor interface {
+	// ExtractText returns the plain text of the content, or an empty
+	// string if there is none
+	ExtractText(file multipart.File, size int64) (string, error)
+}
+
+// WaveformExtractor is implemented by media types that can be drawn as a
+// waveform
+type WaveformExtractor interface {
+	// ExtractWaveform returns peak amplitudes from 0 to 1 across the
+	// length of the content
+	ExtractWaveform(file multipart.File, size int64) ([]float64, error)
+}
+
+// PlaceholderDrawer is implemented by media types with a generic thumbnail
+// to fall back on when the content has no usable cover
+type PlaceholderDrawer interface {
+	// Placeholder draws the thumbnail to fit a square of size pixels
+	Placeholder(size int) image.Image
+}
+
+// newCover checks embedded image data, trusting its content rather than
+// the type the container declares for it
+func newCover(data []byte) (*Cover, error) {
+	if len(data) > maxCoverSize {
+		return nil, fmt.Errorf("cover image is larger than %d bytes", maxCoverSize)
+	}
+
+	mime := mimetype.Detect(data)
+	for _, allowed := range coverMIMETypes {
+		if mime.Is(allowed) {
+			return &Cover{ContentType: allowed, Data: data}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported cover image type %s", mime.String())
+}
@@ -6,7 +6,8 @@ import (
 )
 
 var (
-	ErrAssetNotFound = errors.New("asset not found")
+	ErrAssetNotFound    = errors.New("asset not found")
+	ErrInvalidAssetName = errors.New("invalid asset name")
 )
 
 // AssetType defines the type of asset
@@ -30,6 +31,8 @@ type Asset struct {
 	UpdatedAt   time.Time              `json:"updatedAt"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 	FolderID    *string                `json:"folderId,omitempty"`
+	Digest      string                 `json:"digest,omitempty"` // Hex SHA-256 of the content
+	Tags        []string               `json:"tags"`
 }
 
 // AssetCreateRequest represents the request to create a new asset
@@ -37,8 +40,15 @@ type AssetCreateRequest struct {
 	Name string `json:"name" form:"name" binding:"required"`
 }
 
+// AssetUpdateRequest represents the request to update an asset
+type AssetUpdateRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
 // AssetResponse represents the response after asset creation
 type AssetResponse struct {
-	Asset  *Asset `json:"asset"`
-	Status string `json:"status"`
+	Asset      *Asset   `json:"asset"`
+	Status     string   `json:"status"`
+	Duplicate  bool     `json:"duplicate"`
+	Duplicates []*Asset `json:"duplicates,omitempty"` // Existing assets with the same content
 }
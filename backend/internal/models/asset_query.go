@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// AssetSort names the field assets are listed by
+type AssetSort string
+
+const (
+	AssetSortName      AssetSort = "name"
+	AssetSortSize      AssetSort = "size"
+	AssetSortCreatedAt AssetSort = "createdAt"
+	AssetSortUpdatedAt AssetSort = "updatedAt"
+)
+
+// IsValid reports whether s is a known sort field
+func (s AssetSort) IsValid() bool {
+	switch s {
+	case AssetSortName, AssetSortSize, AssetSortCreatedAt, AssetSortUpdatedAt:
+		return true
+	}
+	return false
+}
+
+// AssetFilter narrows a list of assets. Unset fields don't filter.
+type AssetFilter struct {
+	Type          AssetType
+	FolderID      *string // Only assets directly in this folder
+	RootFolder    bool    // Only assets outside any folder
+	ContentType   string  // An exact type, or a family such as audio/*
+	MinSize       *int64
+	MaxSize       *int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	NamePrefix    string            // Matched case-insensitively
+	Metadata      map[string]string // Each key must have the value, or a list containing it
+	AnyTags       []string          // At least one of these tags
+	AllTags       []string          // Every one of these tags
+}
+
+// AssetListQuery describes one page of a filtered, sorted list of assets
+type AssetListQuery struct {
+	AssetFilter
+	Sort       AssetSort
+	Descending bool
+	Limit      int    // Zero lists every match
+	Cursor     string // From the NextCursor of the previous page
+}
+
+// AssetPage is one page of a list of assets
+type AssetPage struct {
+	Assets     []*Asset `json:"assets"`
+	NextCursor *string  `json:"nextCursor"` // Null on the last page
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCollectionNotFound     = errors.New("collection not found")
+	ErrInvalidCollectionName  = errors.New("invalid collection name")
+	ErrCollectionTypeMismatch = errors.New("asset type not allowed in collection")
+	ErrCollectionItemNotFound = errors.New("asset is not in the collection")
+	ErrInvalidCollectionOrder = errors.New("order must list every item of the collection exactly once")
+	ErrInvalidItemPosition    = errors.New("invalid item position")
+)
+
+// Collection is an ordered, user-curated list of assets, such as a
+// playlist or a reading list. Assets stay in their folders.
+type Collection struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	AssetType   *AssetType `json:"assetType,omitempty"` // Only assets of this type may be added, fixed at creation
+	ItemCount   int        `json:"itemCount"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// CollectionItem is an asset at its position in a collection
+type CollectionItem struct {
+	Position int       `json:"position"` // Zero based
+	AddedAt  time.Time `json:"addedAt"`
+	Asset    *Asset    `json:"asset"`
+}
+
+type CollectionCreateRequest struct {
+	Name        string     `json:"name" binding:"required,max=255"`
+	Description string     `json:"description"`
+	AssetType   *AssetType `json:"assetType"` // Null allows any type
+}
+
+type CollectionUpdateRequest struct {
+	Name        *string `json:"name" binding:"omitempty,max=255"`
+	Description *string `json:"description"`
+}
+
+// CollectionItemsRequest adds assets to a collection
+type CollectionItemsRequest struct {
+	AssetIDs []string `json:"assetIds" binding:"required,min=1"`
+	Position *int     `json:"position"` // Null appends to the end
+}
+
+// CollectionOrderRequest puts the items of a collection in a new order
+type CollectionOrderRequest struct {
+	AssetIDs []string `json:"assetIds" binding:"required"`
+}
+
+// CollectionItemMoveRequest moves one item to a new position
+type CollectionItemMoveRequest struct {
+	Position *int `json:"position" binding:"required"`
+}
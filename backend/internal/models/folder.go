@@ -9,8 +9,37 @@ var (
 	ErrFolderNotFound             = errors.New("folder not found")
 	ErrFolderCannotBeItsOwnParent = errors.New("a folder cannot be its own parent")
 	ErrCyclicReferenceDetected    = errors.New("cyclic reference detected - folder would be its own ancestor")
+	ErrFolderNotEmpty             = errors.New("folder is not empty")
+	ErrFolderNameExists           = errors.New("a folder with this name already exists in the parent")
+	ErrInvalidFolderDeleteMode    = errors.New("invalid folder delete mode")
 )
 
+// FolderDeleteMode says what happens to the contents of a deleted folder
+type FolderDeleteMode string
+
+const (
+	// FolderDeleteModeTrash moves the folder and its contents to the trash
+	FolderDeleteModeTrash FolderDeleteMode = "trash"
+	// FolderDeleteModeRefuseIfNotEmpty only deletes a folder with nothing in it
+	FolderDeleteModeRefuseIfNotEmpty FolderDeleteMode = "refuse-if-not-empty"
+	// FolderDeleteModeMoveContentsToParent hands the contents to the parent
+	// folder, or the root, before deleting the folder
+	FolderDeleteModeMoveContentsToParent FolderDeleteMode = "move-contents-to-parent"
+	// FolderDeleteModeRecursive permanently deletes the folder with every
+	// subfolder and asset in it, content included
+	FolderDeleteModeRecursive FolderDeleteMode = "delete-recursively"
+)
+
+// IsValid reports whether m is a known delete mode
+func (m FolderDeleteMode) IsValid() bool {
+	switch m {
+	case FolderDeleteModeTrash, FolderDeleteModeRefuseIfNotEmpty,
+		FolderDeleteModeMoveContentsToParent, FolderDeleteModeRecursive:
+		return true
+	}
+	return false
+}
+
 type Folder struct {
 	ID          string    `json:"id"`
 	Name        string    `json:"name"`
@@ -35,6 +64,43 @@ type FolderUpdateRequest struct {
 type FolderContents struct {
 	Assets     []*Asset  `json:"assets"`
 	SubFolders []*Folder `json:"subFolders"`
+	NextCursor *string   `json:"nextCursor"` // Next page of assets, null on the last
+}
+
+// FolderTreeNode is a folder with its subfolders nested inside it
+type FolderTreeNode struct {
+	Folder
+	AssetCount int               `json:"assetCount"` // Assets directly in the folder
+	Children   []*FolderTreeNode `json:"children"`
+}
+
+// FolderStats summarizes what is in a folder, or in the whole library for
+// the root
+type FolderStats struct {
+	FolderID *string     `json:"folderId"` // Null for the root
+	Direct   FolderUsage `json:"direct"`   // Only what sits directly in the folder
+	Subtree  FolderUsage `json:"subtree"`  // Everything at any depth below the folder
+}
+
+// FolderUsage holds the aggregate numbers for a part of the folder tree
+type FolderUsage struct {
+	TotalBytes   int64             `json:"totalBytes"`
+	AssetCount   int               `json:"assetCount"`
+	AssetsByType map[AssetType]int `json:"assetsByType"`
+	FolderCount  int               `json:"folderCount"`
+	MaxDepth     int               `json:"maxDepth"`     // Deepest level of subfolders, 0 when there are none
+	LastModified *time.Time        `json:"lastModified"` // Latest change to the folder or anything counted, null if nothing
+}
+
+// FolderDeleteSummary lists what deleting a folder affected
+type FolderDeleteSummary struct {
+	Mode           FolderDeleteMode `json:"mode"`
+	FolderID       string           `json:"folderId"`
+	DeletedFolders []string         `json:"deletedFolders"` // The folder itself and any subfolders removed with it
+	DeletedAssets  []string         `json:"deletedAssets"`
+	MovedFolders   []string         `json:"movedFolders"` // Subfolders handed to the parent
+	MovedAssets    []string         `json:"movedAssets"`
+	TrashItemID    string           `json:"trashItemId,omitempty"` // Set when the folder went to the trash
 }
 
 type AssetMoveRequest struct {
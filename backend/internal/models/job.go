@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobLeaseLost is returned when a worker reports on a job whose
+	// lease ran out and which another worker may have claimed since
+	ErrJobLeaseLost = errors.New("job lease was lost")
+)
+
+// JobType names the work a background job does
+type JobType string
+
+const (
+	// JobTypeExtractMetadata reads the embedded metadata of an asset
+	JobTypeExtractMetadata JobType = "extract-metadata"
+	// JobTypeGenerateRenditions creates the renditions of an asset
+	JobTypeGenerateRenditions JobType = "generate-renditions"
+	// JobTypeIndexText adds the text rendition of an asset to the search index
+	JobTypeIndexText JobType = "index-text"
+)
+
+// JobStatus is where a job is in its lifecycle
+type JobStatus string
+
+const (
+	// JobStatusQueued jobs wait for a worker, possibly to be retried
+	JobStatusQueued JobStatus = "queued"
+	// JobStatusRunning jobs are held by a worker
+	JobStatusRunning JobStatus = "running"
+	// JobStatusSucceeded jobs are done
+	JobStatusSucceeded JobStatus = "succeeded"
+	// JobStatusDead jobs failed on every attempt and won't be retried
+	JobStatusDead JobStatus = "dead"
+)
+
+// Job is a unit of background work kept in the job queue
+type Job struct {
+	ID          string     `json:"id"`
+	Type        JobType    `json:"type"`
+	AssetID     *string    `json:"assetId,omitempty"` // Asset the job works on, if any
+	Status      JobStatus  `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"maxAttempts"`
+	LastError   string     `json:"lastError,omitempty"`
+	RunAt       time.Time  `json:"runAt"` // Earliest time the next attempt may start
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"` // End of the lease of a running job
+}
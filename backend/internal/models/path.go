@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidPath   = errors.New("invalid path")
+	ErrPathNotFound  = errors.New("path not found")
+	ErrAmbiguousPath = errors.New("more than one asset has this path")
+	ErrPathExists    = errors.New("a folder or asset already exists at this path")
+)
+
+// PathEntryType says what a path resolved to
+type PathEntryType string
+
+const (
+	PathEntryTypeFolder PathEntryType = "folder"
+	PathEntryTypeAsset  PathEntryType = "asset"
+)
+
+// PathEntry is the folder or asset a slash-separated path names
+type PathEntry struct {
+	Type     PathEntryType   `json:"type"`
+	Path     string          `json:"path"`
+	Folder   *Folder         `json:"folder,omitempty"` // Left out for the root
+	Asset    *Asset          `json:"asset,omitempty"`
+	Contents *FolderContents `json:"contents,omitempty"` // Only for folders
+}
+
+// SplitPath breaks a slash-separated path into its names. Repeated slashes
+// are ignored; "." and ".." are not supported.
+func SplitPath(path string) ([]string, error) {
+	names := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+
+	for _, name := range names {
+		if name == "." || name == ".." || utf8.RuneCountInString(name) > 255 {
+			return nil, ErrInvalidPath
+		}
+	}
+
+	return names, nil
+}
+
+// JoinPath builds the canonical form of a path from its names
+func JoinPath(names []string) string {
+	return "/" + strings.Join(names, "/")
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidReconcileMode = errors.New("invalid reconcile mode")
+)
+
+// ReconcileMode defines what the reconciler does with the problems it finds
+type ReconcileMode string
+
+const (
+	// ReconcileModeReport only reports problems
+	ReconcileModeReport ReconcileMode = "report"
+	// ReconcileModeRepair deletes orphans and fixes sizes
+	ReconcileModeRepair ReconcileMode = "repair"
+	// ReconcileModeQuarantine sets orphans aside and flags broken assets
+	ReconcileModeQuarantine ReconcileMode = "quarantine"
+)
+
+// ReconcileIssueKind defines the kind of inconsistency found
+type ReconcileIssueKind string
+
+const (
+	ReconcileIssueMissingContent ReconcileIssueKind = "missing_content"
+	ReconcileIssueOrphanContent  ReconcileIssueKind = "orphan_content"
+	ReconcileIssueSizeMismatch   ReconcileIssueKind = "size_mismatch"
+	ReconcileIssueRefCount       ReconcileIssueKind = "ref_count_mismatch"
+)
+
+// ReconcileIssue describes a single inconsistency between assets and content
+type ReconcileIssue struct {
+	Kind         ReconcileIssueKind `json:"kind"`
+	AssetID      string             `json:"assetId,omitempty"`
+	Key          string             `json:"key"`
+	ExpectedSize *int64             `json:"expectedSize,omitempty"`
+	ActualSize   *int64             `json:"actualSize,omitempty"`
+	ExpectedRefs *int64             `json:"expectedRefs,omitempty"`
+	ActualRefs   *int64             `json:"actualRefs,omitempty"`
+	Action       string             `json:"action,omitempty"` // What was done about it, empty in report mode
+	Error        string             `json:"error,omitempty"`
+}
+
+// ReconcileReport is the result of a reconciler run
+type ReconcileReport struct {
+	Mode           ReconcileMode     `json:"mode"`
+	StartedAt      time.Time         `json:"startedAt"`
+	FinishedAt     time.Time         `json:"finishedAt"`
+	AssetsChecked  int               `json:"assetsChecked"`
+	ContentChecked int               `json:"contentChecked"`
+	BlobsChecked   int               `json:"blobsChecked"`
+	Issues         []*ReconcileIssue `json:"issues"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrRenditionNotFound    = errors.New("rendition not found")
+	ErrRenditionNotProduced = errors.New("rendition kind not produced for this type of asset")
+	ErrInvalidRenditionKind = errors.New("invalid rendition kind")
+)
+
+// RenditionKind names a file derived from an asset's content
+type RenditionKind string
+
+const (
+	// RenditionKindCover is the cover image embedded in a book or audio file
+	RenditionKindCover RenditionKind = "cover"
+	// RenditionKindThumbnailSmall is the cover scaled to fit 128 pixels. The
+	// thumbnails of assets without a cover are a placeholder for their type.
+	RenditionKindThumbnailSmall RenditionKind = "thumbnail-small"
+	// RenditionKindThumbnailMedium is the cover scaled to fit 256 pixels
+	RenditionKindThumbnailMedium RenditionKind = "thumbnail-medium"
+	// RenditionKindThumbnailLarge is the cover scaled to fit 512 pixels
+	RenditionKindThumbnailLarge RenditionKind = "thumbnail-large"
+	// RenditionKindWaveform holds the peak amplitudes of an audio file as JSON
+	RenditionKindWaveform RenditionKind = "waveform"
+	// RenditionKindText is the plain text of a document
+	RenditionKindText RenditionKind = "text"
+)
+
+// IsValid reports whether k is a known rendition kind
+func (k RenditionKind) IsValid() bool {
+	switch k {
+	case RenditionKindCover, RenditionKindThumbnailSmall, RenditionKindThumbnailMedium,
+		RenditionKindThumbnailLarge, RenditionKindWaveform, RenditionKindText:
+		return true
+	}
+	return false
+}
+
+// Rendition is a file derived from an asset, stored alongside its content
+type Rendition struct {
+	AssetID     string        `json:"assetId"`
+	Kind        RenditionKind `json:"kind"`
+	ContentType string        `json:"contentType"`
+	Size        int64         `json:"size"`
+	Digest      string        `json:"digest"` // SHA-256 of the rendition, hex encoded
+	Key         string        `json:"-"`      // Where the rendition is stored
+	CreatedAt   time.Time     `json:"createdAt"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"errors"
+)
+
+var (
+	ErrEmptySearchQuery = errors.New("search query is empty")
+)
+
+// SearchQuery describes a full-text search over assets
+type SearchQuery struct {
+	Text       string    // Web search syntax, such as: tolkien "two towers" -film
+	Type       AssetType // Only assets of this type, when set
+	FolderID   *string   // Only assets directly in this folder, when set
+	RootFolder bool      // Only assets outside any folder
+	Limit      int
+	Offset     int
+}
+
+// SearchResult is an asset matching a search, with how well it matched
+type SearchResult struct {
+	Asset   *Asset  `json:"asset"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet,omitempty"` // HTML escaped, with matches wrapped in <mark>
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxTagNameLength caps the length of a tag name in characters
+const maxTagNameLength = 64
+
+var (
+	ErrTagNotFound    = errors.New("tag not found")
+	ErrTagExists      = errors.New("a tag with this name already exists")
+	ErrInvalidTagName = errors.New("invalid tag name")
+	ErrTagMergeSelf   = errors.New("a tag cannot be merged into itself")
+)
+
+// Tag is a label that can be attached to any number of assets
+type Tag struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	AssetCount int       `json:"assetCount"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// AssetTagsRequest represents the request to tag an asset
+type AssetTagsRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1"`
+}
+
+// TagUpdateRequest represents the request to rename a tag
+type TagUpdateRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// TagMergeRequest represents the request to merge a tag into another
+type TagMergeRequest struct {
+	TargetID string `json:"targetId" binding:"required"`
+}
+
+// NormalizeTagName returns the canonical form of a tag name. Tags are
+// compared case-insensitively, and commas and slashes are reserved for
+// tag lists and paths.
+func NormalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if name == "" || utf8.RuneCountInString(name) > maxTagNameLength || strings.ContainsAny(name, ",/") {
+		return "", ErrInvalidTagName
+	}
+	return name, nil
+}
+
+// NormalizeTagNames normalizes a list of tag names and drops duplicates,
+// keeping the first occurrence of each
+func NormalizeTagNames(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	var normalized []string
+	for _, name := range names {
+		name, err := NormalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized, nil
+}
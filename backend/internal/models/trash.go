@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTrashItemNotFound = errors.New("trash item not found")
+)
+
+// TrashItemType is the kind of item that was deleted
+type TrashItemType string
+
+const (
+	TrashItemTypeAsset  TrashItemType = "asset"
+	TrashItemTypeFolder TrashItemType = "folder"
+)
+
+// TrashItem is a deleted asset or folder waiting to be restored or purged.
+// A folder takes its subfolders and assets with it.
+type TrashItem struct {
+	ID           string           `json:"id"`
+	ItemType     TrashItemType    `json:"itemType"`
+	ItemID       string           `json:"itemId"` // ID of the asset or folder
+	Name         string           `json:"name"`
+	OriginalPath []TrashPathEntry `json:"originalPath"` // Folders from the root to where the item was
+	AssetCount   int              `json:"assetCount"`   // Assets deleted with the item, including itself
+	FolderCount  int              `json:"folderCount"`  // Folders deleted with the item, including itself
+	DeletedAt    time.Time        `json:"deletedAt"`
+}
+
+// TrashPathEntry is a folder on the original path of a trash item, kept so
+// the folder can be recreated if it is gone by the time of a restore
+type TrashPathEntry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// TrashRestoreResult describes where a restored item went
+type TrashRestoreResult struct {
+	Item             *TrashItem `json:"item"`
+	FolderID         *string    `json:"folderId"`         // Null when restored to the root
+	RecreatedFolders []*Folder  `json:"recreatedFolders"` // Missing folders made again on the original path
+}
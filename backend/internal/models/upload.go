@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUploadNotFound       = errors.New("upload not found")
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match")
+	ErrUploadTooLarge       = errors.New("upload exceeds declared length")
+	ErrUploadComplete       = errors.New("upload is already complete")
+	ErrUploadInvalidLength  = errors.New("invalid upload length")
+	ErrUploadInvalidType    = errors.New("upload metadata names an unsupported asset type")
+)
+
+// Upload is a resumable upload that is staged in chunks until complete
+type Upload struct {
+	ID        string            `json:"id"`
+	Type      AssetType         `json:"type,omitempty"` // Detected on completion when not given
+	Length    int64             `json:"length"`
+	Offset    int64             `json:"offset"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	AssetID   *string           `json:"assetId,omitempty"` // Set once the upload became an asset
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// IsComplete reports whether every byte of the upload has been received
+func (u *Upload) IsComplete() bool {
+	return u.Offset == u.Length
+}
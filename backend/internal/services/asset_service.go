@@ -1,15 +1,21 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/SaadBeidourii/MediaHub.git/internal/media"
 	"github.com/SaadBeidourii/MediaHub.git/internal/models"
 	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
-	"github.com/SaadBeidourii/MediaHub.git/pkg/validator"
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/google/uuid"
 )
 
@@ -17,18 +23,42 @@ import (
 type AssetService struct {
 	storage    storage.StorageProvider
 	assetStore storage.AssetStore
+	uow        storage.UnitOfWork
+	mediaTypes *media.Registry
 }
 
 // NewAssetService creates a new AssetService
-func NewAssetService(storageProvider storage.StorageProvider, assetStore storage.AssetStore) *AssetService {
+func NewAssetService(storageProvider storage.StorageProvider, assetStore storage.AssetStore, uow storage.UnitOfWork, mediaTypes *media.Registry) *AssetService {
 	return &AssetService{
 		storage:    storageProvider,
 		assetStore: assetStore,
+		uow:        uow,
+		mediaTypes: mediaTypes,
 	}
 }
 
 // ////////////////// * ASSET CREATION * /////////////////////////
+
+// CreateAsset validates an uploaded file as the given asset type and stores it
 func (s *AssetService) CreateAsset(fileHeader *multipart.FileHeader, assetType models.AssetType) (*models.Asset, error) {
+	mediaType, err := s.mediaTypes.Get(assetType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open the uploaded file
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	return s.createAsset(file, fileHeader.Filename, fileHeader.Size, fileHeader.Header.Get("Content-Type"), mediaType, nil)
+}
+
+// CreateDetectedAsset stores an uploaded file as whichever registered media
+// type its content belongs to
+func (s *AssetService) CreateDetectedAsset(fileHeader *multipart.FileHeader) (*models.Asset, error) {
 	// Open the uploaded file
 	file, err := fileHeader.Open()
 	if err != nil {
@@ -36,48 +66,186 @@ func (s *AssetService) CreateAsset(fileHeader *multipart.FileHeader, assetType m
 	}
 	defer file.Close()
 
+	mediaType, _, err := s.mediaTypes.Detect(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createAsset(file, fileHeader.Filename, fileHeader.Size, fileHeader.Header.Get("Content-Type"), mediaType, nil)
+}
+
+// CreateAssetFromFile validates and stores content that did not arrive as a
+// multipart upload, such as a finished resumable upload. The media type is
+// detected from the content when no asset type is given.
+func (s *AssetService) CreateAssetFromFile(file multipart.File, name string, size int64, contentType string, assetType models.AssetType) (*models.Asset, error) {
+	var mediaType media.MediaType
+	var err error
+	if assetType == "" {
+		mediaType, _, err = s.mediaTypes.Detect(file)
+	} else {
+		mediaType, err = s.mediaTypes.Get(assetType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createAsset(file, name, size, contentType, mediaType, nil)
+}
+
+// createAsset validates content against its media type, then stores it along
+// with its metadata. place, if given, runs first in the same unit of work
+// to decide where the asset goes; without it the asset lands in the root.
+func (s *AssetService) createAsset(file multipart.File, name string, size int64, contentType string, mediaType media.MediaType, place func(tx *storage.Tx, asset *models.Asset) error) (*models.Asset, error) {
+	if err := mediaType.Validate(file, size); err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	// Without a client supplied type, fall back to sniffing the content
+	if contentType == "" {
+		mime, err := mimetype.DetectReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect content type: %w", err)
+		}
+		contentType = mime.String()
+
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind file: %w", err)
+		}
+	}
+
 	assetID := uuid.New().String()
 
 	// Create a new asset
 	now := time.Now()
 	asset := &models.Asset{
 		ID:          assetID,
-		Name:        fileHeader.Filename,
-		Type:        assetType,
-		Size:        fileHeader.Size,
-		ContentType: fileHeader.Header.Get("Content-Type"),
+		Name:        name,
+		Type:        mediaType.AssetType(),
+		ContentType: contentType,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		Metadata:    make(map[string]interface{}),
-		Path:        fmt.Sprintf("db://%s", assetID),
+		Tags:        []string{},
 	}
 
 	// Add file extension to metadata
-	asset.Metadata["extension"] = filepath.Ext(fileHeader.Filename)
+	asset.Metadata["extension"] = filepath.Ext(name)
 
-	// FIRST: Save the asset metadata to the database
-	if err := s.assetStore.Save(asset); err != nil {
-		return nil, fmt.Errorf("failed to save asset metadata: %w", err)
+	// The digest has to be known before anything is saved, so it can't be
+	// taken while saving: content is stored under its digest, and content
+	// that is already stored isn't saved again. Uploads are spooled to local
+	// disk, so this first pass is a local read, and the second one only
+	// happens for new content.
+	hasher := sha256.New()
+	written, err := io.Copy(hasher, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind uploaded file: %w", err)
 	}
+	asset.Digest = hex.EncodeToString(hasher.Sum(nil))
+	asset.Size = written
+
+	// Content and metadata are saved in one unit of work, so a failure in
+	// either step leaves neither behind
+	err = s.uow.Do(func(tx *storage.Tx) error {
+		if place != nil {
+			if err := place(tx, asset); err != nil {
+				return err
+			}
+		}
+
+		// FIRST: Reference the content blob, storing it if nobody else has
+		blob, created, err := tx.Blobs.Acquire(asset.Digest, asset.Size)
+		if err != nil {
+			return err
+		}
+
+		if created {
+			path, err := tx.Content.Save(contentKey(asset), file)
+			if err != nil {
+				return fmt.Errorf("failed to save file: %w", err)
+			}
+			if err := tx.Blobs.SetPath(asset.Digest, path); err != nil {
+				return err
+			}
+			blob.Path = path
+		}
+		asset.Path = blob.Path
 
-	// THEN: Save the file content
-	_, err = s.storage.Save(file, asset)
+		// THEN: Save the asset metadata to the database
+		if err := tx.Assets.Save(asset); err == models.ErrPathExists {
+			return err
+		} else if err != nil {
+			return fmt.Errorf("failed to save asset metadata: %w", err)
+		}
+
+		// FINALLY: Queue the slower follow-up work, which only becomes
+		// visible to workers once the asset exists
+		for _, jobType := range []models.JobType{models.JobTypeExtractMetadata, models.JobTypeGenerateRenditions} {
+			if err := tx.Jobs.Enqueue(newAssetJob(jobType, asset.ID)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
-		// If file save fails, clean up the asset metadata
-		s.assetStore.Delete(assetID)
-		return nil, fmt.Errorf("failed to save file: %w", err)
+		return nil, err
 	}
 
 	return asset, nil
 }
 
+// ExtractMetadata reads the embedded metadata of an asset's content and
+// merges it into the asset. Content that passed validation is accepted
+// even if its metadata turns out to be unreadable.
+func (s *AssetService) ExtractMetadata(assetID string) error {
+	asset, err := s.assetStore.GetByID(assetID)
+	if err == models.ErrAssetNotFound {
+		// Deleted before its turn came
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	mediaType, err := s.mediaTypes.Get(asset.Type)
+	if err != nil {
+		return err
+	}
+
+	file, err := spoolContent(s.storage, asset)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	extracted, err := mediaType.ExtractMetadata(file, asset.Size)
+	if err != nil {
+		log.Printf("Failed to extract metadata from %s: %v", asset.Name, err)
+		return nil
+	}
+	if len(extracted) == 0 {
+		return nil
+	}
+
+	err = s.assetStore.UpdateMetadata(asset.ID, extracted)
+	if err == models.ErrAssetNotFound {
+		return nil
+	}
+	return err
+}
+
 //////////////////// * PDF * /////////////////////////
 
 // CreatePDFAsset creates a PDF asset
 func (s *AssetService) CreatePDFAsset(fileHeader *multipart.FileHeader) (*models.Asset, error) {
-	if err := validator.ValidatePDFFile(fileHeader); err != nil {
-		return nil, err
-	}
 	return s.CreateAsset(fileHeader, models.AssetTypePDF)
 }
 
@@ -85,9 +253,6 @@ func (s *AssetService) CreatePDFAsset(fileHeader *multipart.FileHeader) (*models
 
 // CreateEPUBAsset creates an EPUB asset
 func (s *AssetService) CreateEPUBAsset(fileHeader *multipart.FileHeader) (*models.Asset, error) {
-	if err := validator.ValidateEPUBFile(fileHeader); err != nil {
-		return nil, err
-	}
 	return s.CreateAsset(fileHeader, models.AssetTypeEPUB)
 }
 
@@ -95,9 +260,6 @@ func (s *AssetService) CreateEPUBAsset(fileHeader *multipart.FileHeader) (*model
 
 // CreateAudioAsset creates an audio asset
 func (s *AssetService) CreateAudioAsset(fileHeader *multipart.FileHeader) (*models.Asset, error) {
-	if err := validator.ValidateAudioFile(fileHeader); err != nil {
-		return nil, err
-	}
 	return s.CreateAsset(fileHeader, models.AssetTypeAUDIO)
 }
 
@@ -117,13 +279,13 @@ func (s *AssetService) GetAsset(assetID string) (*models.Asset, error) {
 // GetAssetContent retrieves the content of an asset by ID
 func (s *AssetService) GetAssetContent(assetID string) (io.ReadCloser, error) {
 	// Check if the asset exists
-	_, err := s.assetStore.GetByID(assetID)
+	asset, err := s.assetStore.GetByID(assetID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get the file content from storage
-	content, err := s.storage.Get(assetID)
+	content, err := s.storage.Get(contentKey(asset))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get asset content: %w", err)
 	}
@@ -131,28 +293,103 @@ func (s *AssetService) GetAssetContent(assetID string) (io.ReadCloser, error) {
 	return content, nil
 }
 
-// GetAllAssets retrieves all assets
-func (s *AssetService) GetAllAssets() ([]*models.Asset, error) {
-	return s.assetStore.GetAll()
+// OpenAssetContent opens the content of an asset for seeking, so callers
+// can serve byte ranges without reading the whole file
+func (s *AssetService) OpenAssetContent(asset *models.Asset) (io.ReadSeekCloser, error) {
+	content, err := storage.NewContentReader(s.storage, contentKey(asset), asset.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset content: %w", err)
+	}
+
+	return content, nil
 }
 
-// DeleteAsset removes an asset by ID
-func (s *AssetService) DeleteAsset(assetID string) error {
-	// First check if the asset exists
-	_, err := s.assetStore.GetByID(assetID)
+// GetDuplicates retrieves the other assets that share an asset's content
+func (s *AssetService) GetDuplicates(asset *models.Asset) ([]*models.Asset, error) {
+	if asset.Digest == "" {
+		return nil, nil
+	}
+
+	matches, err := s.assetStore.GetByDigest(asset.Digest)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var duplicates []*models.Asset
+	for _, match := range matches {
+		if match.ID != asset.ID {
+			duplicates = append(duplicates, match)
+		}
+	}
+
+	return duplicates, nil
+}
+
+// ListAssets retrieves a page of assets matching a query
+func (s *AssetService) ListAssets(query *models.AssetListQuery) (*models.AssetPage, error) {
+	return s.assetStore.List(query)
+}
+
+// UpdateAsset applies changes to an asset. Renaming keeps the search index
+// in step, as it is generated from the asset's columns.
+func (s *AssetService) UpdateAsset(assetID string, request *models.AssetUpdateRequest) (*models.Asset, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, models.ErrInvalidAssetName
+	}
+
+	if err := s.assetStore.Rename(assetID, name); err != nil {
+		return nil, err
+	}
+
+	return s.assetStore.GetByID(assetID)
+}
+
+// DeleteAsset moves an asset to the trash
+func (s *AssetService) DeleteAsset(assetID string) error {
+	return s.uow.Do(func(tx *storage.Tx) error {
+		asset, err := tx.Assets.GetByID(assetID)
+		if err != nil {
+			return err
+		}
+
+		path, err := trashPath(tx.Folders, asset.FolderID)
+		if err != nil {
+			return err
+		}
+
+		return tx.Trash.TrashAsset(newTrashItem(models.TrashItemTypeAsset, asset.ID, asset.Name, path))
+	})
+}
+
+// contentKey returns the key an asset's content is stored under.
+// Content is addressed by digest; assets uploaded before digests were
+// recorded still live under their own ID.
+func contentKey(asset *models.Asset) string {
+	if asset.Digest != "" {
+		return asset.Digest
 	}
+	return asset.ID
+}
 
-	// Delete the file using the storage provider
-	if err := s.storage.Delete(assetID); err != nil {
-		return fmt.Errorf("failed to delete asset file: %w", err)
+// spoolContent copies the content of an asset to a temporary file, as
+// extractors need random access. The caller removes the file.
+func spoolContent(provider storage.StorageProvider, asset *models.Asset) (*os.File, error) {
+	content, err := provider.Get(contentKey(asset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset content: %w", err)
 	}
+	defer content.Close()
 
-	// Remove the asset from the asset store
-	if err := s.assetStore.Delete(assetID); err != nil {
-		return fmt.Errorf("failed to delete asset metadata: %w", err)
+	file, err := os.CreateTemp("", "asset-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("failed to read asset content: %w", err)
 	}
 
-	return nil
+	return file, nil
 }
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/media"
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+	"github.com/google/uuid"
+)
+
+// CollectionService handles collections and the order of their items
+type CollectionService struct {
+	collectionStore storage.CollectionStore
+	assetStore      storage.AssetStore
+	uow             storage.UnitOfWork
+	mediaTypes      *media.Registry
+}
+
+// NewCollectionService creates a new CollectionService
+func NewCollectionService(collectionStore storage.CollectionStore, assetStore storage.AssetStore, uow storage.UnitOfWork, mediaTypes *media.Registry) *CollectionService {
+	return &CollectionService{
+		collectionStore: collectionStore,
+		assetStore:      assetStore,
+		uow:             uow,
+		mediaTypes:      mediaTypes,
+	}
+}
+
+// CreateCollection creates a new, empty collection
+func (s *CollectionService) CreateCollection(request *models.CollectionCreateRequest) (*models.Collection, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, models.ErrInvalidCollectionName
+	}
+	if request.AssetType != nil {
+		if _, err := s.mediaTypes.Get(*request.AssetType); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	collection := &models.Collection{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: request.Description,
+		AssetType:   request.AssetType,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.collectionStore.Save(collection); err != nil {
+		return nil, err
+	}
+
+	return collection, nil
+}
+
+// GetCollection retrieves a collection by ID
+func (s *CollectionService) GetCollection(collectionID string) (*models.Collection, error) {
+	return s.collectionStore.GetByID(collectionID)
+}
+
+// GetAllCollections retrieves all collections
+func (s *CollectionService) GetAllCollections() ([]*models.Collection, error) {
+	return s.collectionStore.GetAll()
+}
+
+// UpdateCollection changes the name or description of a collection
+func (s *CollectionService) UpdateCollection(collectionID string, request *models.CollectionUpdateRequest) (*models.Collection, error) {
+	collection, err := s.collectionStore.GetByID(collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Name != nil {
+		name := strings.TrimSpace(*request.Name)
+		if name == "" {
+			return nil, models.ErrInvalidCollectionName
+		}
+		collection.Name = name
+	}
+	if request.Description != nil {
+		collection.Description = *request.Description
+	}
+
+	if err := s.collectionStore.Update(collection); err != nil {
+		return nil, err
+	}
+
+	return collection, nil
+}
+
+// DeleteCollection removes a collection. Its assets are not touched.
+func (s *CollectionService) DeleteCollection(collectionID string) error {
+	return s.collectionStore.Delete(collectionID)
+}
+
+// GetItems retrieves the items of a collection in order
+func (s *CollectionService) GetItems(collectionID string) ([]*models.CollectionItem, error) {
+	if _, err := s.collectionStore.GetByID(collectionID); err != nil {
+		return nil, err
+	}
+
+	return s.collectionStore.GetItems(collectionID)
+}
+
+// AddItems adds assets to a collection at a position, or at the end when
+// none is given, and returns the items in their new order. Assets already
+// in the collection keep their place.
+func (s *CollectionService) AddItems(collectionID string, request *models.CollectionItemsRequest) ([]*models.CollectionItem, error) {
+	assetIDs := uniqueStrings(request.AssetIDs)
+
+	err := s.changeItems(collectionID, func(tx *storage.Tx, collection *models.Collection, order []string) error {
+		for _, assetID := range assetIDs {
+			asset, err := tx.Assets.GetByID(assetID)
+			if err != nil {
+				return err
+			}
+			if collection.AssetType != nil && asset.Type != *collection.AssetType {
+				return models.ErrCollectionTypeMismatch
+			}
+		}
+
+		if err := tx.Collections.AddItems(collectionID, assetIDs, time.Now()); err != nil {
+			return err
+		}
+		if request.Position == nil {
+			return nil
+		}
+
+		position := *request.Position
+		if position < 0 || position > len(order) {
+			return models.ErrInvalidItemPosition
+		}
+
+		present := make(map[string]bool, len(order))
+		for _, assetID := range order {
+			present[assetID] = true
+		}
+		var added []string
+		for _, assetID := range assetIDs {
+			if !present[assetID] {
+				added = append(added, assetID)
+			}
+		}
+
+		return tx.Collections.Reorder(collectionID, slices.Insert(order, position, added...))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.collectionStore.GetItems(collectionID)
+}
+
+// ReorderItems puts the items of a collection in the given order, which
+// must list every item exactly once
+func (s *CollectionService) ReorderItems(collectionID string, request *models.CollectionOrderRequest) ([]*models.CollectionItem, error) {
+	err := s.changeItems(collectionID, func(tx *storage.Tx, collection *models.Collection, order []string) error {
+		if len(request.AssetIDs) != len(order) {
+			return models.ErrInvalidCollectionOrder
+		}
+		remaining := make(map[string]bool, len(order))
+		for _, assetID := range order {
+			remaining[assetID] = true
+		}
+		for _, assetID := range request.AssetIDs {
+			if !remaining[assetID] {
+				return models.ErrInvalidCollectionOrder
+			}
+			delete(remaining, assetID)
+		}
+
+		return tx.Collections.Reorder(collectionID, request.AssetIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.collectionStore.GetItems(collectionID)
+}
+
+// MoveItem moves one item of a collection to a new position, shifting the
+// items in between
+func (s *CollectionService) MoveItem(collectionID string, assetID string, position int) ([]*models.CollectionItem, error) {
+	err := s.changeItems(collectionID, func(tx *storage.Tx, collection *models.Collection, order []string) error {
+		from := slices.Index(order, assetID)
+		if from < 0 {
+			return models.ErrCollectionItemNotFound
+		}
+		if position < 0 || position >= len(order) {
+			return models.ErrInvalidItemPosition
+		}
+
+		order = slices.Delete(order, from, from+1)
+		return tx.Collections.Reorder(collectionID, slices.Insert(order, position, assetID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.collectionStore.GetItems(collectionID)
+}
+
+// RemoveItem removes an asset from a collection and returns the items
+// that remain
+func (s *CollectionService) RemoveItem(collectionID string, assetID string) ([]*models.CollectionItem, error) {
+	err := s.changeItems(collectionID, func(tx *storage.Tx, collection *models.Collection, order []string) error {
+		return tx.Collections.RemoveItem(collectionID, assetID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.collectionStore.GetItems(collectionID)
+}
+
+// GetAssetCollections retrieves the collections an asset belongs to
+func (s *CollectionService) GetAssetCollections(assetID string) ([]*models.Collection, error) {
+	if _, err := s.assetStore.GetByID(assetID); err != nil {
+		return nil, err
+	}
+
+	return s.assetStore.GetCollections(assetID)
+}
+
+// changeItems runs fn in a unit of work holding the collection, with the
+// asset IDs of its items in their current order. Touching the collection
+// first keeps concurrent changes from working on a stale order.
+func (s *CollectionService) changeItems(collectionID string, fn func(tx *storage.Tx, collection *models.Collection, order []string) error) error {
+	return s.uow.Do(func(tx *storage.Tx) error {
+		if err := tx.Collections.Touch(collectionID, time.Now()); err != nil {
+			return err
+		}
+
+		collection, err := tx.Collections.GetByID(collectionID)
+		if err != nil {
+			return err
+		}
+		items, err := tx.Collections.GetItems(collectionID)
+		if err != nil {
+			return err
+		}
+
+		order := make([]string, len(items))
+		for i, item := range items {
+			order[i] = item.Asset.ID
+		}
+
+		return fn(tx, collection, order)
+	})
+}
+
+// uniqueStrings drops repeated values, keeping the first occurrence of each
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -13,13 +13,15 @@ import (
 type FolderService struct {
 	folderStore storage.FolderStore
 	assetStore  storage.AssetStore
+	uow         storage.UnitOfWork
 }
 
 // NewFolderService creates a new FolderService
-func NewFolderService(folderStore storage.FolderStore, assetStore storage.AssetStore) *FolderService {
+func NewFolderService(folderStore storage.FolderStore, assetStore storage.AssetStore, uow storage.UnitOfWork) *FolderService {
 	return &FolderService{
 		folderStore: folderStore,
 		assetStore:  assetStore,
+		uow:         uow,
 	}
 }
 
@@ -87,6 +89,11 @@ func (s *FolderService) UpdateFolder(folderID string, request *models.FolderUpda
 		}
 
 		if *request.ParentID != "" {
+			// The new parent must exist and not be in the trash
+			if _, err := s.folderStore.GetByID(*request.ParentID); err != nil {
+				return nil, err
+			}
+
 			if err := s.checkForCyclicReference(folderID, *request.ParentID); err != nil {
 				return nil, err
 			}
@@ -107,59 +114,227 @@ func (s *FolderService) UpdateFolder(folderID string, request *models.FolderUpda
 
 // Helper method to check for cyclic references
 func (s *FolderService) checkForCyclicReference(folderID, potentialParentID string) error {
-	currentID := potentialParentID
+	ancestors, err := s.folderStore.GetAncestors(potentialParentID)
+	if err != nil {
+		return err
+	}
+
+	// If the folder is above its new parent, we have a cycle
+	for _, ancestor := range ancestors {
+		if ancestor.ID == folderID {
+			return models.ErrCyclicReferenceDetected
+		}
+	}
+
+	return nil
+}
 
-	for {
-		parent, err := s.folderStore.GetByID(currentID)
+// DeleteFolder deletes a folder in one transaction, handling its contents
+// as the mode says, and reports which folders and assets were affected
+func (s *FolderService) DeleteFolder(folderID string, mode models.FolderDeleteMode) (*models.FolderDeleteSummary, error) {
+	if mode == "" {
+		mode = models.FolderDeleteModeTrash
+	}
+	if !mode.IsValid() {
+		return nil, models.ErrInvalidFolderDeleteMode
+	}
+
+	summary := &models.FolderDeleteSummary{
+		Mode:           mode,
+		FolderID:       folderID,
+		DeletedFolders: []string{},
+		DeletedAssets:  []string{},
+		MovedFolders:   []string{},
+		MovedAssets:    []string{},
+	}
+
+	err := s.uow.Do(func(tx *storage.Tx) error {
+		folder, err := tx.Folders.GetByID(folderID)
 		if err != nil {
-			if err == models.ErrFolderNotFound {
-				return nil
-			}
 			return err
 		}
 
-		// If parent ID is nil, we've reached the root without finding a cycle
-		if parent.ParentID == nil {
-			return nil
+		switch mode {
+		case models.FolderDeleteModeRefuseIfNotEmpty:
+			return deleteEmptyFolder(tx, folder, summary)
+		case models.FolderDeleteModeMoveContentsToParent:
+			return deleteFolderToParent(tx, folder, summary)
+		case models.FolderDeleteModeRecursive:
+			return deleteFolderRecursively(tx, folder, summary)
+		default:
+			return trashFolder(tx, folder, summary)
 		}
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// If this parent points back to our original folder, we have a cycle
-		if *parent.ParentID == folderID {
-			return models.ErrCyclicReferenceDetected
+	return summary, nil
+}
+
+// folderSubtree retrieves a folder's descendants and every asset in the
+// folder or below it
+func folderSubtree(tx *storage.Tx, folder *models.Folder) ([]*models.Folder, []*models.Asset, error) {
+	descendants, err := tx.Folders.GetDescendants(folder.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var assets []*models.Asset
+	for _, f := range append([]*models.Folder{folder}, descendants...) {
+		inFolder, err := tx.Assets.GetByFolderID(&f.ID)
+		if err != nil {
+			return nil, nil, err
 		}
+		assets = append(assets, inFolder...)
+	}
+
+	return descendants, assets, nil
+}
 
-		// Move up the chain
-		currentID = *parent.ParentID
+// trashFolder moves a folder to the trash with everything in it
+func trashFolder(tx *storage.Tx, folder *models.Folder, summary *models.FolderDeleteSummary) error {
+	descendants, assets, err := folderSubtree(tx, folder)
+	if err != nil {
+		return err
+	}
+
+	path, err := trashPath(tx.Folders, folder.ParentID)
+	if err != nil {
+		return err
+	}
+
+	item := newTrashItem(models.TrashItemTypeFolder, folder.ID, folder.Name, path)
+	if err := tx.Trash.TrashFolder(item); err != nil {
+		return err
+	}
+
+	summary.TrashItemID = item.ID
+	summary.DeletedFolders = append(summary.DeletedFolders, folder.ID)
+	for _, f := range descendants {
+		summary.DeletedFolders = append(summary.DeletedFolders, f.ID)
+	}
+	for _, asset := range assets {
+		summary.DeletedAssets = append(summary.DeletedAssets, asset.ID)
 	}
+
+	return nil
 }
 
-// DeleteFolder deletes a folder and optionally its contents
-func (s *FolderService) DeleteFolder(folderID string) error {
-	return s.folderStore.Delete(folderID)
+// deleteEmptyFolder deletes a folder that holds no subfolders or assets
+func deleteEmptyFolder(tx *storage.Tx, folder *models.Folder, summary *models.FolderDeleteSummary) error {
+	subfolders, err := tx.Folders.GetByParentID(&folder.ID)
+	if err != nil {
+		return err
+	}
+	assets, err := tx.Assets.GetByFolderID(&folder.ID)
+	if err != nil {
+		return err
+	}
+	if len(subfolders) > 0 || len(assets) > 0 {
+		return models.ErrFolderNotEmpty
+	}
+
+	if err := tx.Folders.Delete(folder.ID); err != nil {
+		return err
+	}
+
+	summary.DeletedFolders = append(summary.DeletedFolders, folder.ID)
+	return nil
 }
 
-// MoveAsset moves an asset to a different folder
-func (s *FolderService) MoveAsset(assetID string, folderID *string) error {
-	// Verify asset exists
-	_, err := s.assetStore.GetByID(assetID)
+// deleteFolderToParent moves a folder's subfolders and assets up to its
+// parent, or the root, then deletes the folder
+func deleteFolderToParent(tx *storage.Tx, folder *models.Folder, summary *models.FolderDeleteSummary) error {
+	subfolders, err := tx.Folders.GetByParentID(&folder.ID)
+	if err != nil {
+		return err
+	}
+	assets, err := tx.Assets.GetByFolderID(&folder.ID)
 	if err != nil {
 		return err
 	}
 
-	// Verify folder exists if not null
-	if folderID != nil {
-		_, err := s.folderStore.GetByID(*folderID)
-		if err != nil {
+	now := time.Now()
+	for _, subfolder := range subfolders {
+		subfolder.ParentID = folder.ParentID
+		subfolder.UpdatedAt = now
+		if err := tx.Folders.Update(subfolder); err != nil {
+			return err
+		}
+		summary.MovedFolders = append(summary.MovedFolders, subfolder.ID)
+	}
+
+	for _, asset := range assets {
+		if err := tx.Assets.MoveAsset(asset.ID, folder.ParentID); err != nil {
 			return err
 		}
+		summary.MovedAssets = append(summary.MovedAssets, asset.ID)
+	}
+
+	if err := tx.Folders.Delete(folder.ID); err != nil {
+		return err
 	}
 
-	// Move the asset
-	return s.assetStore.MoveAsset(assetID, folderID)
+	summary.DeletedFolders = append(summary.DeletedFolders, folder.ID)
+	return nil
 }
 
-// GetFolderContents retrieves all assets in a folder
-func (s *FolderService) GetFolderContents(folderID *string) (*models.FolderContents, error) {
+// deleteFolderRecursively permanently deletes a folder with every subfolder
+// and asset in it, removing the assets' content from storage
+func deleteFolderRecursively(tx *storage.Tx, folder *models.Folder, summary *models.FolderDeleteSummary) error {
+	descendants, assets, err := folderSubtree(tx, folder)
+	if err != nil {
+		return err
+	}
+
+	for _, asset := range assets {
+		if err := purgeAsset(tx, asset); err != nil {
+			return err
+		}
+		summary.DeletedAssets = append(summary.DeletedAssets, asset.ID)
+	}
+
+	// Descendants come shallowest first, so walking them backwards removes
+	// every folder after its subfolders
+	folders := append([]*models.Folder{folder}, descendants...)
+	for i := len(folders) - 1; i >= 0; i-- {
+		if err := tx.Folders.Delete(folders[i].ID); err != nil {
+			return err
+		}
+	}
+	for _, f := range folders {
+		summary.DeletedFolders = append(summary.DeletedFolders, f.ID)
+	}
+
+	return nil
+}
+
+// MoveAsset moves an asset to a different folder
+func (s *FolderService) MoveAsset(assetID string, folderID *string) error {
+	return s.uow.Do(func(tx *storage.Tx) error {
+		// Verify asset exists
+		_, err := tx.Assets.GetByID(assetID)
+		if err != nil {
+			return err
+		}
+
+		// Verify folder exists if not null
+		if folderID != nil {
+			_, err := tx.Folders.GetByID(*folderID)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Move the asset
+		return tx.Assets.MoveAsset(assetID, folderID)
+	})
+}
+
+// GetFolderContents retrieves the subfolders of a folder and a page of the
+// assets in it. Any folder set in the query is replaced by this one.
+func (s *FolderService) GetFolderContents(folderID *string, query *models.AssetListQuery) (*models.FolderContents, error) {
 	// If folder ID is provided, verify it exists
 	if folderID != nil {
 		_, err := s.folderStore.GetByID(*folderID)
@@ -169,8 +344,12 @@ func (s *FolderService) GetFolderContents(folderID *string) (*models.FolderConte
 	}
 
 	// Get assets in the folder
-	assets, err := s.assetStore.GetByFolderID(folderID)
-	if err != nil {
+	query.FolderID = folderID
+	query.RootFolder = folderID == nil
+	page, err := s.assetStore.List(query)
+	if err == models.ErrInvalidCursor {
+		return nil, err
+	} else if err != nil {
 		return nil, fmt.Errorf("failed to get assets: %w", err)
 	}
 
@@ -182,29 +361,57 @@ func (s *FolderService) GetFolderContents(folderID *string) (*models.FolderConte
 
 	// Return combined results
 	return &models.FolderContents{
-		Assets:     assets,
+		Assets:     page.Assets,
 		SubFolders: subfolders,
+		NextCursor: page.NextCursor,
 	}, nil
 }
 
 // GetFolderPath retrieves the path from a folder to the root
 func (s *FolderService) GetFolderPath(folderID string) ([]*models.Folder, error) {
-	var path []*models.Folder
-	currentID := folderID
+	folder, err := s.folderStore.GetByID(folderID)
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		folder, err := s.folderStore.GetByID(currentID)
-		if err != nil {
-			return nil, err
-		}
+	ancestors, err := s.folderStore.GetAncestors(folderID)
+	if err != nil {
+		return nil, err
+	}
 
-		path = append([]*models.Folder{folder}, path...)
+	return append(ancestors, folder), nil
+}
 
-		if folder.ParentID == nil {
-			break
-		}
-		currentID = *folder.ParentID
+// GetFolderStats aggregates sizes and counts for a folder, or for the
+// whole library when folderID is nil
+func (s *FolderService) GetFolderStats(folderID *string) (*models.FolderStats, error) {
+	return s.folderStore.GetStats(folderID)
+}
+
+// GetFolderTree retrieves the root folders with their subfolders nested
+// inside them, down to maxDepth levels (0 for no limit)
+func (s *FolderService) GetFolderTree(maxDepth int) ([]*models.FolderTreeNode, error) {
+	roots, err := s.folderStore.GetTree(nil, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	if roots == nil {
+		roots = []*models.FolderTreeNode{}
+	}
+
+	return roots, nil
+}
+
+// GetSubtree retrieves a folder with its subfolders nested inside it, down
+// to maxDepth levels below it (0 for no limit)
+func (s *FolderService) GetSubtree(folderID string, maxDepth int) (*models.FolderTreeNode, error) {
+	nodes, err := s.folderStore.GetTree(&folderID, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, models.ErrFolderNotFound
 	}
 
-	return path, nil
+	return nodes[0], nil
 }
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultJobAttempts is how often a job runs before it is dead lettered
+	defaultJobAttempts = 5
+	// jobLease is how long a claimed job is held before another worker
+	// may assume its worker died and take it over
+	jobLease = 15 * time.Minute
+	// jobPollInterval is how long an idle worker waits before looking for
+	// due jobs again
+	jobPollInterval = time.Second
+	// jobRetryBase and jobRetryMax bound the backoff between attempts
+	jobRetryBase = 10 * time.Second
+	jobRetryMax  = time.Hour
+	// jobRetention is how long succeeded jobs are kept for status lookups
+	jobRetention = 7 * 24 * time.Hour
+	// jobCleanupInterval is how often old succeeded jobs are removed
+	jobCleanupInterval = time.Hour
+)
+
+var (
+	ErrMissingJobAsset = errors.New("job has no asset")
+)
+
+// JobHandler does the work of one job. Returning an error fails the
+// attempt, which is retried until the job runs out of attempts.
+type JobHandler func(job *models.Job) error
+
+// AssetJob adapts a function taking an asset ID to a JobHandler
+func AssetJob(fn func(assetID string) error) JobHandler {
+	return func(job *models.Job) error {
+		if job.AssetID == nil {
+			return ErrMissingJobAsset
+		}
+		return fn(*job.AssetID)
+	}
+}
+
+// JobService runs the jobs of the durable job queue
+type JobService struct {
+	jobStore storage.JobStore
+	handlers map[models.JobType]JobHandler
+}
+
+// NewJobService creates a new JobService
+func NewJobService(jobStore storage.JobStore) *JobService {
+	return &JobService{
+		jobStore: jobStore,
+		handlers: make(map[models.JobType]JobHandler),
+	}
+}
+
+// Register sets the handler for jobs of the given type. Handlers must be
+// registered before Run is called.
+func (s *JobService) Register(jobType models.JobType, handler JobHandler) {
+	s.handlers[jobType] = handler
+}
+
+// GetJob retrieves a job by ID
+func (s *JobService) GetJob(jobID string) (*models.Job, error) {
+	return s.jobStore.GetByID(jobID)
+}
+
+// GetAssetJobs retrieves the jobs queued for an asset
+func (s *JobService) GetAssetJobs(assetID string) ([]*models.Job, error) {
+	return s.jobStore.GetByAssetID(assetID)
+}
+
+// Run works through due jobs with the given number of workers and removes
+// old succeeded jobs, until stop is closed
+func (s *JobService) Run(workers int, stop <-chan struct{}) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(stop)
+		}()
+	}
+
+	ticker := time.NewTicker(jobCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := s.jobStore.DeleteSucceededBefore(time.Now().Add(-jobRetention))
+			if err != nil {
+				log.Printf("Failed to remove old jobs: %v", err)
+			} else if removed > 0 {
+				log.Printf("Removed %d old jobs", removed)
+			}
+		case <-stop:
+			wg.Wait()
+			return
+		}
+	}
+}
+
+// work claims and runs jobs one at a time, waiting between polls while
+// the queue is empty
+func (s *JobService) work(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		job, err := s.jobStore.Claim(jobLease)
+		if err != nil {
+			log.Printf("Failed to claim job: %v", err)
+		}
+		if job == nil {
+			select {
+			case <-time.After(jobPollInterval):
+			case <-stop:
+				return
+			}
+			continue
+		}
+
+		s.runJob(job)
+	}
+}
+
+// runJob runs a claimed job and records the outcome
+func (s *JobService) runJob(job *models.Job) {
+	// Outcomes are only recorded while the job still holds its lease
+	lease := *job.LockedUntil
+
+	err := s.handle(job)
+	if err == nil {
+		if err := s.jobStore.Complete(job.ID, lease); err != nil {
+			log.Printf("Failed to complete job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		log.Printf("Job %s (%s) failed for good after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+		if err := s.jobStore.Fail(job.ID, lease, err.Error()); err != nil {
+			log.Printf("Failed to dead letter job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	runAt := time.Now().Add(retryDelay(job.Attempts))
+	log.Printf("Job %s (%s) failed, retrying at %s: %v", job.ID, job.Type, runAt.Format(time.RFC3339), err)
+	if err := s.jobStore.Retry(job.ID, lease, runAt, err.Error()); err != nil {
+		log.Printf("Failed to requeue job %s: %v", job.ID, err)
+	}
+}
+
+// handle calls the handler of a job, turning a panic into an error so one
+// bad job can't take down its worker
+func (s *JobService) handle(job *models.Job) (err error) {
+	handler, ok := s.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler for job type %s", job.Type)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+
+	return handler(job)
+}
+
+// retryDelay doubles the wait after each failed attempt, up to jobRetryMax
+func retryDelay(attempts int) time.Duration {
+	delay := jobRetryBase
+	for i := 1; i < attempts && delay < jobRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, jobRetryMax)
+}
+
+// newAssetJob creates a job for an asset that is due right away
+func newAssetJob(jobType models.JobType, assetID string) *models.Job {
+	now := time.Now()
+	return &models.Job{
+		ID:          uuid.New().String(),
+		Type:        jobType,
+		AssetID:     &assetID,
+		Status:      models.JobStatusQueued,
+		MaxAttempts: defaultJobAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
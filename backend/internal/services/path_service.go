@@ -0,0 +1,173 @@
+package services
+
+import (
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+	"github.com/google/uuid"
+)
+
+// PathService addresses folders and assets by slash-separated paths of
+// folder names, such as /Books/Sci-Fi/dune.epub
+type PathService struct {
+	folderStore  storage.FolderStore
+	assetStore   storage.AssetStore
+	assetService *AssetService
+}
+
+// NewPathService creates a new PathService
+func NewPathService(folderStore storage.FolderStore, assetStore storage.AssetStore, assetService *AssetService) *PathService {
+	return &PathService{
+		folderStore:  folderStore,
+		assetStore:   assetStore,
+		assetService: assetService,
+	}
+}
+
+// Resolve finds the folder or asset a path names. A folder wins over an
+// asset with the same name in the same place.
+func (s *PathService) Resolve(path string) (*models.PathEntry, error) {
+	names, err := models.SplitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.PathEntry{
+		Type: models.PathEntryTypeFolder,
+		Path: models.JoinPath(names),
+	}
+	if len(names) == 0 {
+		return entry, nil
+	}
+
+	folders, err := s.folderStore.GetByPath(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(folders) == len(names) {
+		entry.Folder = folders[len(folders)-1]
+		return entry, nil
+	}
+
+	// Only the last name may be an asset
+	if len(folders) < len(names)-1 {
+		return nil, models.ErrPathNotFound
+	}
+
+	var folderID *string
+	if len(folders) > 0 {
+		folderID = &folders[len(folders)-1].ID
+	}
+
+	assets, err := s.assetStore.GetByName(folderID, names[len(names)-1])
+	if err != nil {
+		return nil, err
+	}
+	switch len(assets) {
+	case 0:
+		return nil, models.ErrPathNotFound
+	case 1:
+		entry.Type = models.PathEntryTypeAsset
+		entry.Asset = assets[0]
+		return entry, nil
+	default:
+		return nil, models.ErrAmbiguousPath
+	}
+}
+
+// UploadAsset stores an uploaded file at a path, creating any folders on
+// the way that don't exist yet. The media type is detected from the
+// content, and nothing may already exist at the path.
+func (s *PathService) UploadAsset(path string, fileHeader *multipart.FileHeader) (*models.Asset, error) {
+	names, err := models.SplitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, models.ErrInvalidPath
+	}
+	folderNames, name := names[:len(names)-1], names[len(names)-1]
+
+	// Open the uploaded file
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	mediaType, _, err := s.assetService.mediaTypes.Detect(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// The folders are made in the asset's own unit of work, so a failed
+	// upload doesn't leave empty folders behind
+	place := func(tx *storage.Tx, asset *models.Asset) error {
+		folderID, err := makeFolders(tx, folderNames)
+		if err != nil {
+			return err
+		}
+
+		folders, err := tx.Folders.GetByPath(names)
+		if err != nil {
+			return err
+		}
+		if len(folders) == len(names) {
+			return models.ErrPathExists
+		}
+
+		existing, err := tx.Assets.GetByName(folderID, name)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return models.ErrPathExists
+		}
+
+		asset.FolderID = folderID
+		return nil
+	}
+
+	return s.assetService.createAsset(file, name, fileHeader.Size, fileHeader.Header.Get("Content-Type"), mediaType, place)
+}
+
+// makeFolders creates whichever folders along a path are missing, like
+// mkdir -p, and returns the ID of the last one (nil for the root)
+func makeFolders(tx *storage.Tx, names []string) (*string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	existing, err := tx.Folders.GetByPath(names)
+	if err != nil {
+		return nil, err
+	}
+
+	var parentID *string
+	if len(existing) > 0 {
+		parentID = &existing[len(existing)-1].ID
+	}
+
+	now := time.Now()
+	for _, name := range names[len(existing):] {
+		folder := &models.Folder{
+			ID:        uuid.New().String(),
+			Name:      name,
+			ParentID:  parentID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		// Another upload may be creating the same folder, in which case
+		// both uploads go into it
+		folder, err := tx.Folders.SaveOrGet(folder)
+		if err != nil {
+			return nil, err
+		}
+		parentID = &folder.ID
+	}
+
+	return parentID, nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+)
+
+func TestMakeFoldersConcurrently(t *testing.T) {
+	s := newTestStores(t)
+	names := []string{"a", "b"}
+
+	// The first upload creates the folders and holds on to them while the
+	// second one, which saw none, tries to create them too
+	second := make(chan error, 1)
+	var firstID, secondID *string
+
+	err := s.uow.Do(func(tx *storage.Tx) error {
+		var err error
+		firstID, err = makeFolders(tx, names)
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			second <- s.uow.Do(func(tx *storage.Tx) error {
+				var err error
+				secondID, err = makeFolders(tx, names)
+				return err
+			})
+		}()
+
+		// Give the second upload time to wait for these folders
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("first makeFolders: %v", err)
+	}
+	if err := <-second; err != nil {
+		t.Fatalf("second makeFolders: %v", err)
+	}
+
+	if firstID == nil || secondID == nil || *firstID != *secondID {
+		t.Fatalf("folder IDs = %v, %v, want the same folder", firstID, secondID)
+	}
+
+	folders, err := s.folders.GetAll()
+	if err != nil {
+		t.Fatalf("get folders: %v", err)
+	}
+	count := map[string]int{}
+	for _, folder := range folders {
+		count[folder.Name]++
+	}
+	if len(folders) != 2 || count["a"] != 1 || count["b"] != 1 {
+		t.Errorf("folders = %v, want one a and one b", count)
+	}
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+)
+
+// DefaultReconcileGracePeriod is how old unreferenced content must be before it
+// counts as orphaned, so uploads that are still in flight are left alone
+const DefaultReconcileGracePeriod = time.Hour
+
+var (
+	ErrStorageNotListable = errors.New("storage provider does not support listing")
+)
+
+// ReconcileService checks that asset metadata and stored content agree
+type ReconcileService struct {
+	storage        storage.StorageProvider
+	assetStore     storage.AssetStore
+	blobStore      storage.BlobStore
+	renditionStore storage.RenditionStore
+	uow            storage.UnitOfWork
+}
+
+// NewReconcileService creates a new ReconcileService
+func NewReconcileService(storageProvider storage.StorageProvider, assetStore storage.AssetStore, blobStore storage.BlobStore, renditionStore storage.RenditionStore, uow storage.UnitOfWork) *ReconcileService {
+	return &ReconcileService{
+		storage:        storageProvider,
+		assetStore:     assetStore,
+		blobStore:      blobStore,
+		renditionStore: renditionStore,
+		uow:            uow,
+	}
+}
+
+// Reconcile walks all assets and all stored content and reports assets missing
+// content, content without assets, size mismatches and blob reference counts
+// that disagree with the assets. Depending on mode the problems are also
+// repaired or quarantined.
+func (s *ReconcileService) Reconcile(mode models.ReconcileMode, gracePeriod time.Duration) (*models.ReconcileReport, error) {
+	switch mode {
+	case models.ReconcileModeReport, models.ReconcileModeRepair, models.ReconcileModeQuarantine:
+	default:
+		return nil, models.ErrInvalidReconcileMode
+	}
+
+	lister, ok := s.storage.(storage.ListableStorageProvider)
+	if !ok {
+		return nil, ErrStorageNotListable
+	}
+
+	report := &models.ReconcileReport{
+		Mode:      mode,
+		StartedAt: time.Now(),
+		Issues:    []*models.ReconcileIssue{},
+	}
+
+	// Assets are loaded before the content is listed. Content saved by an
+	// upload that commits in between is then at worst unreferenced and
+	// young, rather than an asset that looks like it lost its content.
+	assets, err := s.assetStore.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assets: %w", err)
+	}
+	report.AssetsChecked = len(assets)
+
+	// Collect everything the provider holds, leaving quarantined content out
+	content := make(map[string]storage.ContentInfo)
+	err = lister.List(func(info storage.ContentInfo) error {
+		if !strings.HasPrefix(info.Key, storage.QuarantinePrefix) {
+			content[info.Key] = info
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored content: %w", err)
+	}
+	report.ContentChecked = len(content)
+
+	// Renditions are derived and can be generated again, so only content
+	// that no rendition points at is reported
+	renditions, err := s.renditionStore.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get renditions: %w", err)
+	}
+	referenced := make(map[string]bool)
+	for _, rendition := range renditions {
+		referenced[rendition.Key] = true
+	}
+
+	// Check every asset against its content. Assets younger than the grace
+	// period may still be mid-upload, so only their references count.
+	cutoff := report.StartedAt.Add(-gracePeriod)
+	digestRefs := make(map[string]int64)
+	for _, asset := range assets {
+		key := contentKey(asset)
+		referenced[key] = true
+		if asset.Digest != "" {
+			digestRefs[asset.Digest]++
+		}
+		if asset.CreatedAt.After(cutoff) {
+			continue
+		}
+
+		info, exists := content[key]
+		if !exists {
+			issue := &models.ReconcileIssue{
+				Kind:         models.ReconcileIssueMissingContent,
+				AssetID:      asset.ID,
+				Key:          key,
+				ExpectedSize: int64Ptr(asset.Size),
+			}
+			s.resolveMissingContent(mode, asset, issue)
+			report.Issues = append(report.Issues, issue)
+
+			// A deleted asset no longer holds a reference to its blob
+			if issue.Action == "deleted_asset" && asset.Digest != "" {
+				digestRefs[asset.Digest]--
+			}
+			continue
+		}
+
+		if info.Size != asset.Size {
+			issue := &models.ReconcileIssue{
+				Kind:         models.ReconcileIssueSizeMismatch,
+				AssetID:      asset.ID,
+				Key:          key,
+				ExpectedSize: int64Ptr(asset.Size),
+				ActualSize:   int64Ptr(info.Size),
+			}
+			s.resolveSizeMismatch(mode, asset, info, issue)
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+
+	// Blob reference counts must match the assets that point at them
+	blobs, err := s.blobStore.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blobs: %w", err)
+	}
+	report.BlobsChecked = len(blobs)
+
+	// The counts from the asset snapshot only point out suspect blobs. Uploads
+	// and purges carry on meanwhile, so repairs count again under the lock.
+	for _, blob := range blobs {
+		expected := digestRefs[blob.Digest]
+		if blob.RefCount == expected || blob.CreatedAt.After(cutoff) {
+			continue
+		}
+
+		issue := &models.ReconcileIssue{
+			Kind:         models.ReconcileIssueRefCount,
+			Key:          blob.Digest,
+			ExpectedRefs: int64Ptr(expected),
+			ActualRefs:   int64Ptr(blob.RefCount),
+		}
+		if mode == models.ReconcileModeRepair {
+			var previous, current int64
+			err := s.uow.Do(func(tx *storage.Tx) error {
+				var err error
+				previous, current, err = tx.Blobs.Recount(blob.Digest)
+				return err
+			})
+			if err != nil {
+				issue.Error = err.Error()
+			} else if previous == current {
+				// The snapshot was out of date and the count is right
+				continue
+			} else {
+				issue.ExpectedRefs = int64Ptr(current)
+				issue.ActualRefs = int64Ptr(previous)
+				issue.Action = "updated_ref_count"
+			}
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	// Anything left over that is old enough belongs to no asset
+	for key, info := range content {
+		if referenced[key] || info.ModTime.After(cutoff) {
+			continue
+		}
+
+		issue := &models.ReconcileIssue{
+			Kind:       models.ReconcileIssueOrphanContent,
+			Key:        key,
+			ActualSize: int64Ptr(info.Size),
+		}
+		s.resolveOrphanContent(mode, key, issue)
+		report.Issues = append(report.Issues, issue)
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// resolveMissingContent deletes or flags an asset whose content is gone.
+// Deleting purges the asset as the trash does, except for its content.
+func (s *ReconcileService) resolveMissingContent(mode models.ReconcileMode, asset *models.Asset, issue *models.ReconcileIssue) {
+	switch mode {
+	case models.ReconcileModeRepair:
+		err := s.uow.Do(func(tx *storage.Tx) error {
+			if _, err := releaseAsset(tx, asset); err != nil {
+				return err
+			}
+			return tx.Trash.DeleteAssetItem(asset.ID)
+		})
+		if err != nil {
+			issue.Error = err.Error()
+			return
+		}
+		issue.Action = "deleted_asset"
+	case models.ReconcileModeQuarantine:
+		if err := s.flagAsset(asset, models.ReconcileIssueMissingContent); err != nil {
+			issue.Error = err.Error()
+			return
+		}
+		issue.Action = "flagged_asset"
+	}
+}
+
+// resolveSizeMismatch trusts the stored content and updates or flags the asset
+func (s *ReconcileService) resolveSizeMismatch(mode models.ReconcileMode, asset *models.Asset, info storage.ContentInfo, issue *models.ReconcileIssue) {
+	switch mode {
+	case models.ReconcileModeRepair:
+		if err := s.assetStore.UpdateSize(asset.ID, info.Size); err != nil {
+			issue.Error = err.Error()
+			return
+		}
+		issue.Action = "updated_size"
+	case models.ReconcileModeQuarantine:
+		if err := s.flagAsset(asset, models.ReconcileIssueSizeMismatch); err != nil {
+			issue.Error = err.Error()
+			return
+		}
+		issue.Action = "flagged_asset"
+	}
+}
+
+// resolveOrphanContent deletes unreferenced content or moves it under the quarantine prefix
+func (s *ReconcileService) resolveOrphanContent(mode models.ReconcileMode, key string, issue *models.ReconcileIssue) {
+	switch mode {
+	case models.ReconcileModeRepair:
+		if err := s.storage.Delete(key); err != nil {
+			issue.Error = err.Error()
+			return
+		}
+		issue.Action = "deleted_content"
+	case models.ReconcileModeQuarantine:
+		if err := s.quarantineContent(key); err != nil {
+			issue.Error = err.Error()
+			return
+		}
+		issue.Action = "quarantined_content"
+	}
+}
+
+// quarantineContent copies content under the quarantine prefix and removes the original
+func (s *ReconcileService) quarantineContent(key string) error {
+	content, err := s.storage.Get(key)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if _, err := s.storage.Save(storage.QuarantinePrefix+key, content); err != nil {
+		return fmt.Errorf("failed to copy content to quarantine: %w", err)
+	}
+
+	return s.storage.Delete(key)
+}
+
+// flagAsset records a quarantine marker in the asset metadata. Only that
+// key is written, so assets in the trash can be flagged too.
+func (s *ReconcileService) flagAsset(asset *models.Asset, reason models.ReconcileIssueKind) error {
+	return s.assetStore.UpdateMetadata(asset.ID, map[string]interface{}{
+		"quarantine": map[string]interface{}{
+			"reason": reason,
+			"at":     time.Now().Format(time.RFC3339),
+		},
+	})
+}
+
+// int64Ptr returns a pointer to v
+func int64Ptr(v int64) *int64 {
+	return &v
+}
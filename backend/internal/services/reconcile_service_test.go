@@ -0,0 +1,101 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+)
+
+func TestReconcileRepairPurgesAssetWithoutContent(t *testing.T) {
+	s := newTestStores(t)
+
+	// A trashed asset, old enough to be checked, whose content is gone
+	// while its rendition is still stored
+	created := time.Now().Add(-2 * time.Hour)
+	asset := &models.Asset{
+		ID:          "asset-1",
+		Name:        "gone.pdf",
+		Type:        models.AssetTypePDF,
+		Size:        4,
+		ContentType: "application/pdf",
+		Digest:      "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		CreatedAt:   created,
+		UpdatedAt:   created,
+		Metadata:    map[string]interface{}{},
+	}
+	rendition := &models.Rendition{
+		AssetID:     asset.ID,
+		Kind:        models.RenditionKindText,
+		ContentType: "text/plain; charset=utf-8",
+		Size:        4,
+		Digest:      "digest",
+		Key:         renditionKey(asset.ID, models.RenditionKindText, "digest"),
+		CreatedAt:   created,
+	}
+	item := newTrashItem(models.TrashItemTypeAsset, asset.ID, asset.Name, []models.TrashPathEntry{})
+
+	err := s.uow.Do(func(tx *storage.Tx) error {
+		if _, _, err := tx.Blobs.Acquire(asset.Digest, asset.Size); err != nil {
+			return err
+		}
+		if err := tx.Assets.Save(asset); err != nil {
+			return err
+		}
+		if _, err := tx.Content.Save(rendition.Key, bytes.NewReader([]byte("text"))); err != nil {
+			return err
+		}
+		if err := tx.Renditions.Save(rendition); err != nil {
+			return err
+		}
+		return tx.Trash.TrashAsset(item)
+	})
+	if err != nil {
+		t.Fatalf("set up asset: %v", err)
+	}
+
+	reconciler := NewReconcileService(s.content, s.assets, s.blobs, s.renditions, s.uow)
+	report, err := reconciler.Reconcile(models.ReconcileModeRepair, time.Hour)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	var found bool
+	for _, issue := range report.Issues {
+		if issue.Kind != models.ReconcileIssueMissingContent {
+			t.Errorf("unexpected %s issue for %s", issue.Kind, issue.Key)
+			continue
+		}
+		found = true
+		if issue.Action != "deleted_asset" || issue.Error != "" {
+			t.Errorf("missing content issue has action %q and error %q", issue.Action, issue.Error)
+		}
+	}
+	if !found {
+		t.Fatal("missing content was not reported")
+	}
+
+	// Everything the purge removes is gone with the asset
+	renditions, err := s.renditions.GetByAssetID(asset.ID)
+	if err != nil {
+		t.Fatalf("GetByAssetID: %v", err)
+	}
+	if len(renditions) != 0 {
+		t.Errorf("%d renditions left", len(renditions))
+	}
+	if _, err := s.content.Get(rendition.Key); err == nil {
+		t.Error("rendition content left in storage")
+	}
+	if _, err := s.trash.GetByID(item.ID); err != models.ErrTrashItemNotFound {
+		t.Errorf("trash item lookup returned %v, want ErrTrashItemNotFound", err)
+	}
+	blobs, err := s.blobs.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll blobs: %v", err)
+	}
+	if len(blobs) != 0 {
+		t.Errorf("%d blobs left", len(blobs))
+	}
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/media"
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/thumbnail"
+)
+
+// thumbnailSizes lists the thumbnails made from a cover or placeholder, by
+// the size of the square they fit in
+var thumbnailSizes = []struct {
+	kind models.RenditionKind
+	size int
+}{
+	{models.RenditionKindThumbnailSmall, 128},
+	{models.RenditionKindThumbnailMedium, 256},
+	{models.RenditionKindThumbnailLarge, 512},
+}
+
+// waveform is the JSON document stored as a waveform rendition
+type waveform struct {
+	Points int       `json:"points"`
+	Peaks  []float64 `json:"peaks"`
+}
+
+// renditionData is a generated rendition waiting to be stored
+type renditionData struct {
+	kind        models.RenditionKind
+	contentType string
+	data        []byte
+}
+
+// RenditionService generates and serves the files derived from assets
+type RenditionService struct {
+	storage        storage.StorageProvider
+	assetStore     storage.AssetStore
+	renditionStore storage.RenditionStore
+	uow            storage.UnitOfWork
+	mediaTypes     *media.Registry
+}
+
+// NewRenditionService creates a new RenditionService
+func NewRenditionService(storageProvider storage.StorageProvider, assetStore storage.AssetStore, renditionStore storage.RenditionStore, uow storage.UnitOfWork, mediaTypes *media.Registry) *RenditionService {
+	return &RenditionService{
+		storage:        storageProvider,
+		assetStore:     assetStore,
+		renditionStore: renditionStore,
+		uow:            uow,
+		mediaTypes:     mediaTypes,
+	}
+}
+
+// Generate creates every rendition the media type of an asset supports and
+// stores them together. Each rendition is best effort, so content that
+// yields only some of them still gets those.
+func (s *RenditionService) Generate(assetID string) error {
+	asset, err := s.assetStore.GetByID(assetID)
+	if err == models.ErrAssetNotFound {
+		// Deleted before its turn came
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	mediaType, err := s.mediaTypes.Get(asset.Type)
+	if err != nil {
+		return err
+	}
+
+	file, err := spoolContent(s.storage, asset)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	var renditions, thumbs []renditionData
+
+	if extractor, ok := mediaType.(media.CoverExtractor); ok {
+		cover, err := extractor.ExtractCover(file, asset.Size)
+		if err != nil {
+			log.Printf("Failed to extract cover from asset %s: %v", asset.ID, err)
+		} else if cover != nil {
+			renditions = append(renditions, renditionData{models.RenditionKindCover, cover.ContentType, cover.Data})
+			thumbs = thumbnails(asset.ID, cover.Data)
+		}
+	}
+
+	// Content without a usable cover still gets thumbnails, drawn for its
+	// type. The cover itself is left missing.
+	if drawer, ok := mediaType.(media.PlaceholderDrawer); ok && len(thumbs) == 0 {
+		thumbs = placeholderThumbnails(asset.ID, drawer)
+	}
+	renditions = append(renditions, thumbs...)
+
+	if extractor, ok := mediaType.(media.TextExtractor); ok {
+		text, err := extractor.ExtractText(file, asset.Size)
+		if err != nil {
+			log.Printf("Failed to extract text from asset %s: %v", asset.ID, err)
+		} else if text != "" {
+			renditions = append(renditions, renditionData{models.RenditionKindText, "text/plain; charset=utf-8", []byte(text)})
+		}
+	}
+
+	if extractor, ok := mediaType.(media.WaveformExtractor); ok {
+		peaks, err := extractor.ExtractWaveform(file, asset.Size)
+		if err != nil {
+			log.Printf("Failed to compute waveform of asset %s: %v", asset.ID, err)
+		} else if peaks != nil {
+			data, err := json.Marshal(waveform{Points: len(peaks), Peaks: peaks})
+			if err != nil {
+				return fmt.Errorf("failed to encode waveform: %w", err)
+			}
+			renditions = append(renditions, renditionData{models.RenditionKindWaveform, "application/json", data})
+		}
+	}
+
+	if len(renditions) == 0 {
+		return nil
+	}
+	return s.save(asset.ID, renditions)
+}
+
+// thumbnails scales a cover image to each thumbnail size
+func thumbnails(assetID string, cover []byte) []renditionData {
+	img, err := thumbnail.Decode(cover)
+	if err != nil {
+		log.Printf("Failed to decode cover of asset %s: %v", assetID, err)
+		return nil
+	}
+
+	var renditions []renditionData
+	for _, t := range thumbnailSizes {
+		data, contentType, err := thumbnail.Encode(thumbnail.Fit(img, t.size))
+		if err != nil {
+			log.Printf("Failed to make %s of asset %s: %v", t.kind, assetID, err)
+			continue
+		}
+		renditions = append(renditions, renditionData{t.kind, contentType, data})
+	}
+	return renditions
+}
+
+// placeholderThumbnails draws the placeholder of a media type at each
+// thumbnail size
+func placeholderThumbnails(assetID string, drawer media.PlaceholderDrawer) []renditionData {
+	var renditions []renditionData
+	for _, t := range thumbnailSizes {
+		data, contentType, err := thumbnail.Encode(drawer.Placeholder(t.size))
+		if err != nil {
+			log.Printf("Failed to draw %s of asset %s: %v", t.kind, assetID, err)
+			continue
+		}
+		renditions = append(renditions, renditionData{t.kind, contentType, data})
+	}
+	return renditions
+}
+
+// save stores generated renditions, replacing older ones of the same kind.
+// Renditions that haven't changed are left as they are.
+func (s *RenditionService) save(assetID string, renditions []renditionData) error {
+	return s.uow.Do(func(tx *storage.Tx) error {
+		existing, err := tx.Renditions.GetByAssetID(assetID)
+		if err != nil {
+			return err
+		}
+		previous := make(map[models.RenditionKind]*models.Rendition)
+		for _, rendition := range existing {
+			previous[rendition.Kind] = rendition
+		}
+
+		now := time.Now()
+		for _, r := range renditions {
+			sum := sha256.Sum256(r.data)
+			digest := hex.EncodeToString(sum[:])
+
+			old := previous[r.kind]
+			if old != nil && old.Digest == digest && old.ContentType == r.contentType {
+				continue
+			}
+
+			key := renditionKey(assetID, r.kind, digest)
+			if _, err := tx.Content.Save(key, bytes.NewReader(r.data)); err != nil {
+				return fmt.Errorf("failed to save rendition: %w", err)
+			}
+
+			err := tx.Renditions.Save(&models.Rendition{
+				AssetID:     assetID,
+				Kind:        r.kind,
+				ContentType: r.contentType,
+				Size:        int64(len(r.data)),
+				Digest:      digest,
+				Key:         key,
+				CreatedAt:   now,
+			})
+			if err != nil {
+				return err
+			}
+
+			if old != nil && old.Key != key {
+				if err := tx.Content.Delete(old.Key); err != nil {
+					return fmt.Errorf("failed to delete rendition: %w", err)
+				}
+			}
+
+			// New text has to be searchable too
+			if r.kind == models.RenditionKindText {
+				if err := tx.Jobs.Enqueue(newAssetJob(models.JobTypeIndexText, assetID)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetRendition retrieves the rendition of an asset of the given kind. It
+// returns ErrRenditionNotProduced when assets of its type never have that
+// kind, and ErrRenditionNotFound when one could exist but doesn't, because
+// it hasn't been generated yet or the content has nothing to make it from.
+func (s *RenditionService) GetRendition(assetID string, kind models.RenditionKind) (*models.Rendition, error) {
+	if !kind.IsValid() {
+		return nil, models.ErrInvalidRenditionKind
+	}
+	asset, err := s.assetStore.GetByID(assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	rendition, err := s.renditionStore.Get(assetID, kind)
+	if err != models.ErrRenditionNotFound {
+		return rendition, err
+	}
+
+	mediaType, err := s.mediaTypes.Get(asset.Type)
+	if err != nil || !produces(mediaType, kind) {
+		return nil, models.ErrRenditionNotProduced
+	}
+	return nil, models.ErrRenditionNotFound
+}
+
+// produces reports whether a media type generates renditions of a kind
+func produces(mediaType media.MediaType, kind models.RenditionKind) bool {
+	switch kind {
+	case models.RenditionKindCover:
+		_, ok := mediaType.(media.CoverExtractor)
+		return ok
+	case models.RenditionKindThumbnailSmall, models.RenditionKindThumbnailMedium, models.RenditionKindThumbnailLarge:
+		_, cover := mediaType.(media.CoverExtractor)
+		_, placeholder := mediaType.(media.PlaceholderDrawer)
+		return cover || placeholder
+	case models.RenditionKindText:
+		_, ok := mediaType.(media.TextExtractor)
+		return ok
+	case models.RenditionKindWaveform:
+		_, ok := mediaType.(media.WaveformExtractor)
+		return ok
+	}
+	return false
+}
+
+// GetRenditions retrieves all renditions generated for an asset
+func (s *RenditionService) GetRenditions(assetID string) ([]*models.Rendition, error) {
+	if _, err := s.assetStore.GetByID(assetID); err != nil {
+		return nil, err
+	}
+
+	return s.renditionStore.GetByAssetID(assetID)
+}
+
+// OpenRendition opens a rendition for seeking
+func (s *RenditionService) OpenRendition(rendition *models.Rendition) (io.ReadSeekCloser, error) {
+	content, err := storage.NewContentReader(s.storage, rendition.Key, rendition.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rendition: %w", err)
+	}
+
+	return content, nil
+}
+
+// renditionKey returns the key a rendition is stored under. The digest
+// keeps a regenerated rendition from overwriting the one being served.
+func renditionKey(assetID string, kind models.RenditionKind, digest string) string {
+	return storage.RenditionPrefix + assetID + "/" + string(kind) + "-" + digest
+}
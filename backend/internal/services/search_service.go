@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/media"
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+)
+
+const (
+	// DefaultSearchLimit is the number of results returned when no limit is given
+	DefaultSearchLimit = 20
+	// MaxSearchLimit caps the number of results returned at once
+	MaxSearchLimit = 100
+	// maxIndexedText caps how much of a document's text is indexed, keeping
+	// its search vector well within what PostgreSQL allows
+	maxIndexedText = 256 << 10
+)
+
+// SearchService handles full-text search over assets
+type SearchService struct {
+	storage        storage.StorageProvider
+	assetStore     storage.AssetStore
+	renditionStore storage.RenditionStore
+	searchStore    storage.SearchStore
+	jobStore       storage.JobStore
+	mediaTypes     *media.Registry
+}
+
+// NewSearchService creates a new SearchService
+func NewSearchService(storageProvider storage.StorageProvider, assetStore storage.AssetStore, renditionStore storage.RenditionStore, searchStore storage.SearchStore, jobStore storage.JobStore, mediaTypes *media.Registry) *SearchService {
+	return &SearchService{
+		storage:        storageProvider,
+		assetStore:     assetStore,
+		renditionStore: renditionStore,
+		searchStore:    searchStore,
+		jobStore:       jobStore,
+		mediaTypes:     mediaTypes,
+	}
+}
+
+// Search retrieves the assets matching a query, best matches first
+func (s *SearchService) Search(query *models.SearchQuery) ([]*models.SearchResult, error) {
+	query.Text = strings.TrimSpace(query.Text)
+	if query.Text == "" {
+		return nil, models.ErrEmptySearchQuery
+	}
+	if query.Type != "" {
+		if _, err := s.mediaTypes.Get(query.Type); err != nil {
+			return nil, err
+		}
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = DefaultSearchLimit
+	}
+	query.Limit = min(query.Limit, MaxSearchLimit)
+	query.Offset = max(query.Offset, 0)
+
+	return s.searchStore.Search(query)
+}
+
+// IndexText adds the text rendition of an asset to the search index
+func (s *SearchService) IndexText(assetID string) error {
+	rendition, err := s.renditionStore.Get(assetID, models.RenditionKindText)
+	if err == models.ErrRenditionNotFound {
+		// The asset was deleted, taking its renditions along
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	content, err := s.storage.Get(rendition.Key)
+	if err != nil {
+		return fmt.Errorf("failed to get text rendition: %w", err)
+	}
+	defer content.Close()
+
+	data, err := io.ReadAll(io.LimitReader(content, maxIndexedText))
+	if err != nil {
+		return fmt.Errorf("failed to read text rendition: %w", err)
+	}
+
+	// PostgreSQL text can't hold NUL characters
+	text := strings.ToValidUTF8(string(data), "")
+	text = strings.ReplaceAll(text, "\x00", "")
+
+	if err := s.searchStore.SaveText(assetID, text); err != nil {
+		// Deleted while its text was being read
+		if _, getErr := s.assetStore.GetByID(assetID); getErr == models.ErrAssetNotFound {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Reindex queues every asset with a text rendition to be indexed again and
+// returns how many were queued
+func (s *SearchService) Reindex() (int, error) {
+	renditions, err := s.renditionStore.GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get renditions: %w", err)
+	}
+
+	queued := 0
+	for _, rendition := range renditions {
+		if rendition.Kind != models.RenditionKindText {
+			continue
+		}
+		if err := s.jobStore.Enqueue(newAssetJob(models.JobTypeIndexText, rendition.AssetID)); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+
+	return queued, nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+)
+
+// testDatabaseURLEnv names the variable holding a PostgreSQL connection
+// string for tests that need a real database. They are skipped without it.
+const testDatabaseURLEnv = "MEDIAHUB_TEST_DATABASE_URL"
+
+// testStores holds the stores of a test database, with content kept in a
+// temporary directory
+type testStores struct {
+	db          *sql.DB
+	assets      *storage.PostgresAssetStore
+	folders     *storage.PostgresFolderStore
+	blobs       *storage.PostgresBlobStore
+	renditions  *storage.PostgresRenditionStore
+	collections *storage.PostgresCollectionStore
+	trash       *storage.PostgresTrashStore
+	jobs        *storage.PostgresJobStore
+	content     *storage.FileSystemStorageProvider
+	uow         *storage.PostgresUnitOfWork
+}
+
+// newTestStores connects to the test database, confines the test to a
+// schema of its own that is dropped again afterwards, and creates the stores
+func newTestStores(t *testing.T) *testStores {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	admin, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		admin.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		admin.Close()
+	})
+
+	// Every connection of the pool gets the schema as its search path, so
+	// tests can run transactions side by side
+	db, err := sql.Open("postgres", withSearchPath(url, schema))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s := &testStores{db: db}
+	check := func(what string, err error) {
+		if err != nil {
+			t.Fatalf("create %s: %v", what, err)
+		}
+	}
+	s.assets, err = storage.NewPostgresAssetStore(db)
+	check("asset store", err)
+	s.folders, err = storage.NewPostgresFolderStore(db)
+	check("folder store", err)
+	s.blobs, err = storage.NewPostgresBlobStore(db)
+	check("blob store", err)
+	s.renditions, err = storage.NewPostgresRenditionStore(db)
+	check("rendition store", err)
+	s.collections, err = storage.NewPostgresCollectionStore(db)
+	check("collection store", err)
+	s.trash, err = storage.NewPostgresTrashStore(db)
+	check("trash store", err)
+	s.jobs, err = storage.NewPostgresJobStore(db)
+	check("job store", err)
+	s.content, err = storage.NewFileSystemStorageProvider(t.TempDir())
+	check("storage provider", err)
+
+	s.uow = storage.NewPostgresUnitOfWork(db, s.assets, s.folders, s.blobs, s.renditions, s.jobs, s.collections, s.trash, s.content)
+	return s
+}
+
+// withSearchPath adds a search path to a connection string in either the
+// URL or the key=value form
+func withSearchPath(url, schema string) string {
+	if !strings.HasPrefix(url, "postgres://") && !strings.HasPrefix(url, "postgresql://") {
+		return url + " search_path=" + schema
+	}
+	if strings.Contains(url, "?") {
+		return url + "&search_path=" + schema
+	}
+	return url + "?search_path=" + schema
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+	"github.com/google/uuid"
+)
+
+// TagService handles labelling assets with tags
+type TagService struct {
+	tagStore   storage.TagStore
+	assetStore storage.AssetStore
+}
+
+// NewTagService creates a new TagService
+func NewTagService(tagStore storage.TagStore, assetStore storage.AssetStore) *TagService {
+	return &TagService{
+		tagStore:   tagStore,
+		assetStore: assetStore,
+	}
+}
+
+// GetAllTags retrieves all tags with the number of assets using each
+func (s *TagService) GetAllTags() ([]*models.Tag, error) {
+	return s.tagStore.GetAll()
+}
+
+// TagAsset adds tags to an asset and returns the updated asset
+func (s *TagService) TagAsset(assetID string, names []string) (*models.Asset, error) {
+	names, err := models.NormalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.assetStore.GetByID(assetID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tags := make([]*models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = &models.Tag{
+			ID:        uuid.New().String(),
+			Name:      name,
+			CreatedAt: now,
+		}
+	}
+
+	if err := s.tagStore.AddToAsset(assetID, tags); err != nil {
+		return nil, err
+	}
+
+	return s.assetStore.GetByID(assetID)
+}
+
+// UntagAsset removes a tag from an asset and returns the updated asset
+func (s *TagService) UntagAsset(assetID string, name string) (*models.Asset, error) {
+	name, err := models.NormalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.assetStore.GetByID(assetID); err != nil {
+		return nil, err
+	}
+
+	if err := s.tagStore.RemoveFromAsset(assetID, name); err != nil {
+		return nil, err
+	}
+
+	return s.assetStore.GetByID(assetID)
+}
+
+// RenameTag changes the name of a tag. Taking the name of another tag is
+// refused; merging the tags does that.
+func (s *TagService) RenameTag(tagID string, name string) (*models.Tag, error) {
+	name, err := models.NormalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.tagStore.GetByName(name)
+	if err == nil && existing.ID != tagID {
+		return nil, models.ErrTagExists
+	} else if err != nil && err != models.ErrTagNotFound {
+		return nil, err
+	}
+
+	if err := s.tagStore.Rename(tagID, name); err != nil {
+		return nil, err
+	}
+
+	return s.tagStore.GetByID(tagID)
+}
+
+// MergeTags moves every asset of one tag to another and removes the
+// first, returning the tag that remains
+func (s *TagService) MergeTags(sourceID string, targetID string) (*models.Tag, error) {
+	if sourceID == targetID {
+		return nil, models.ErrTagMergeSelf
+	}
+
+	if _, err := s.tagStore.GetByID(sourceID); err != nil {
+		return nil, err
+	}
+	if _, err := s.tagStore.GetByID(targetID); err != nil {
+		return nil, err
+	}
+
+	if err := s.tagStore.Merge(sourceID, targetID); err != nil {
+		return nil, err
+	}
+
+	return s.tagStore.GetByID(targetID)
+}
+
+// DeleteTag removes a tag from every asset
+func (s *TagService) DeleteTag(tagID string) error {
+	return s.tagStore.Delete(tagID)
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+	"github.com/google/uuid"
+)
+
+// TrashService restores and purges deleted assets and folders
+type TrashService struct {
+	trashStore storage.TrashStore
+	uow        storage.UnitOfWork
+	retention  time.Duration
+}
+
+// NewTrashService creates a new TrashService. Items stay in the trash for
+// the retention period before they are purged for good.
+func NewTrashService(trashStore storage.TrashStore, uow storage.UnitOfWork, retention time.Duration) *TrashService {
+	return &TrashService{
+		trashStore: trashStore,
+		uow:        uow,
+		retention:  retention,
+	}
+}
+
+// GetTrash retrieves everything in the trash, most recently deleted first
+func (s *TrashService) GetTrash() ([]*models.TrashItem, error) {
+	return s.trashStore.GetAll()
+}
+
+// GetTrashItem retrieves a trash item by ID
+func (s *TrashService) GetTrashItem(itemID string) (*models.TrashItem, error) {
+	return s.trashStore.GetByID(itemID)
+}
+
+// Restore brings a trash item back to the folder it was deleted from.
+// Folders on the way that are gone by now are made again.
+func (s *TrashService) Restore(itemID string) (*models.TrashRestoreResult, error) {
+	result := &models.TrashRestoreResult{RecreatedFolders: []*models.Folder{}}
+
+	err := s.uow.Do(func(tx *storage.Tx) error {
+		item, err := tx.Trash.GetByID(itemID)
+		if err != nil {
+			return err
+		}
+		result.Item = item
+
+		var parentID *string
+		for _, entry := range item.OriginalPath {
+			folder, created, err := restoreFolder(tx, entry, parentID)
+			if err != nil {
+				return err
+			}
+			if created {
+				result.RecreatedFolders = append(result.RecreatedFolders, folder)
+			}
+			parentID = &folder.ID
+		}
+		result.FolderID = parentID
+
+		return tx.Trash.Restore(item, parentID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Purge permanently deletes a trash item with everything in it
+func (s *TrashService) Purge(itemID string) (*models.TrashItem, error) {
+	var purged *models.TrashItem
+
+	err := s.uow.Do(func(tx *storage.Tx) error {
+		item, err := tx.Trash.GetByID(itemID)
+		if err != nil {
+			return err
+		}
+		purged = item
+
+		assets, err := tx.Trash.GetAssets(itemID)
+		if err != nil {
+			return err
+		}
+		for _, asset := range assets {
+			if err := purgeAsset(tx, asset); err != nil {
+				return err
+			}
+		}
+
+		return tx.Trash.Purge(itemID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return purged, nil
+}
+
+// EmptyTrash purges every trash item and returns how many were removed
+func (s *TrashService) EmptyTrash() (int, error) {
+	items, err := s.trashStore.GetAll()
+	if err != nil {
+		return 0, err
+	}
+	return s.purgeItems(items)
+}
+
+// PurgeExpired purges the trash items deleted longer ago than the
+// retention period and returns how many were removed
+func (s *TrashService) PurgeExpired() (int, error) {
+	items, err := s.trashStore.GetDeletedBefore(time.Now().Add(-s.retention))
+	if err != nil {
+		return 0, err
+	}
+	return s.purgeItems(items)
+}
+
+// purgeItems purges trash items one at a time, so a failure leaves the
+// others purged
+func (s *TrashService) purgeItems(items []*models.TrashItem) (int, error) {
+	removed := 0
+	for _, item := range items {
+		if _, err := s.Purge(item.ID); err == models.ErrTrashItemNotFound {
+			// Restored or purged meanwhile
+			continue
+		} else if err != nil {
+			return removed, fmt.Errorf("failed to purge %s %s: %w", item.ItemType, item.ItemID, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// RunRetention purges expired trash items at the given interval until stop
+// is closed
+func (s *TrashService) RunRetention(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := s.PurgeExpired()
+			if err != nil {
+				log.Printf("Failed to purge expired trash: %v", err)
+			} else if removed > 0 {
+				log.Printf("Purged %d expired trash items", removed)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// restoreFolder finds the folder a path entry stands for under parentID:
+// the folder itself if it is still there, otherwise one of the same name,
+// otherwise a new one. It reports whether the folder had to be made.
+func restoreFolder(tx *storage.Tx, entry models.TrashPathEntry, parentID *string) (*models.Folder, bool, error) {
+	folder, err := tx.Folders.GetByID(entry.ID)
+	if err == nil {
+		return folder, false, nil
+	} else if err != models.ErrFolderNotFound {
+		return nil, false, err
+	}
+
+	siblings, err := tx.Folders.GetByParentID(parentID)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, sibling := range siblings {
+		if sibling.Name == entry.Name {
+			return sibling, false, nil
+		}
+	}
+
+	now := time.Now()
+	folder = &models.Folder{
+		ID:        uuid.New().String(),
+		Name:      entry.Name,
+		ParentID:  parentID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := tx.Folders.Save(folder); err != nil {
+		return nil, false, err
+	}
+
+	return folder, true, nil
+}
+
+// trashPath lists the folders from the root down to folderID, for the
+// original path of a trash item
+func trashPath(folders storage.FolderStore, folderID *string) ([]models.TrashPathEntry, error) {
+	path := []models.TrashPathEntry{}
+	if folderID == nil {
+		return path, nil
+	}
+
+	folder, err := folders.GetByID(*folderID)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := folders.GetAncestors(*folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ancestor := range append(ancestors, folder) {
+		path = append(path, models.TrashPathEntry{ID: ancestor.ID, Name: ancestor.Name})
+	}
+
+	return path, nil
+}
+
+// newTrashItem creates a trash item for an asset or folder deleted now
+func newTrashItem(itemType models.TrashItemType, itemID string, name string, path []models.TrashPathEntry) *models.TrashItem {
+	return &models.TrashItem{
+		ID:           uuid.New().String(),
+		ItemType:     itemType,
+		ItemID:       itemID,
+		Name:         name,
+		OriginalPath: path,
+		DeletedAt:    time.Now(),
+	}
+}
+
+// purgeAsset permanently deletes an asset with its renditions, and its
+// content once no other asset shares it
+func purgeAsset(tx *storage.Tx, asset *models.Asset) error {
+	last, err := releaseAsset(tx, asset)
+	if err != nil || !last {
+		return err
+	}
+
+	// Delete the file using the storage provider
+	if err := tx.Content.Delete(contentKey(asset)); err != nil {
+		return fmt.Errorf("failed to delete asset file: %w", err)
+	}
+
+	return nil
+}
+
+// releaseAsset deletes an asset with its renditions and drops its reference
+// to its content, reporting whether it was the last one. The content itself
+// is left for the caller.
+func releaseAsset(tx *storage.Tx, asset *models.Asset) (bool, error) {
+	// Renditions belong to this asset alone, so they always go with it
+	renditions, err := tx.Renditions.GetByAssetID(asset.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, rendition := range renditions {
+		if err := tx.Content.Delete(rendition.Key); err != nil {
+			return false, fmt.Errorf("failed to delete rendition: %w", err)
+		}
+	}
+	if err := tx.Renditions.DeleteByAssetID(asset.ID); err != nil {
+		return false, err
+	}
+
+	// Remove the asset from the asset store
+	if err := tx.Assets.Delete(asset.ID); err != nil {
+		return false, fmt.Errorf("failed to delete asset metadata: %w", err)
+	}
+
+	// Shared content stays until its last asset is gone
+	if asset.Digest != "" {
+		remaining, err := tx.Blobs.Release(asset.Digest)
+		if err != nil {
+			return false, err
+		}
+		return remaining == 0, nil
+	}
+
+	return true, nil
+}
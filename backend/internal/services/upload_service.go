@@ -0,0 +1,342 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/media"
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/SaadBeidourii/MediaHub.git/internal/storage"
+	"github.com/SaadBeidourii/MediaHub.git/pkg/validator"
+	"github.com/google/uuid"
+)
+
+// UploadService stages resumable uploads on local disk and turns finished
+// uploads into assets through the AssetService
+type UploadService struct {
+	uploadStore  storage.UploadStore
+	assetService *AssetService
+	mediaTypes   *media.Registry
+	stagingDir   string
+	expiry       time.Duration
+
+	// Chunks for one upload are written one at a time
+	mu    sync.Mutex
+	locks map[string]*refMutex
+}
+
+// NewUploadService creates a new UploadService
+func NewUploadService(uploadStore storage.UploadStore, assetService *AssetService, mediaTypes *media.Registry, stagingDir string, expiry time.Duration) (*UploadService, error) {
+	if err := os.MkdirAll(stagingDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create upload staging directory: %w", err)
+	}
+
+	return &UploadService{
+		uploadStore:  uploadStore,
+		assetService: assetService,
+		mediaTypes:   mediaTypes,
+		stagingDir:   stagingDir,
+		expiry:       expiry,
+		locks:        make(map[string]*refMutex),
+	}, nil
+}
+
+// MaxUploadSize returns the largest upload accepted for any media type
+func (s *UploadService) MaxUploadSize() int64 {
+	return s.mediaTypes.MaxSize()
+}
+
+// CreateUpload registers a new upload of the given length. An asset type in
+// the "assetType" metadata entry is checked up front so a client doesn't send
+// a whole file that could never be accepted; without one the type is
+// detected once the upload is complete.
+func (s *UploadService) CreateUpload(length int64, metadata map[string]string) (*models.Upload, error) {
+	if length < 0 {
+		return nil, models.ErrUploadInvalidLength
+	}
+
+	assetType := models.AssetType(metadata["assetType"])
+	maxSize := s.mediaTypes.MaxSize()
+	if assetType != "" {
+		mediaType, err := s.mediaTypes.Get(assetType)
+		if err != nil {
+			return nil, models.ErrUploadInvalidType
+		}
+		maxSize = mediaType.MaxSize()
+	}
+	if length == 0 {
+		return nil, validator.ErrEmptyFile
+	}
+	if length > maxSize {
+		return nil, validator.ErrFileTooLarge
+	}
+
+	now := time.Now()
+	upload := &models.Upload{
+		ID:        uuid.New().String(),
+		Type:      assetType,
+		Length:    length,
+		Metadata:  metadata,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(s.expiry),
+	}
+
+	file, err := os.OpenFile(s.stagingPath(upload.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staged upload: %w", err)
+	}
+	file.Close()
+
+	if err := s.uploadStore.Save(upload); err != nil {
+		os.Remove(s.stagingPath(upload.ID))
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// GetUpload retrieves an upload that has not expired yet
+func (s *UploadService) GetUpload(id string) (*models.Upload, error) {
+	upload, err := s.uploadStore.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(upload.ExpiresAt) {
+		return nil, models.ErrUploadNotFound
+	}
+
+	return upload, nil
+}
+
+// WriteChunk appends content at the given offset, which must match the bytes
+// received so far. Whatever arrives is kept even if the client goes away, so
+// the upload can resume from there. Once the last byte is in, the staged file
+// is validated and stored as an asset. The upload is returned alongside any
+// error that happens after the content was received.
+func (s *UploadService) WriteChunk(id string, offset int64, content io.Reader) (*models.Upload, error) {
+	unlock := s.lock(id)
+	defer unlock()
+
+	upload, err := s.GetUpload(id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.AssetID != nil {
+		return nil, models.ErrUploadComplete
+	}
+	if offset != upload.Offset {
+		return nil, models.ErrUploadOffsetMismatch
+	}
+
+	file, err := os.OpenFile(s.stagingPath(id), os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open staged upload: %w", err)
+	}
+	defer file.Close()
+
+	// Drop anything past the recorded offset, left behind by a write whose
+	// offset never made it to the database
+	if err := file.Truncate(offset); err != nil {
+		return nil, fmt.Errorf("failed to truncate staged upload: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek staged upload: %w", err)
+	}
+
+	// Read one byte more than is missing to notice oversized chunks
+	remaining := upload.Length - offset
+	written, copyErr := io.Copy(file, io.LimitReader(content, remaining+1))
+	if written > remaining {
+		file.Truncate(offset)
+		return nil, models.ErrUploadTooLarge
+	}
+
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync staged upload: %w", err)
+	}
+
+	upload.Offset += written
+	upload.ExpiresAt = time.Now().Add(s.expiry)
+	if err := s.uploadStore.Update(upload); err != nil {
+		return nil, err
+	}
+
+	if copyErr != nil {
+		return upload, fmt.Errorf("failed to receive upload content: %w", copyErr)
+	}
+
+	if upload.IsComplete() {
+		if err := s.finish(upload); err != nil {
+			return upload, err
+		}
+	}
+
+	return upload, nil
+}
+
+// TerminateUpload discards an upload and its staged content
+func (s *UploadService) TerminateUpload(id string) error {
+	unlock := s.lock(id)
+	defer unlock()
+
+	if _, err := s.GetUpload(id); err != nil {
+		return err
+	}
+
+	return s.discard(id)
+}
+
+// ExpireUploads discards every upload that has been idle past its expiry and
+// returns how many were removed
+func (s *UploadService) ExpireUploads() (int, error) {
+	expired, err := s.uploadStore.GetExpired(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, upload := range expired {
+		unlock := s.lock(upload.ID)
+
+		// A chunk may have arrived while we waited for the lock
+		current, err := s.uploadStore.GetByID(upload.ID)
+		if err == nil && time.Now().After(current.ExpiresAt) {
+			if err := s.discard(upload.ID); err != nil {
+				log.Printf("Failed to expire upload %s: %v", upload.ID, err)
+			} else {
+				removed++
+			}
+		}
+
+		unlock()
+	}
+
+	return removed, nil
+}
+
+// RunExpiry expires stale uploads at the given interval until stop is closed
+func (s *UploadService) RunExpiry(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := s.ExpireUploads()
+			if err != nil {
+				log.Printf("Failed to expire uploads: %v", err)
+			} else if removed > 0 {
+				log.Printf("Expired %d abandoned uploads", removed)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// finish hands a complete upload to the asset pipeline. Content that fails
+// validation can never succeed, so the upload is discarded in that case.
+func (s *UploadService) finish(upload *models.Upload) error {
+	file, err := os.Open(s.stagingPath(upload.ID))
+	if err != nil {
+		return fmt.Errorf("failed to open staged upload: %w", err)
+	}
+
+	name := upload.Metadata["filename"]
+	if name == "" {
+		name = upload.ID
+	}
+
+	asset, err := s.assetService.CreateAssetFromFile(
+		file,
+		name,
+		upload.Length,
+		upload.Metadata["filetype"],
+		upload.Type,
+	)
+	file.Close()
+	if err != nil {
+		if isValidationError(err) {
+			if discardErr := s.discard(upload.ID); discardErr != nil {
+				log.Printf("Failed to discard rejected upload %s: %v", upload.ID, discardErr)
+			}
+		}
+		return err
+	}
+
+	// Keep the record until it expires so the client can look up the asset
+	upload.AssetID = &asset.ID
+	if err := s.uploadStore.Update(upload); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.stagingPath(upload.ID)); err != nil {
+		log.Printf("Failed to remove staged upload %s: %v", upload.ID, err)
+	}
+
+	return nil
+}
+
+// discard removes an upload record and its staged file
+func (s *UploadService) discard(id string) error {
+	if err := os.Remove(s.stagingPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove staged upload: %w", err)
+	}
+
+	if err := s.uploadStore.Delete(id); err != nil && err != models.ErrUploadNotFound {
+		return err
+	}
+
+	return nil
+}
+
+// lock serializes work on one upload and returns the matching unlock
+func (s *UploadService) lock(id string) func() {
+	s.mu.Lock()
+	uploadLock, exists := s.locks[id]
+	if !exists {
+		uploadLock = &refMutex{}
+		s.locks[id] = uploadLock
+	}
+	uploadLock.refs++
+	s.mu.Unlock()
+
+	uploadLock.Lock()
+	return func() {
+		uploadLock.Unlock()
+
+		s.mu.Lock()
+		uploadLock.refs--
+		if uploadLock.refs == 0 {
+			delete(s.locks, id)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// refMutex is a mutex that counts the callers holding or waiting for it
+type refMutex struct {
+	sync.Mutex
+	refs int
+}
+
+// stagingPath returns where the content of an upload is staged
+func (s *UploadService) stagingPath(id string) string {
+	return filepath.Join(s.stagingDir, id)
+}
+
+// isValidationError reports whether err rejects the content itself
+func isValidationError(err error) bool {
+	return errors.Is(err, media.ErrUnsupportedMediaType) ||
+		errors.Is(err, validator.ErrInvalidFileType) ||
+		errors.Is(err, validator.ErrFileTooLarge) ||
+		errors.Is(err, validator.ErrEmptyFile)
+}
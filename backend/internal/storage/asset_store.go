@@ -6,21 +6,50 @@ import (
 
 // AssetStore is an interface for accessing asset metadata
 type AssetStore interface {
-	// Save stores asset metadata
+	// Save stores asset metadata. Asset names are unique within a folder
+	// outside the trash, and ErrPathExists is returned for a taken one.
 	Save(asset *models.Asset) error
 
 	// GetByID retrieves an asset by its ID
 	GetByID(id string) (*models.Asset, error)
 
+	// Update updates an existing asset, returning ErrPathExists when its
+	// folder already has another asset of its name
+	Update(asset *models.Asset) error
+
+	// Rename changes the name of an asset, returning ErrPathExists when
+	// another asset in its folder has the name
+	Rename(id string, name string) error
+
+	// UpdateMetadata merges the given keys into an asset's metadata
+	UpdateMetadata(id string, metadata map[string]interface{}) error
+
+	// UpdateSize sets the recorded content size of an asset, whether or
+	// not it is in the trash
+	UpdateSize(id string, size int64) error
+
 	// GetAll retrieves all assets
 	GetAll() ([]*models.Asset, error)
 
 	// Delete removes an asset from the store
 	Delete(id string) error
 
+	// GetByDigest retrieves all assets whose content has the given digest
+	GetByDigest(digest string) ([]*models.Asset, error)
+
+	// List retrieves a page of assets matching a filter in the requested order
+	List(query *models.AssetListQuery) (*models.AssetPage, error)
+
 	// GetByFolderID retrieves all assets in a folder
 	GetByFolderID(folderID *string) ([]*models.Asset, error)
 
-	// Move asset to a different folder
+	// GetByName retrieves the assets with a name in a folder, oldest first
+	GetByName(folderID *string, name string) ([]*models.Asset, error)
+
+	// Move asset to a different folder, returning ErrPathExists when the
+	// folder already has an asset of its name
 	MoveAsset(assetID string, folderID *string) error
+
+	// GetCollections retrieves the collections an asset belongs to
+	GetCollections(assetID string) ([]*models.Collection, error)
 }
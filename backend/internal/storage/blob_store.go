@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"time"
+)
+
+// Blob is a stored piece of content shared by every asset with the same digest
+type Blob struct {
+	Digest    string
+	Size      int64
+	RefCount  int64
+	Path      string
+	CreatedAt time.Time
+}
+
+// BlobStore is an interface for reference counting content-addressed blobs
+type BlobStore interface {
+	// Acquire adds a reference to the blob with the given digest, creating it
+	// if needed. It reports whether the blob is new, in which case its content
+	// still has to be saved.
+	Acquire(digest string, size int64) (*Blob, bool, error)
+
+	// SetPath records where a new blob's content was saved
+	SetPath(digest string, path string) error
+
+	// Release drops a reference to a blob and returns how many are left.
+	// The blob record is removed when the last reference goes away.
+	Release(digest string) (int64, error)
+
+	// GetAll retrieves all blobs
+	GetAll() ([]*Blob, error)
+
+	// Recount sets a blob's reference count to the number of assets with
+	// its digest, removing it at zero, and returns the count before and
+	// after. A blob that is gone counts as zero both times.
+	Recount(digest string) (int64, int64, error)
+}
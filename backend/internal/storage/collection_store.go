@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// CollectionStore is an interface for managing collections and their items
+type CollectionStore interface {
+	// Save stores a new collection
+	Save(collection *models.Collection) error
+
+	// GetByID retrieves a collection by its ID
+	GetByID(id string) (*models.Collection, error)
+
+	// GetAll retrieves all collections
+	GetAll() ([]*models.Collection, error)
+
+	// Update updates the name and description of a collection
+	Update(collection *models.Collection) error
+
+	// Delete removes a collection and its items
+	Delete(id string) error
+
+	// Touch sets the updated time of a collection. Inside a unit of work it
+	// also keeps other changes to the collection out until the work ends.
+	Touch(id string, at time.Time) error
+
+	// GetItems retrieves the items of a collection in order
+	GetItems(collectionID string) ([]*models.CollectionItem, error)
+
+	// AddItems appends assets to the end of a collection. Assets already in
+	// the collection are left where they are.
+	AddItems(collectionID string, assetIDs []string, addedAt time.Time) error
+
+	// RemoveItem removes an asset from a collection
+	RemoveItem(collectionID string, assetID string) error
+
+	// Reorder puts the items of a collection in the order of assetIDs,
+	// which must list each item GetItems returns once
+	Reorder(collectionID string, assetIDs []string) error
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// limitedReadCloser closes the underlying content of a limited reader
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ContentReader is an io.ReadSeekCloser over stored content of a known size.
+// Seeks only move the position. The open content is kept until a read needs
+// it somewhere else, so seeking to the end to find the size and back costs
+// nothing. Reads elsewhere reopen the content at the new position, using
+// ranged reads when the provider supports them. Without them, reads ahead
+// skip forward on the open content.
+type ContentReader struct {
+	provider StorageProvider
+	key      string
+	size     int64
+
+	offset        int64
+	current       io.ReadCloser // Nil until needed
+	currentOffset int64         // Where current is positioned
+}
+
+// NewContentReader opens stored content of the given size for seeking.
+// The content is opened right away so a missing key is reported here rather
+// than on the first read, after a response has been started.
+func NewContentReader(provider StorageProvider, key string, size int64) (*ContentReader, error) {
+	r := &ContentReader{
+		provider: provider,
+		key:      key,
+		size:     size,
+	}
+
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Read implements io.Reader
+func (r *ContentReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	if err := r.position(); err != nil {
+		return 0, err
+	}
+
+	n, err := r.current.Read(p)
+	r.offset += int64(n)
+	r.currentOffset = r.offset
+	return n, err
+}
+
+// Seek implements io.Seeker
+func (r *ContentReader) Seek(offset int64, whence int) (int64, error) {
+	var position int64
+	switch whence {
+	case io.SeekStart:
+		position = offset
+	case io.SeekCurrent:
+		position = r.offset + offset
+	case io.SeekEnd:
+		position = r.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if position < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	r.offset = position
+	return position, nil
+}
+
+// Close implements io.Closer
+func (r *ContentReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
+
+// position makes the open content ready to read at the current offset
+func (r *ContentReader) position() error {
+	if r.current != nil && r.currentOffset != r.offset {
+		_, ranged := r.provider.(RangeStorageProvider)
+		if !ranged && r.offset > r.currentOffset {
+			if _, err := io.CopyN(io.Discard, r.current, r.offset-r.currentOffset); err != nil {
+				return fmt.Errorf("failed to skip to content offset: %w", err)
+			}
+			r.currentOffset = r.offset
+			return nil
+		}
+
+		r.current.Close()
+		r.current = nil
+	}
+
+	if r.current == nil {
+		return r.open()
+	}
+	return nil
+}
+
+// open opens the underlying content at the current offset
+func (r *ContentReader) open() error {
+	if ranged, ok := r.provider.(RangeStorageProvider); ok {
+		content, err := ranged.GetRange(r.key, r.offset, r.size-r.offset)
+		if err != nil {
+			return err
+		}
+		r.current = content
+		r.currentOffset = r.offset
+		return nil
+	}
+
+	content, err := r.provider.Get(r.key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(io.Discard, content, r.offset); err != nil {
+		content.Close()
+		return fmt.Errorf("failed to skip to content offset: %w", err)
+	}
+
+	r.current = content
+	r.currentOffset = r.offset
+	return nil
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// countingProvider serves fixed content and counts how often it is opened
+type countingProvider struct {
+	content []byte
+	opens   int
+}
+
+func (p *countingProvider) Save(key string, content io.Reader) (string, error) {
+	return "", errors.New("read only")
+}
+
+func (p *countingProvider) Get(key string) (io.ReadCloser, error) {
+	if key != "key" {
+		return nil, errors.New("not found")
+	}
+	p.opens++
+	return io.NopCloser(bytes.NewReader(p.content)), nil
+}
+
+func (p *countingProvider) Delete(key string) error {
+	return errors.New("read only")
+}
+
+// newTestContentReader opens the provider's content for seeking
+func newTestContentReader(t *testing.T, provider *countingProvider) *ContentReader {
+	t.Helper()
+
+	r, err := NewContentReader(provider, "key", int64(len(provider.content)))
+	if err != nil {
+		t.Fatalf("NewContentReader: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestContentReaderReusesContentAfterSizeProbe(t *testing.T) {
+	provider := &countingProvider{content: []byte("0123456789")}
+	r := newTestContentReader(t, provider)
+
+	// Finding the size the way http.ServeContent does keeps the content
+	// opened up front
+	if size, err := r.Seek(0, io.SeekEnd); err != nil || size != 10 {
+		t.Fatalf("Seek to end = %d, %v", size, err)
+	}
+	if _, err := r.Seek(4, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != "456789" || provider.opens != 1 {
+		t.Errorf("read %q with %d opens, want %q with 1", got, provider.opens, "456789")
+	}
+}
+
+func TestContentReaderSeeksBack(t *testing.T) {
+	provider := &countingProvider{content: []byte("0123456789")}
+	r := newTestContentReader(t, provider)
+
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(r, buf); err != nil || string(buf) != "012" {
+		t.Fatalf("first read = %q, %v", buf, err)
+	}
+	if _, err := r.Seek(1, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	if _, err := io.ReadFull(r, buf); err != nil || string(buf) != "123" {
+		t.Fatalf("read after seeking back = %q, %v", buf, err)
+	}
+	if provider.opens != 2 {
+		t.Errorf("content opened %d times, want 2", provider.opens)
+	}
+}
+
+func TestContentReaderMissingContent(t *testing.T) {
+	if _, err := NewContentReader(&countingProvider{}, "missing", 10); err == nil {
+		t.Error("NewContentReader of missing content succeeded")
+	}
+}
+
+func TestContentReaderServesRange(t *testing.T) {
+	provider := &countingProvider{content: []byte("0123456789")}
+	r := newTestContentReader(t, provider)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	w := httptest.NewRecorder()
+	// Handlers always set the type, which spares a read to sniff it
+	w.Header().Set("Content-Type", "text/plain")
+	http.ServeContent(w, req, "", time.Time{}, r)
+
+	if w.Code != http.StatusPartialContent || w.Body.String() != "2345" {
+		t.Errorf("response = %d %q, want 206 %q", w.Code, w.Body.String(), "2345")
+	}
+	if provider.opens != 1 {
+		t.Errorf("content opened %d times, want 1", provider.opens)
+	}
+}
@@ -2,19 +2,49 @@ package storage
 
 import (
 	"io"
-	"mime/multipart"
-
-	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"time"
 )
 
-
+// StorageProvider is an interface for storing file content under a key.
+// Keys are plain names (usually asset IDs), optionally namespaced with
+// slash-separated prefixes such as "quarantine/<key>".
 type StorageProvider interface {
-	// Save stores a file and returns its storage path
-	Save(file multipart.File, asset *models.Asset) (string, error)
+	// Save stores content under key and returns its storage path
+	Save(key string, content io.Reader) (string, error)
+
+	// Get retrieves content by its key
+	Get(key string) (io.ReadCloser, error)
+
+	// Delete removes content from storage
+	Delete(key string) error
+}
+
+// ContentInfo describes a stored content object
+type ContentInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// ListableStorageProvider is implemented by providers that can enumerate their content
+type ListableStorageProvider interface {
+	StorageProvider
+
+	// List calls fn for every stored content object, stopping at the first error
+	List(fn func(info ContentInfo) error) error
+}
 
-	// Get retrieves a file by its ID
-	Get(assetID string) (io.ReadCloser, error)
+// RangeStorageProvider is implemented by providers that can read part of
+// stored content without fetching everything before it
+type RangeStorageProvider interface {
+	StorageProvider
 
-	// Delete removes a file from storage
-	Delete(assetID string) error
+	// GetRange retrieves length bytes of content starting at offset
+	GetRange(key string, offset, length int64) (io.ReadCloser, error)
 }
+
+// QuarantinePrefix is the key namespace that holds quarantined content
+const QuarantinePrefix = "quarantine/"
+
+// RenditionPrefix is the key namespace that holds files derived from assets
+const RenditionPrefix = "renditions/"
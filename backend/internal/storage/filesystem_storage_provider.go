@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileSystemStorageProvider implements StorageProvider on the local filesystem.
+// Files are sharded into two levels of directories taken from the key
+// prefix (e.g. <root>/ab/cd/abcd1234-...) so no single directory grows too large.
+// Namespaced keys keep their prefix as directories (<root>/quarantine/ab/cd/...).
+type FileSystemStorageProvider struct {
+	root string
+}
+
+// NewFileSystemStorageProvider creates a new FileSystemStorageProvider rooted at root
+func NewFileSystemStorageProvider(root string) (*FileSystemStorageProvider, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve storage root: %w", err)
+	}
+
+	if err := os.MkdirAll(absRoot, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage root: %w", err)
+	}
+
+	return &FileSystemStorageProvider{
+		root: absRoot,
+	}, nil
+}
+
+// Save writes a file to disk and returns its file:// path.
+// Content is written to a temporary file in the target directory first and
+// renamed into place, so readers never observe a partially written file.
+func (fs *FileSystemStorageProvider) Save(key string, content io.Reader) (string, error) {
+	target, err := fs.pathFor(key)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(target)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create shard directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	// Make sure the temporary file never outlives a failed write
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		return "", fmt.Errorf("failed to write file content: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return "", fmt.Errorf("failed to sync file content: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		return "", fmt.Errorf("failed to move file into place: %w", err)
+	}
+	committed = true
+
+	return "file://" + filepath.ToSlash(target), nil
+}
+
+// Get opens a file from disk
+func (fs *FileSystemStorageProvider) Get(key string) (io.ReadCloser, error) {
+	path, err := fs.pathFor(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("file content not found for key: %s", key)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open file content: %w", err)
+	}
+
+	return file, nil
+}
+
+// GetRange opens a file and reads length bytes from offset
+func (fs *FileSystemStorageProvider) GetRange(key string, offset, length int64) (io.ReadCloser, error) {
+	file, err := fs.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.(*os.File).Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek file content: %w", err)
+	}
+
+	return &limitedReadCloser{
+		Reader: io.LimitReader(file, length),
+		Closer: file,
+	}, nil
+}
+
+// Delete removes a file from disk
+func (fs *FileSystemStorageProvider) Delete(key string) error {
+	path, err := fs.pathFor(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("file content not found for key: %s", key)
+	} else if err != nil {
+		return fmt.Errorf("failed to delete file content: %w", err)
+	}
+
+	return nil
+}
+
+// List walks the storage root and reports every stored file
+func (fs *FileSystemStorageProvider) List(fn func(info ContentInfo) error) error {
+	return filepath.WalkDir(fs.root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(fs.root, path)
+		if err != nil {
+			return err
+		}
+
+		// Drop the two shard directories in front of the file name
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) < 3 {
+			return nil
+		}
+		key := parts[len(parts)-1]
+		if namespace := parts[:len(parts)-3]; len(namespace) > 0 {
+			key = strings.Join(namespace, "/") + "/" + key
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		return fn(ContentInfo{
+			Key:     key,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	})
+}
+
+// pathFor maps a key to its sharded location under the storage root
+func (fs *FileSystemStorageProvider) pathFor(key string) (string, error) {
+	segments := strings.Split(key, "/")
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." || strings.Contains(segment, `\`) {
+			return "", fmt.Errorf("invalid key for filesystem storage: %q", key)
+		}
+	}
+
+	name := segments[len(segments)-1]
+	if len(name) < 4 {
+		return "", fmt.Errorf("invalid key for filesystem storage: %q", key)
+	}
+
+	parts := append([]string{fs.root}, segments[:len(segments)-1]...)
+	parts = append(parts, name[0:2], name[2:4], name)
+	return filepath.Join(parts...), nil
+}
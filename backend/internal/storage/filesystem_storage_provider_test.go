@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newTestFileSystemProvider returns a provider rooted in a temporary directory
+func newTestFileSystemProvider(t *testing.T) *FileSystemStorageProvider {
+	t.Helper()
+
+	provider, err := NewFileSystemStorageProvider(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSystemStorageProvider: %v", err)
+	}
+
+	return provider
+}
+
+// storedFiles returns the paths of all files under the provider's root,
+// relative to it
+func storedFiles(t *testing.T, provider *FileSystemStorageProvider) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(provider.root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(provider.root, path)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walk storage root: %v", err)
+	}
+
+	sort.Strings(files)
+	return files
+}
+
+func TestFileSystemStorageProviderPathFor(t *testing.T) {
+	provider := newTestFileSystemProvider(t)
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "abcd1234", want: "ab/cd/abcd1234"},
+		{key: "abcd", want: "ab/cd/abcd"},
+		{key: "quarantine/abcd1234", want: "quarantine/ab/cd/abcd1234"},
+		{key: "renditions/asset/abcd1234", want: "renditions/asset/ab/cd/abcd1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			path, err := provider.pathFor(tt.key)
+			if err != nil {
+				t.Fatalf("pathFor: %v", err)
+			}
+			if want := filepath.Join(provider.root, filepath.FromSlash(tt.want)); path != want {
+				t.Errorf("pathFor(%q) = %q, want %q", tt.key, path, want)
+			}
+		})
+	}
+}
+
+func TestFileSystemStorageProviderRejectsInvalidKeys(t *testing.T) {
+	provider := newTestFileSystemProvider(t)
+
+	keys := []string{
+		"",
+		"abc",
+		"../x",
+		"../abcd1234",
+		"a/../abcd1234",
+		"./abcd1234",
+		"/abcd1234",
+		"quarantine//abcd1234",
+		"abcd1234/",
+		`..\abcd1234`,
+		`a\b/abcd1234`,
+	}
+
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			if path, err := provider.pathFor(key); err == nil {
+				t.Errorf("pathFor(%q) = %q, want an error", key, path)
+			}
+			if _, err := provider.Save(key, bytes.NewReader([]byte("content"))); err == nil {
+				t.Errorf("Save(%q) succeeded", key)
+			}
+			if _, err := provider.Get(key); err == nil {
+				t.Errorf("Get(%q) succeeded", key)
+			}
+			if err := provider.Delete(key); err == nil {
+				t.Errorf("Delete(%q) succeeded", key)
+			}
+		})
+	}
+
+	if files := storedFiles(t, provider); len(files) != 0 {
+		t.Errorf("invalid keys left files %s", files)
+	}
+}
+
+func TestFileSystemStorageProviderSaveAndGet(t *testing.T) {
+	provider := newTestFileSystemProvider(t)
+	content := testContent(4096)
+
+	uri, err := provider.Save("abcd1234", bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if want := "file://" + filepath.ToSlash(filepath.Join(provider.root, "ab", "cd", "abcd1234")); uri != want {
+		t.Errorf("Save returned %q, want %q", uri, want)
+	}
+
+	got, err := provider.Get("abcd1234")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if data := readAll(t, got); !bytes.Equal(data, content) {
+		t.Errorf("Get returned %d bytes that differ from the %d saved", len(data), len(content))
+	}
+
+	ranged, err := provider.GetRange("abcd1234", 1000, 500)
+	if err != nil {
+		t.Fatalf("GetRange: %v", err)
+	}
+	if data := readAll(t, ranged); !bytes.Equal(data, content[1000:1500]) {
+		t.Errorf("GetRange returned %d bytes, want 500 matching bytes", len(data))
+	}
+
+	// The temporary file was renamed into place
+	if got, want := strings.Join(storedFiles(t, provider), ","), "ab/cd/abcd1234"; got != want {
+		t.Errorf("stored files %s, want %s", got, want)
+	}
+}
+
+func TestFileSystemStorageProviderGetMissing(t *testing.T) {
+	provider := newTestFileSystemProvider(t)
+
+	if _, err := provider.Get("missing"); err == nil {
+		t.Fatal("Get of a missing key succeeded")
+	}
+}
+
+// failingReader returns some content and then an error
+type failingReader struct {
+	content []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.content) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.content)
+	r.content = r.content[n:]
+	return n, nil
+}
+
+func TestFileSystemStorageProviderSaveIsAtomic(t *testing.T) {
+	provider := newTestFileSystemProvider(t)
+
+	// A failed write leaves neither the file nor its temporary file behind
+	_, err := provider.Save("abcd1234", &failingReader{content: []byte("partial")})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Save returned %v, want the reader's error", err)
+	}
+	if files := storedFiles(t, provider); len(files) != 0 {
+		t.Errorf("failed Save left files %s", files)
+	}
+
+	// A failed overwrite keeps the content that was there
+	if _, err := provider.Save("abcd1234", bytes.NewReader([]byte("original"))); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := provider.Save("abcd1234", &failingReader{content: []byte("partial")}); err == nil {
+		t.Fatal("Save of a failing reader succeeded")
+	}
+
+	got, err := provider.Get("abcd1234")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if data := readAll(t, got); string(data) != "original" {
+		t.Errorf("Get returned %q after a failed overwrite, want %q", data, "original")
+	}
+	if got, want := strings.Join(storedFiles(t, provider), ","), "ab/cd/abcd1234"; got != want {
+		t.Errorf("stored files %s, want %s", got, want)
+	}
+}
+
+func TestFileSystemStorageProviderDelete(t *testing.T) {
+	provider := newTestFileSystemProvider(t)
+
+	if _, err := provider.Save("abcd1234", bytes.NewReader([]byte("content"))); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := provider.Delete("abcd1234"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := provider.Get("abcd1234"); err == nil {
+		t.Error("Get succeeded after Delete")
+	}
+
+	err := provider.Delete("missing")
+	if err == nil {
+		t.Fatal("Delete of a missing key succeeded")
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Delete of a missing key returned %q, want a not found error", err)
+	}
+}
+
+func TestFileSystemStorageProviderList(t *testing.T) {
+	provider := newTestFileSystemProvider(t)
+
+	saved := map[string]int{
+		"abcd1234":                  10,
+		"abce5678":                  20,
+		"quarantine/abcd1234":       30,
+		"renditions/asset/ffff0000": 40,
+	}
+	for key, size := range saved {
+		if _, err := provider.Save(key, bytes.NewReader(testContent(size))); err != nil {
+			t.Fatalf("Save %s: %v", key, err)
+		}
+	}
+
+	// Temporary files of writes in progress are not content yet
+	stray := filepath.Join(provider.root, "ab", "cd", ".upload-123")
+	if err := os.WriteFile(stray, []byte("partial"), 0o644); err != nil {
+		t.Fatalf("write temporary file: %v", err)
+	}
+
+	var keys []string
+	err := provider.List(func(info ContentInfo) error {
+		keys = append(keys, info.Key)
+		if want, ok := saved[info.Key]; !ok || info.Size != int64(want) {
+			t.Errorf("List reported %s with size %d", info.Key, info.Size)
+		}
+		if info.ModTime.IsZero() {
+			t.Errorf("List reported %s without a modification time", info.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	sort.Strings(keys)
+	if got, want := strings.Join(keys, ","), "abcd1234,abce5678,quarantine/abcd1234,renditions/asset/ffff0000"; got != want {
+		t.Errorf("List reported keys %s, want %s", got, want)
+	}
+}
+
+func TestFileSystemStorageProviderListStopsOnError(t *testing.T) {
+	provider := newTestFileSystemProvider(t)
+
+	for _, key := range []string{"abcd0001", "abcd0002"} {
+		if _, err := provider.Save(key, bytes.NewReader([]byte(key))); err != nil {
+			t.Fatalf("Save %s: %v", key, err)
+		}
+	}
+
+	stop := io.ErrUnexpectedEOF
+	calls := 0
+	err := provider.List(func(info ContentInfo) error {
+		calls++
+		return stop
+	})
+	if err != stop {
+		t.Errorf("List returned %v, want the callback's error", err)
+	}
+	if calls != 1 {
+		t.Errorf("List called back %d times after an error, want 1", calls)
+	}
+}
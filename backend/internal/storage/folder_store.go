@@ -8,12 +8,35 @@ import (
 type FolderStore interface {
 	Save(folder *models.Folder) error
 
+	// SaveOrGet stores a folder unless its parent already has a folder of
+	// the same name, and returns whichever folder is there
+	SaveOrGet(folder *models.Folder) (*models.Folder, error)
+
 	GetByID(id string) (*models.Folder, error)
 
 	GetAll() ([]*models.Folder, error)
 
 	GetByParentID(parentID *string) ([]*models.Folder, error)
 
+	// GetByPath retrieves the folders along a path of names from the root,
+	// stopping before the first name that doesn't match
+	GetByPath(names []string) ([]*models.Folder, error)
+
+	// GetAncestors retrieves every folder above a folder, root first
+	GetAncestors(id string) ([]*models.Folder, error)
+
+	// GetDescendants retrieves every folder below a folder, shallowest first
+	GetDescendants(id string) ([]*models.Folder, error)
+
+	// GetTree retrieves a folder with its subfolders nested inside it, or
+	// every root folder when rootID is nil, down to maxDepth levels below
+	// (0 for no limit). A missing folder gives an empty result.
+	GetTree(rootID *string, maxDepth int) ([]*models.FolderTreeNode, error)
+
+	// GetStats aggregates sizes and counts for a folder, or for the whole
+	// library when id is nil
+	GetStats(id *string) (*models.FolderStats, error)
+
 	Update(folder *models.Folder) error
 
 	Delete(id string) error
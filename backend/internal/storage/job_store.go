@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// JobStore is an interface for the durable queue of background jobs
+type JobStore interface {
+	// Enqueue stores a new job
+	Enqueue(job *models.Job) error
+
+	// GetByID retrieves a job by its ID
+	GetByID(id string) (*models.Job, error)
+
+	// GetByAssetID retrieves all jobs for an asset, oldest first
+	GetByAssetID(assetID string) ([]*models.Job, error)
+
+	// Claim takes the next job that is due and holds it for lease. Running
+	// jobs whose lease ran out are claimed again, or dead lettered if that
+	// was their last attempt. Claim returns nil when no job is due.
+	Claim(lease time.Duration) (*models.Job, error)
+
+	// Complete marks a job as succeeded. Like Retry and Fail it takes the
+	// lease the job was claimed with, and returns ErrJobLeaseLost if the
+	// job has since been claimed again or dead lettered.
+	Complete(id string, lease time.Time) error
+
+	// Retry queues a failed job to run again at runAt
+	Retry(id string, lease time.Time, runAt time.Time, lastError string) error
+
+	// Fail moves a job to the dead letter state
+	Fail(id string, lease time.Time, lastError string) error
+
+	// DeleteSucceededBefore removes jobs that succeeded before the given
+	// time and returns how many were removed
+	DeleteSucceededBefore(before time.Time) (int64, error)
+}
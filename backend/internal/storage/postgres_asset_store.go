@@ -2,16 +2,20 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/lib/pq"
 )
 
 // PostgresAssetStore implements AssetStore with PostgreSQL storage
 type PostgresAssetStore struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewPostgresAssetStore creates a new PostgresAssetStore
@@ -35,11 +39,114 @@ func NewPostgresAssetStore(db *sql.DB) (*PostgresAssetStore, error) {
 		return nil, fmt.Errorf("failed to create assets table: %w", err)
 	}
 
+	_, err = db.Exec(`
+		ALTER TABLE assets ADD COLUMN IF NOT EXISTS digest VARCHAR(64);
+		CREATE INDEX IF NOT EXISTS idx_assets_digest ON assets (digest);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add digest column: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_assets_metadata ON assets USING GIN (metadata jsonb_path_ops)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metadata index: %w", err)
+	}
+
+	// Deleted assets stay in the table, hidden, until the trash is purged
+	_, err = db.Exec(`
+		ALTER TABLE assets ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+		ALTER TABLE assets ADD COLUMN IF NOT EXISTS trash_id VARCHAR(36);
+		CREATE INDEX IF NOT EXISTS idx_assets_trash_id ON assets (trash_id);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add trash columns: %w", err)
+	}
+
+	// Keyset pagination walks these in either direction
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_assets_folder_id ON assets (folder_id);
+		CREATE INDEX IF NOT EXISTS idx_assets_name_id ON assets (name, id);
+		CREATE INDEX IF NOT EXISTS idx_assets_size_id ON assets (size, id);
+		CREATE INDEX IF NOT EXISTS idx_assets_created_at_id ON assets (created_at, id);
+		CREATE INDEX IF NOT EXISTS idx_assets_updated_at_id ON assets (updated_at, id);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create listing indexes: %w", err)
+	}
+
+	// Assets are addressed by path like folders, so names must be unique
+	// among the assets of a folder outside the trash. Duplicates from before
+	// get the first numbered suffix that is still free, ahead of their
+	// extension, cutting the name short where it would not fit in the column.
+	_, err = db.Exec(`
+		DO $$
+		DECLARE
+			dup RECORD;
+			n INTEGER;
+			ext TEXT;
+			suffix TEXT;
+			candidate TEXT;
+		BEGIN
+			IF NOT EXISTS (
+				SELECT FROM pg_indexes WHERE indexname = 'idx_assets_folder_name'
+			) THEN
+				FOR dup IN
+					SELECT id, folder_id, name
+					FROM (
+						SELECT id, folder_id, name, row_number() OVER (
+							PARTITION BY folder_id, name ORDER BY created_at, id
+						) AS nth
+						FROM assets
+						WHERE deleted_at IS NULL
+					) d
+					WHERE nth > 1
+					ORDER BY folder_id, name, nth
+				LOOP
+					ext := coalesce(substring(dup.name from '\.[^./]{1,16}$'), '');
+					IF length(ext) = length(dup.name) THEN
+						ext := '';
+					END IF;
+
+					n := 2;
+					LOOP
+						suffix := ' (' || n || ')';
+						candidate := left(
+							left(dup.name, length(dup.name) - length(ext)),
+							255 - length(suffix) - length(ext)
+						) || suffix || ext;
+						EXIT WHEN NOT EXISTS (
+							SELECT FROM assets
+							WHERE folder_id IS NOT DISTINCT FROM dup.folder_id
+								AND name = candidate AND deleted_at IS NULL
+						);
+						n := n + 1;
+					END LOOP;
+
+					UPDATE assets SET name = candidate WHERE id = dup.id;
+				END LOOP;
+
+				CREATE UNIQUE INDEX idx_assets_folder_name
+					ON assets (COALESCE(folder_id, ''), name)
+					WHERE deleted_at IS NULL;
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add unique asset name index: %w", err)
+	}
+
 	return &PostgresAssetStore{
 		db: db,
 	}, nil
 }
 
+// WithTx returns a copy of the store that runs its queries inside tx
+func (s *PostgresAssetStore) WithTx(tx *sql.Tx) *PostgresAssetStore {
+	return &PostgresAssetStore{
+		db: tx,
+	}
+}
+
 // Save stores asset metadata in PostgreSQL
 func (s *PostgresAssetStore) Save(asset *models.Asset) error {
 	// Convert metadata to JSON
@@ -51,8 +158,8 @@ func (s *PostgresAssetStore) Save(asset *models.Asset) error {
 	// Insert asset into database
 	_, err = s.db.Exec(
 		`INSERT INTO assets 
-		(id, name, type, size, content_type, path, folder_id, created_at, updated_at, metadata)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		(id, name, type, size, content_type, path, folder_id, created_at, updated_at, metadata, digest)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''))`,
 		asset.ID,
 		asset.Name,
 		asset.Type,
@@ -63,7 +170,11 @@ func (s *PostgresAssetStore) Save(asset *models.Asset) error {
 		asset.CreatedAt,
 		asset.UpdatedAt,
 		metadataJSON,
+		asset.Digest,
 	)
+	if isUniqueViolation(err) {
+		return models.ErrPathExists
+	}
 	if err != nil {
 		return fmt.Errorf("failed to insert asset: %w", err)
 	}
@@ -73,27 +184,12 @@ func (s *PostgresAssetStore) Save(asset *models.Asset) error {
 
 // GetByID retrieves an asset by its ID
 func (s *PostgresAssetStore) GetByID(id string) (*models.Asset, error) {
-	var asset models.Asset
-	var metadataJSON []byte
-
-	err := s.db.QueryRow(
-		`SELECT 
-			id, name, type, size, content_type, path, folder_id, created_at, updated_at, metadata
+	asset, err := scanAsset(s.db.QueryRow(
+		`SELECT `+assetColumns+`
 		FROM assets 
-		WHERE id = $1`,
+		WHERE id = $1 AND deleted_at IS NULL`,
 		id,
-	).Scan(
-		&asset.ID,
-		&asset.Name,
-		&asset.Type,
-		&asset.Size,
-		&asset.ContentType,
-		&asset.Path,
-		&asset.FolderID,
-		&asset.CreatedAt,
-		&asset.UpdatedAt,
-		&metadataJSON,
-	)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, models.ErrAssetNotFound
@@ -101,70 +197,86 @@ func (s *PostgresAssetStore) GetByID(id string) (*models.Asset, error) {
 		return nil, fmt.Errorf("failed to get asset: %w", err)
 	}
 
-	// Unmarshal metadata
-	if metadataJSON != nil {
-		if err := json.Unmarshal(metadataJSON, &asset.Metadata); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-		}
-	} else {
-		asset.Metadata = make(map[string]interface{})
-	}
-
-	return &asset, nil
+	return asset, nil
 }
 
-// GetAll retrieves all assets
+// GetAll retrieves all assets, including those in the trash, as they
+// still hold their content
 func (s *PostgresAssetStore) GetAll() ([]*models.Asset, error) {
-	rows, err := s.db.Query(
-		`SELECT 
-			id, name, type, size, content_type, path, folder_id, created_at, updated_at, metadata
+	return queryAssets(s.db,
+		`SELECT `+assetColumns+`
 		FROM assets
 		ORDER BY created_at DESC`,
 	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query assets: %w", err)
+}
+
+// GetByDigest retrieves all assets whose content has the given digest
+func (s *PostgresAssetStore) GetByDigest(digest string) ([]*models.Asset, error) {
+	return queryAssets(s.db,
+		`SELECT `+assetColumns+`
+		FROM assets
+		WHERE digest = $1 AND deleted_at IS NULL
+		ORDER BY created_at ASC`,
+		digest,
+	)
+}
+
+// List retrieves a page of assets matching a filter in the requested
+// order. Pages are found by keyset: the cursor holds the sort value and ID
+// of the last asset on the previous page, so deep pages stay as cheap as
+// the first and concurrent inserts don't shift them.
+func (s *PostgresAssetStore) List(query *models.AssetListQuery) (*models.AssetPage, error) {
+	column, ok := assetSortColumns[query.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", query.Sort)
+	}
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
 	}
-	defer rows.Close()
 
-	var assets []*models.Asset
+	conditions, args := assetFilterConditions(&query.AssetFilter)
 
-	for rows.Next() {
-		var asset models.Asset
-		var metadataJSON []byte
-
-		err := rows.Scan(
-			&asset.ID,
-			&asset.Name,
-			&asset.Type,
-			&asset.Size,
-			&asset.ContentType,
-			&asset.Path,
-			&asset.FolderID,
-			&asset.CreatedAt,
-			&asset.UpdatedAt,
-			&metadataJSON,
-		)
+	if query.Cursor != "" {
+		value, id, err := decodeAssetCursor(query.Cursor, query.Sort, query.Descending)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan asset row: %w", err)
+			return nil, err
 		}
+		args = append(args, value, id)
+		conditions = append(conditions, fmt.Sprintf(`(%s, id) %s ($%d, $%d)`, column, comparison, len(args)-1, len(args)))
+	}
 
-		// Unmarshal metadata
-		if metadataJSON != nil {
-			if err := json.Unmarshal(metadataJSON, &asset.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
-		} else {
-			asset.Metadata = make(map[string]interface{})
-		}
+	sqlQuery := `SELECT ` + assetColumns + `
+		FROM assets
+		WHERE ` + strings.Join(conditions, " AND ")
+	sqlQuery += fmt.Sprintf(`
+		ORDER BY %[1]s %[2]s, id %[2]s`, column, direction)
+	if query.Limit > 0 {
+		// One more than asked for tells whether another page follows
+		args = append(args, query.Limit+1)
+		sqlQuery += fmt.Sprintf(`
+		LIMIT $%d`, len(args))
+	}
 
-		assets = append(assets, &asset)
+	assets, err := queryAssets(s.db, sqlQuery, args...)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating asset rows: %w", err)
+	page := &models.AssetPage{Assets: assets}
+	if page.Assets == nil {
+		page.Assets = []*models.Asset{}
+	}
+	if query.Limit > 0 && len(assets) > query.Limit {
+		page.Assets = assets[:query.Limit]
+		cursor, err := encodeAssetCursor(page.Assets[query.Limit-1], query.Sort, query.Descending)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = &cursor
 	}
 
-	return assets, nil
+	return page, nil
 }
 
 // Delete removes an asset from the store
@@ -201,8 +313,8 @@ func (s *PostgresAssetStore) Update(asset *models.Asset) error {
 	result, err := s.db.Exec(
 		`UPDATE assets 
 		SET name = $2, type = $3, size = $4, content_type = $5, path = $6, 
-		    folder_id = $7, updated_at = $8, metadata = $9
-		WHERE id = $1`,
+		    folder_id = $7, updated_at = $8, metadata = $9, digest = NULLIF($10, '')
+		WHERE id = $1 AND deleted_at IS NULL`,
 		asset.ID,
 		asset.Name,
 		asset.Type,
@@ -212,7 +324,11 @@ func (s *PostgresAssetStore) Update(asset *models.Asset) error {
 		asset.FolderID,
 		asset.UpdatedAt,
 		metadataJSON,
+		asset.Digest,
 	)
+	if isUniqueViolation(err) {
+		return models.ErrPathExists
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update asset: %w", err)
 	}
@@ -229,82 +345,115 @@ func (s *PostgresAssetStore) Update(asset *models.Asset) error {
 	return nil
 }
 
-// GetByFolderID retrieves all assets in a specific folder
-func (s *PostgresAssetStore) GetByFolderID(folderID *string) ([]*models.Asset, error) {
-	var query string
-	var args []interface{}
+// Rename changes the name of an asset and its updated time
+func (s *PostgresAssetStore) Rename(id string, name string) error {
+	result, err := s.db.Exec(
+		`UPDATE assets SET name = $2, updated_at = $3 WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+		name,
+		time.Now(),
+	)
+	if isUniqueViolation(err) {
+		return models.ErrPathExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rename asset: %w", err)
+	}
 
-	if folderID == nil {
-		// Get root assets (where folder_id is NULL)
-		query = `
-			SELECT 
-				id, name, type, size, content_type, path, folder_id, created_at, updated_at, metadata
-			FROM assets
-			WHERE folder_id IS NULL
-			ORDER BY created_at DESC
-		`
-	} else {
-		// Get assets in the specified folder
-		query = `
-			SELECT 
-				id, name, type, size, content_type, path, folder_id, created_at, updated_at, metadata
-			FROM assets
-			WHERE folder_id = $1
-			ORDER BY created_at DESC
-		`
-		args = append(args, *folderID)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrAssetNotFound
 	}
 
-	rows, err := s.db.Query(query, args...)
+	return nil
+}
+
+// UpdateSize sets the recorded content size of an asset. Unlike Update it
+// also reaches assets in the trash, which still hold their content.
+func (s *PostgresAssetStore) UpdateSize(id string, size int64) error {
+	result, err := s.db.Exec(`UPDATE assets SET size = $2 WHERE id = $1`, id, size)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query assets: %w", err)
+		return fmt.Errorf("failed to update asset size: %w", err)
 	}
-	defer rows.Close()
 
-	var assets []*models.Asset
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
 
-	for rows.Next() {
-		var asset models.Asset
-		var metadataJSON []byte
-		var folderIDValue sql.NullString
-
-		err := rows.Scan(
-			&asset.ID,
-			&asset.Name,
-			&asset.Type,
-			&asset.Size,
-			&asset.ContentType,
-			&asset.Path,
-			&folderIDValue,
-			&asset.CreatedAt,
-			&asset.UpdatedAt,
-			&metadataJSON,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan asset row: %w", err)
-		}
+	if rowsAffected == 0 {
+		return models.ErrAssetNotFound
+	}
 
-		if folderIDValue.Valid {
-			asset.FolderID = &folderIDValue.String
-		}
+	return nil
+}
 
-		// Unmarshal metadata
-		if metadataJSON != nil {
-			if err := json.Unmarshal(metadataJSON, &asset.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
-		} else {
-			asset.Metadata = make(map[string]interface{})
-		}
+// UpdateMetadata merges the given keys into an asset's metadata, leaving
+// other keys and columns untouched so concurrent edits aren't lost
+func (s *PostgresAssetStore) UpdateMetadata(id string, metadata map[string]interface{}) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
 
-		assets = append(assets, &asset)
+	result, err := s.db.Exec(
+		`UPDATE assets
+		SET metadata = COALESCE(metadata, '{}'::jsonb) || $2::jsonb
+		WHERE id = $1`,
+		id,
+		metadataJSON,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update asset metadata: %w", err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating asset rows: %w", err)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	return assets, nil
+	if rowsAffected == 0 {
+		return models.ErrAssetNotFound
+	}
+
+	return nil
+}
+
+// GetByName retrieves the assets with a name in a folder, oldest first
+func (s *PostgresAssetStore) GetByName(folderID *string, name string) ([]*models.Asset, error) {
+	return queryAssets(s.db,
+		`SELECT `+assetColumns+`
+		FROM assets
+		WHERE folder_id IS NOT DISTINCT FROM $1 AND name = $2 AND deleted_at IS NULL
+		ORDER BY created_at, id`,
+		folderID, name,
+	)
+}
+
+// GetByFolderID retrieves all assets in a specific folder
+func (s *PostgresAssetStore) GetByFolderID(folderID *string) ([]*models.Asset, error) {
+	if folderID == nil {
+		// Get root assets (where folder_id is NULL)
+		return queryAssets(s.db,
+			`SELECT `+assetColumns+`
+			FROM assets
+			WHERE folder_id IS NULL AND deleted_at IS NULL
+			ORDER BY created_at DESC`,
+		)
+	}
+
+	// Get assets in the specified folder
+	return queryAssets(s.db,
+		`SELECT `+assetColumns+`
+		FROM assets
+		WHERE folder_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC`,
+		*folderID,
+	)
 }
 
 // MoveAsset moves an asset to a different folder
@@ -312,11 +461,14 @@ func (s *PostgresAssetStore) MoveAsset(assetID string, folderID *string) error {
 	result, err := s.db.Exec(
 		`UPDATE assets 
 		SET folder_id = $2, updated_at = $3
-		WHERE id = $1`,
+		WHERE id = $1 AND deleted_at IS NULL`,
 		assetID,
 		folderID,
 		time.Now(),
 	)
+	if isUniqueViolation(err) {
+		return models.ErrPathExists
+	}
 	if err != nil {
 		return fmt.Errorf("failed to move asset: %w", err)
 	}
@@ -332,3 +484,258 @@ func (s *PostgresAssetStore) MoveAsset(assetID string, folderID *string) error {
 
 	return nil
 }
+
+// GetCollections retrieves the collections an asset belongs to, by name
+func (s *PostgresAssetStore) GetCollections(assetID string) ([]*models.Collection, error) {
+	return queryCollections(s.db,
+		`SELECT `+collectionColumns+`
+		FROM collections c
+		JOIN collection_items i ON i.collection_id = c.id
+		WHERE i.asset_id = $1
+		ORDER BY c.name, c.id`,
+		assetID,
+	)
+}
+
+// assetColumns is the column list selected by every asset query, in scanAsset
+// order. The tags are looked up by assets.id, so queries must select from
+// the assets table or a subquery of that name.
+const assetColumns = `id, name, type, size, content_type, path, folder_id, created_at, updated_at, metadata, COALESCE(digest, ''),
+	ARRAY(
+		SELECT t.name FROM asset_tags at JOIN tags t ON t.id = at.tag_id
+		WHERE at.asset_id = assets.id ORDER BY t.name
+	)`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAsset reads one asset selected with assetColumns
+func scanAsset(row rowScanner) (*models.Asset, error) {
+	var asset models.Asset
+	var metadataJSON []byte
+
+	err := row.Scan(
+		&asset.ID,
+		&asset.Name,
+		&asset.Type,
+		&asset.Size,
+		&asset.ContentType,
+		&asset.Path,
+		&asset.FolderID,
+		&asset.CreatedAt,
+		&asset.UpdatedAt,
+		&metadataJSON,
+		&asset.Digest,
+		pq.Array(&asset.Tags),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal metadata
+	if metadataJSON != nil {
+		if err := json.Unmarshal(metadataJSON, &asset.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	} else {
+		asset.Metadata = make(map[string]interface{})
+	}
+
+	return &asset, nil
+}
+
+// queryAssets runs a query selecting assetColumns and collects the results
+func queryAssets(db DBTX, query string, args ...interface{}) ([]*models.Asset, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assets: %w", err)
+	}
+	defer rows.Close()
+
+	var assets []*models.Asset
+
+	for rows.Next() {
+		asset, err := scanAsset(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan asset row: %w", err)
+		}
+
+		assets = append(assets, asset)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating asset rows: %w", err)
+	}
+
+	return assets, nil
+}
+
+// assetSortColumns maps sort fields to the columns they order by
+var assetSortColumns = map[models.AssetSort]string{
+	models.AssetSortName:      "name",
+	models.AssetSortSize:      "size",
+	models.AssetSortCreatedAt: "created_at",
+	models.AssetSortUpdatedAt: "updated_at",
+}
+
+// assetFilterConditions turns a filter into SQL conditions and their
+// arguments. Trashed assets never match. Metadata string values and lists
+// of strings are matched by containment so the GIN index applies; other
+// scalars such as numbers are compared as text. Tag names must already be
+// normalized and distinct.
+func assetFilterConditions(filter *models.AssetFilter) ([]string, []interface{}) {
+	conditions := []string{`deleted_at IS NULL`}
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Type != "" {
+		add(`type = $%d`, filter.Type)
+	}
+	if filter.RootFolder {
+		conditions = append(conditions, `folder_id IS NULL`)
+	} else if filter.FolderID != nil {
+		add(`folder_id = $%d`, *filter.FolderID)
+	}
+	if family, ok := strings.CutSuffix(filter.ContentType, "/*"); ok {
+		add(`content_type ILIKE $%d ESCAPE '\'`, escapeLike(family)+"/%")
+	} else if filter.ContentType != "" {
+		add(`content_type = $%d`, filter.ContentType)
+	}
+	if filter.MinSize != nil {
+		add(`size >= $%d`, *filter.MinSize)
+	}
+	if filter.MaxSize != nil {
+		add(`size <= $%d`, *filter.MaxSize)
+	}
+	if filter.CreatedAfter != nil {
+		add(`created_at >= $%d`, *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		add(`created_at < $%d`, *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		add(`updated_at >= $%d`, *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		add(`updated_at < $%d`, *filter.UpdatedBefore)
+	}
+	if filter.NamePrefix != "" {
+		add(`name ILIKE $%d ESCAPE '\'`, escapeLike(filter.NamePrefix)+"%")
+	}
+
+	if len(filter.AnyTags) > 0 {
+		add(`id IN (
+			SELECT at.asset_id FROM asset_tags at JOIN tags t ON t.id = at.tag_id
+			WHERE t.name = ANY($%d))`, pq.Array(filter.AnyTags))
+	}
+	if len(filter.AllTags) > 0 {
+		// Names are distinct, so matching all of them means matching as many
+		args = append(args, pq.Array(filter.AllTags), len(filter.AllTags))
+		conditions = append(conditions, fmt.Sprintf(`id IN (
+			SELECT at.asset_id FROM asset_tags at JOIN tags t ON t.id = at.tag_id
+			WHERE t.name = ANY($%d)
+			GROUP BY at.asset_id HAVING count(*) = $%d)`, len(args)-1, len(args)))
+	}
+
+	keys := make([]string, 0, len(filter.Metadata))
+	for key := range filter.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		k, v := len(args)+1, len(args)+2
+		conditions = append(conditions, fmt.Sprintf(
+			`(metadata @> jsonb_build_object($%[1]d::text, $%[2]d::text)
+			OR metadata @> jsonb_build_object($%[1]d::text, jsonb_build_array($%[2]d::text))
+			OR metadata->>$%[1]d::text = $%[2]d)`,
+			k, v,
+		))
+		args = append(args, key, filter.Metadata[key])
+	}
+
+	return conditions, args
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// assetCursor is the position after the last asset of a page. The sort
+// is recorded so a cursor can't be replayed against a different order.
+type assetCursor struct {
+	Sort       models.AssetSort `json:"s"`
+	Descending bool             `json:"d,omitempty"`
+	Value      json.RawMessage  `json:"v"`
+	ID         string           `json:"id"`
+}
+
+// encodeAssetCursor returns an opaque cursor pointing after asset
+func encodeAssetCursor(asset *models.Asset, sortBy models.AssetSort, descending bool) (string, error) {
+	var value interface{}
+	switch sortBy {
+	case models.AssetSortName:
+		value = asset.Name
+	case models.AssetSortSize:
+		value = asset.Size
+	case models.AssetSortCreatedAt:
+		value = asset.CreatedAt
+	case models.AssetSortUpdatedAt:
+		value = asset.UpdatedAt
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	data, err := json.Marshal(assetCursor{Sort: sortBy, Descending: descending, Value: raw, ID: asset.ID})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeAssetCursor reads the sort value and ID from a cursor made for the
+// same order
+func decodeAssetCursor(cursor string, sortBy models.AssetSort, descending bool) (interface{}, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, "", models.ErrInvalidCursor
+	}
+
+	var c assetCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, "", models.ErrInvalidCursor
+	}
+	if c.Sort != sortBy || c.Descending != descending {
+		return nil, "", models.ErrInvalidCursor
+	}
+
+	var value interface{}
+	switch sortBy {
+	case models.AssetSortName:
+		var name string
+		err = json.Unmarshal(c.Value, &name)
+		value = name
+	case models.AssetSortSize:
+		var size int64
+		err = json.Unmarshal(c.Value, &size)
+		value = size
+	default:
+		var t time.Time
+		err = json.Unmarshal(c.Value, &t)
+		value = t
+	}
+	if err != nil {
+		return nil, "", models.ErrInvalidCursor
+	}
+
+	return value, c.ID, nil
+}
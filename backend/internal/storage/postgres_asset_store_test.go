@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// testDatabaseURLEnv names the variable holding a PostgreSQL connection
+// string for tests that need a real database. They are skipped without it.
+const testDatabaseURLEnv = "MEDIAHUB_TEST_DATABASE_URL"
+
+// newTestDB connects to the test database and confines the test to a
+// schema of its own, dropped again afterwards
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// The search path is per connection, so keep to one
+	db.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := db.Exec(`CREATE SCHEMA ` + schema + `; SET search_path TO ` + schema); err != nil {
+		db.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		db.Close()
+	})
+
+	return db
+}
+
+func TestAssetCursorRoundTrip(t *testing.T) {
+	asset := &models.Asset{
+		ID:        "0b8f0e8e-6d5c-4c3f-9b1a-2f6e4d3c2b1a",
+		Name:      "Ünïcode, \"quoted\" name",
+		Size:      1 << 40,
+		CreatedAt: time.Date(2024, 3, 4, 5, 6, 7, 123456000, time.FixedZone("", -7*3600)),
+		UpdatedAt: time.Date(2025, 1, 2, 3, 4, 5, 999999000, time.UTC),
+	}
+
+	tests := []struct {
+		sort models.AssetSort
+		want interface{}
+	}{
+		{sort: models.AssetSortName, want: asset.Name},
+		{sort: models.AssetSortSize, want: asset.Size},
+		{sort: models.AssetSortCreatedAt, want: asset.CreatedAt},
+		{sort: models.AssetSortUpdatedAt, want: asset.UpdatedAt},
+	}
+
+	for _, tt := range tests {
+		for _, descending := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s descending %v", tt.sort, descending), func(t *testing.T) {
+				cursor, err := encodeAssetCursor(asset, tt.sort, descending)
+				if err != nil {
+					t.Fatalf("encodeAssetCursor: %v", err)
+				}
+
+				value, id, err := decodeAssetCursor(cursor, tt.sort, descending)
+				if err != nil {
+					t.Fatalf("decodeAssetCursor: %v", err)
+				}
+				if id != asset.ID {
+					t.Errorf("ID = %q, want %q", id, asset.ID)
+				}
+
+				// Times must keep their full precision to resume exactly
+				if want, ok := tt.want.(time.Time); ok {
+					if got, ok := value.(time.Time); !ok || !got.Equal(want) {
+						t.Errorf("value = %v, want %v", value, want)
+					}
+				} else if value != tt.want {
+					t.Errorf("value = %#v, want %#v", value, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestAssetCursorRejected(t *testing.T) {
+	asset := &models.Asset{ID: "id-1", Name: "name", Size: 10, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	cursor, err := encodeAssetCursor(asset, models.AssetSortName, false)
+	if err != nil {
+		t.Fatalf("encodeAssetCursor: %v", err)
+	}
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name       string
+		cursor     string
+		sort       models.AssetSort
+		descending bool
+	}{
+		{name: "different sort", cursor: cursor, sort: models.AssetSortSize},
+		{name: "different order", cursor: cursor, sort: models.AssetSortName, descending: true},
+		{name: "not base64", cursor: "not a cursor!", sort: models.AssetSortName},
+		{name: "not json", cursor: encode("not json"), sort: models.AssetSortName},
+		{name: "no id", cursor: encode(`{"s":"name","v":"name"}`), sort: models.AssetSortName},
+		{name: "size that is not a number", cursor: encode(`{"s":"size","v":"big","id":"id-1"}`), sort: models.AssetSortSize},
+		{name: "time that is not a time", cursor: encode(`{"s":"createdAt","v":"yesterday","id":"id-1"}`), sort: models.AssetSortCreatedAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeAssetCursor(tt.cursor, tt.sort, tt.descending); !errors.Is(err, models.ErrInvalidCursor) {
+				t.Errorf("decodeAssetCursor returned %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestPostgresAssetStoreListPagesThroughTies(t *testing.T) {
+	store, err := NewPostgresAssetStore(newTestDB(t))
+	if err != nil {
+		t.Fatalf("NewPostgresAssetStore: %v", err)
+	}
+
+	// Every sort key is shared by several assets, so only the ID tells
+	// them apart at page boundaries
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		asset := &models.Asset{
+			ID:          fmt.Sprintf("asset-%02d", (i*7)%10),
+			Name:        []string{"alpha", "beta"}[i%2],
+			Type:        models.AssetTypePDF,
+			Size:        int64(i % 3),
+			ContentType: "application/pdf",
+			Path:        "/dev/null",
+			CreatedAt:   created,
+			UpdatedAt:   created.Add(time.Duration(i%2) * time.Hour),
+			Metadata:    map[string]interface{}{},
+		}
+		if err := store.Save(asset); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	sorts := []models.AssetSort{models.AssetSortName, models.AssetSortSize, models.AssetSortCreatedAt, models.AssetSortUpdatedAt}
+	for _, sortBy := range sorts {
+		for _, descending := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s descending %v", sortBy, descending), func(t *testing.T) {
+				all, err := store.List(&models.AssetListQuery{Sort: sortBy, Descending: descending})
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if len(all.Assets) != 10 || all.NextCursor != nil {
+					t.Fatalf("unpaged List returned %d assets and cursor %v", len(all.Assets), all.NextCursor)
+				}
+
+				var paged []*models.Asset
+				query := &models.AssetListQuery{Sort: sortBy, Descending: descending, Limit: 3}
+				for pages := 0; ; pages++ {
+					if pages > 10 {
+						t.Fatal("paging did not end")
+					}
+					page, err := store.List(query)
+					if err != nil {
+						t.Fatalf("List page %d: %v", pages+1, err)
+					}
+					paged = append(paged, page.Assets...)
+					if page.NextCursor == nil {
+						break
+					}
+					query.Cursor = *page.NextCursor
+				}
+
+				// Pages must add up to the unpaged order exactly
+				if len(paged) != len(all.Assets) {
+					t.Fatalf("pages held %d assets, want %d", len(paged), len(all.Assets))
+				}
+				for i := range paged {
+					if paged[i].ID != all.Assets[i].ID {
+						t.Fatalf("asset %d of the pages is %s, want %s", i, paged[i].ID, all.Assets[i].ID)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PostgresBlobStore implements BlobStore with PostgreSQL storage.
+// Every operation on a digest first takes a transaction-scoped advisory lock
+// on it, so creating and removing the same blob never interleave.
+type PostgresBlobStore struct {
+	db DBTX
+}
+
+// NewPostgresBlobStore creates a new PostgresBlobStore
+func NewPostgresBlobStore(db *sql.DB) (*PostgresBlobStore, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS content_blobs (
+			digest VARCHAR(64) PRIMARY KEY,
+			size BIGINT NOT NULL,
+			ref_count BIGINT NOT NULL,
+			path TEXT NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create content_blobs table: %w", err)
+	}
+
+	return &PostgresBlobStore{
+		db: db,
+	}, nil
+}
+
+// WithTx returns a copy of the store that runs its queries inside tx
+func (s *PostgresBlobStore) WithTx(tx *sql.Tx) *PostgresBlobStore {
+	return &PostgresBlobStore{
+		db: tx,
+	}
+}
+
+// Acquire adds a reference to a blob, inserting it on first use
+func (s *PostgresBlobStore) Acquire(digest string, size int64) (*Blob, bool, error) {
+	if err := lockDigest(s.db, digest); err != nil {
+		return nil, false, err
+	}
+
+	var blob Blob
+	var created bool
+
+	// xmax is only zero for a freshly inserted row
+	err := s.db.QueryRow(
+		`INSERT INTO content_blobs (digest, size, ref_count, path, created_at)
+		VALUES ($1, $2, 1, '', $3)
+		ON CONFLICT (digest) DO UPDATE SET ref_count = content_blobs.ref_count + 1
+		RETURNING digest, size, ref_count, path, created_at, (xmax = 0)`,
+		digest, size, time.Now(),
+	).Scan(
+		&blob.Digest,
+		&blob.Size,
+		&blob.RefCount,
+		&blob.Path,
+		&blob.CreatedAt,
+		&created,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire blob: %w", err)
+	}
+
+	return &blob, created, nil
+}
+
+// SetPath records where a blob's content was saved
+func (s *PostgresBlobStore) SetPath(digest string, path string) error {
+	_, err := s.db.Exec(
+		`UPDATE content_blobs SET path = $2 WHERE digest = $1`,
+		digest, path,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update blob path: %w", err)
+	}
+
+	return nil
+}
+
+// Release drops a reference to a blob and deletes its record at zero
+func (s *PostgresBlobStore) Release(digest string) (int64, error) {
+	if err := lockDigest(s.db, digest); err != nil {
+		return 0, err
+	}
+
+	var remaining int64
+	err := s.db.QueryRow(
+		`UPDATE content_blobs SET ref_count = ref_count - 1
+		WHERE digest = $1
+		RETURNING ref_count`,
+		digest,
+	).Scan(&remaining)
+
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("blob not found: %s", digest)
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to release blob: %w", err)
+	}
+
+	if remaining <= 0 {
+		if _, err := s.db.Exec(`DELETE FROM content_blobs WHERE digest = $1`, digest); err != nil {
+			return 0, fmt.Errorf("failed to delete blob: %w", err)
+		}
+		remaining = 0
+	}
+
+	return remaining, nil
+}
+
+// GetAll retrieves all blobs
+func (s *PostgresBlobStore) GetAll() ([]*Blob, error) {
+	rows, err := s.db.Query(
+		`SELECT digest, size, ref_count, path, created_at
+		FROM content_blobs
+		ORDER BY digest`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blobs: %w", err)
+	}
+	defer rows.Close()
+
+	var blobs []*Blob
+
+	for rows.Next() {
+		var blob Blob
+		err := rows.Scan(
+			&blob.Digest,
+			&blob.Size,
+			&blob.RefCount,
+			&blob.Path,
+			&blob.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blob row: %w", err)
+		}
+
+		blobs = append(blobs, &blob)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blob rows: %w", err)
+	}
+
+	return blobs, nil
+}
+
+// Recount sets a blob's reference count from the assets with its digest,
+// trashed ones included. Under the digest lock no upload or purge of the
+// digest is in flight, so the count is exact.
+func (s *PostgresBlobStore) Recount(digest string) (int64, int64, error) {
+	if err := lockDigest(s.db, digest); err != nil {
+		return 0, 0, err
+	}
+
+	var previous, current int64
+	err := s.db.QueryRow(
+		`WITH old AS (
+			SELECT ref_count FROM content_blobs WHERE digest = $1
+		)
+		UPDATE content_blobs
+		SET ref_count = (SELECT count(*) FROM assets WHERE digest = $1)
+		WHERE digest = $1
+		RETURNING (SELECT ref_count FROM old), ref_count`,
+		digest,
+	).Scan(&previous, &current)
+
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, fmt.Errorf("failed to recount blob references: %w", err)
+	}
+
+	if current == 0 {
+		if _, err := s.db.Exec(`DELETE FROM content_blobs WHERE digest = $1`, digest); err != nil {
+			return 0, 0, fmt.Errorf("failed to delete blob: %w", err)
+		}
+	}
+
+	return previous, current, nil
+}
+
+// lockDigest takes the advisory lock guarding a digest until the end of the transaction
+func lockDigest(db DBTX, digest string) error {
+	if _, err := db.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, digest); err != nil {
+		return fmt.Errorf("failed to lock blob: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/lib/pq"
+)
+
+// collectionColumns lists the columns scanned by scanCollection, in order.
+// Assets in the trash aren't counted.
+const collectionColumns = `c.id, c.name, c.description, c.asset_type,
+	(SELECT count(*) FROM collection_items ci JOIN assets a ON a.id = ci.asset_id
+	WHERE ci.collection_id = c.id AND a.deleted_at IS NULL),
+	c.created_at, c.updated_at`
+
+// PostgresCollectionStore implements CollectionStore with PostgreSQL storage
+type PostgresCollectionStore struct {
+	db DBTX
+}
+
+// NewPostgresCollectionStore creates a new PostgresCollectionStore. Items
+// go with their asset when it is deleted; positions may then have gaps,
+// which ordering doesn't mind.
+func NewPostgresCollectionStore(db *sql.DB) (*PostgresCollectionStore, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS collections (
+			id VARCHAR(36) PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			asset_type VARCHAR(50),
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS collection_items (
+			collection_id VARCHAR(36) NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+			asset_id VARCHAR(36) NOT NULL REFERENCES assets (id) ON DELETE CASCADE,
+			position INTEGER NOT NULL,
+			added_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			PRIMARY KEY (collection_id, asset_id),
+			-- Checked at the end of the statement or transaction, so items can
+			-- swap positions
+			UNIQUE (collection_id, position) DEFERRABLE INITIALLY DEFERRED
+		);
+		CREATE INDEX IF NOT EXISTS idx_collection_items_asset_id ON collection_items (asset_id);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collections tables: %w", err)
+	}
+
+	return &PostgresCollectionStore{
+		db: db,
+	}, nil
+}
+
+// WithTx returns a copy of the store that runs its queries inside tx
+func (s *PostgresCollectionStore) WithTx(tx *sql.Tx) *PostgresCollectionStore {
+	return &PostgresCollectionStore{
+		db: tx,
+	}
+}
+
+// Save stores a new collection
+func (s *PostgresCollectionStore) Save(collection *models.Collection) error {
+	_, err := s.db.Exec(
+		`INSERT INTO collections (id, name, description, asset_type, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		collection.ID,
+		collection.Name,
+		collection.Description,
+		collection.AssetType,
+		collection.CreatedAt,
+		collection.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert collection: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a collection by its ID
+func (s *PostgresCollectionStore) GetByID(id string) (*models.Collection, error) {
+	collection, err := scanCollection(s.db.QueryRow(
+		`SELECT `+collectionColumns+`
+		FROM collections c
+		WHERE c.id = $1`,
+		id,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, models.ErrCollectionNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	return collection, nil
+}
+
+// GetAll retrieves all collections by name
+func (s *PostgresCollectionStore) GetAll() ([]*models.Collection, error) {
+	return queryCollections(s.db,
+		`SELECT `+collectionColumns+`
+		FROM collections c
+		ORDER BY c.name, c.id`,
+	)
+}
+
+// Update updates the name and description of a collection
+func (s *PostgresCollectionStore) Update(collection *models.Collection) error {
+	collection.UpdatedAt = time.Now()
+
+	result, err := s.db.Exec(
+		`UPDATE collections
+		SET name = $2, description = $3, updated_at = $4
+		WHERE id = $1`,
+		collection.ID,
+		collection.Name,
+		collection.Description,
+		collection.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+
+	return collectionAffected(result)
+}
+
+// Delete removes a collection and its items
+func (s *PostgresCollectionStore) Delete(id string) error {
+	result, err := s.db.Exec(`DELETE FROM collections WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+
+	return collectionAffected(result)
+}
+
+// Touch sets the updated time of a collection. The row stays locked until
+// the transaction ends, which serializes changes to the item order.
+func (s *PostgresCollectionStore) Touch(id string, at time.Time) error {
+	result, err := s.db.Exec(`UPDATE collections SET updated_at = $2 WHERE id = $1`, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+
+	return collectionAffected(result)
+}
+
+// GetItems retrieves the items of a collection in order, leaving out assets
+// in the trash. Positions are numbered from zero whatever gaps the stored
+// positions have.
+func (s *PostgresCollectionStore) GetItems(collectionID string) ([]*models.CollectionItem, error) {
+	rows, err := s.db.Query(
+		`SELECT `+assetColumns+`, ci.added_at
+		FROM collection_items ci
+		JOIN assets ON assets.id = ci.asset_id
+		WHERE ci.collection_id = $1 AND assets.deleted_at IS NULL
+		ORDER BY ci.position`,
+		collectionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*models.CollectionItem{}
+	for rows.Next() {
+		item := models.CollectionItem{Position: len(items)}
+		asset, err := scanAsset(extraColumns{rows, []interface{}{&item.AddedAt}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collection item: %w", err)
+		}
+		item.Asset = asset
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating collection items: %w", err)
+	}
+
+	return items, nil
+}
+
+// AddItems appends assets to the end of a collection in the given order.
+// Assets already in the collection are left where they are.
+func (s *PostgresCollectionStore) AddItems(collectionID string, assetIDs []string, addedAt time.Time) error {
+	_, err := s.db.Exec(
+		`INSERT INTO collection_items (collection_id, asset_id, position, added_at)
+		SELECT $1, a.asset_id,
+			(SELECT COALESCE(max(position), -1) FROM collection_items WHERE collection_id = $1) + a.ord,
+			$3
+		FROM unnest($2::varchar[]) WITH ORDINALITY AS a (asset_id, ord)
+		ON CONFLICT (collection_id, asset_id) DO NOTHING`,
+		collectionID,
+		pq.Array(assetIDs),
+		addedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add collection items: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveItem removes an asset from a collection
+func (s *PostgresCollectionStore) RemoveItem(collectionID string, assetID string) error {
+	result, err := s.db.Exec(
+		`DELETE FROM collection_items WHERE collection_id = $1 AND asset_id = $2`,
+		collectionID,
+		assetID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove collection item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrCollectionItemNotFound
+	}
+
+	return nil
+}
+
+// Reorder numbers the items of a collection from zero in the order of
+// assetIDs, in one statement. Items not listed, such as trashed assets,
+// move behind them in their previous order.
+func (s *PostgresCollectionStore) Reorder(collectionID string, assetIDs []string) error {
+	_, err := s.db.Exec(
+		`UPDATE collection_items
+		SET position = COALESCE(
+			array_position($2::varchar[], asset_id) - 1,
+			cardinality($2::varchar[]) + position
+		)
+		WHERE collection_id = $1`,
+		collectionID,
+		pq.Array(assetIDs),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reorder collection items: %w", err)
+	}
+
+	return nil
+}
+
+// collectionAffected turns an update of no rows into ErrCollectionNotFound
+func collectionAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrCollectionNotFound
+	}
+
+	return nil
+}
+
+// queryCollections runs a query selecting collectionColumns
+func queryCollections(db DBTX, query string, args ...interface{}) ([]*models.Collection, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collections: %w", err)
+	}
+	defer rows.Close()
+
+	collections := []*models.Collection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		collections = append(collections, collection)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating collections: %w", err)
+	}
+
+	return collections, nil
+}
+
+// scanCollection reads a row selected with collectionColumns
+func scanCollection(row rowScanner) (*models.Collection, error) {
+	var collection models.Collection
+	err := row.Scan(
+		&collection.ID,
+		&collection.Name,
+		&collection.Description,
+		&collection.AssetType,
+		&collection.ItemCount,
+		&collection.CreatedAt,
+		&collection.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
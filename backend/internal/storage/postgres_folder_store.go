@@ -2,15 +2,17 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/lib/pq"
 )
 
 
 type PostgresFolderStore struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewPostgresFolderStore creates a new PostgresFolderStore
@@ -24,7 +26,7 @@ func NewPostgresFolderStore(db *sql.DB) (*PostgresFolderStore, error) {
 			parent_id VARCHAR(36),
 			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
 			updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
-			FOREIGN KEY (parent_id) REFERENCES folders(id) ON DELETE CASCADE
+			FOREIGN KEY (parent_id) REFERENCES folders(id)
 		)
 	`)
 	if err != nil {
@@ -40,7 +42,7 @@ func NewPostgresFolderStore(db *sql.DB) (*PostgresFolderStore, error) {
 			) THEN
 				ALTER TABLE assets ADD COLUMN folder_id VARCHAR(36);
 				ALTER TABLE assets ADD CONSTRAINT fk_folder_id 
-					FOREIGN KEY (folder_id) REFERENCES folders(id) ON DELETE SET NULL;
+					FOREIGN KEY (folder_id) REFERENCES folders(id);
 			END IF;
 		END $$;
 	`)
@@ -48,11 +50,104 @@ func NewPostgresFolderStore(db *sql.DB) (*PostgresFolderStore, error) {
 		return nil, fmt.Errorf("failed to update assets table: %w", err)
 	}
 
+	// Deleting a folder used to take its subfolders with it and move its
+	// assets to the root. Folders now go through the trash, which empties
+	// them itself, so the database refuses deletes that would lose anything.
+	_, err = db.Exec(`
+		ALTER TABLE folders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+		ALTER TABLE folders ADD COLUMN IF NOT EXISTS trash_id VARCHAR(36);
+		CREATE INDEX IF NOT EXISTS idx_folders_parent_id ON folders (parent_id);
+		CREATE INDEX IF NOT EXISTS idx_folders_trash_id ON folders (trash_id);
+
+		DO $$
+		BEGIN
+			IF EXISTS (
+				SELECT FROM information_schema.referential_constraints
+				WHERE constraint_name = 'folders_parent_id_fkey' AND delete_rule = 'CASCADE'
+			) THEN
+				ALTER TABLE folders DROP CONSTRAINT folders_parent_id_fkey;
+				ALTER TABLE folders ADD CONSTRAINT folders_parent_id_fkey
+					FOREIGN KEY (parent_id) REFERENCES folders(id);
+			END IF;
+
+			IF EXISTS (
+				SELECT FROM information_schema.referential_constraints
+				WHERE constraint_name = 'fk_folder_id' AND delete_rule = 'SET NULL'
+			) THEN
+				ALTER TABLE assets DROP CONSTRAINT fk_folder_id;
+				ALTER TABLE assets ADD CONSTRAINT fk_folder_id
+					FOREIGN KEY (folder_id) REFERENCES folders(id);
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add trash columns to folders: %w", err)
+	}
+
+	// Folders are addressed by path, so names must be unique among the
+	// folders outside the trash. Duplicates from before get the first
+	// numbered suffix that is still free the first time round, cutting the
+	// name short where the suffix would not fit in the column.
+	_, err = db.Exec(`
+		DO $$
+		DECLARE
+			dup RECORD;
+			n INTEGER;
+			suffix TEXT;
+			candidate TEXT;
+		BEGIN
+			IF NOT EXISTS (
+				SELECT FROM pg_indexes WHERE indexname = 'idx_folders_parent_name'
+			) THEN
+				FOR dup IN
+					SELECT id, parent_id, name
+					FROM (
+						SELECT id, parent_id, name, row_number() OVER (
+							PARTITION BY parent_id, name ORDER BY created_at, id
+						) AS nth
+						FROM folders
+						WHERE deleted_at IS NULL
+					) d
+					WHERE nth > 1
+					ORDER BY parent_id, name, nth
+				LOOP
+					n := 2;
+					LOOP
+						suffix := ' (' || n || ')';
+						candidate := left(dup.name, 255 - length(suffix)) || suffix;
+						EXIT WHEN NOT EXISTS (
+							SELECT FROM folders
+							WHERE parent_id IS NOT DISTINCT FROM dup.parent_id
+								AND name = candidate AND deleted_at IS NULL
+						);
+						n := n + 1;
+					END LOOP;
+
+					UPDATE folders SET name = candidate WHERE id = dup.id;
+				END LOOP;
+
+				CREATE UNIQUE INDEX idx_folders_parent_name
+					ON folders (COALESCE(parent_id, ''), name)
+					WHERE deleted_at IS NULL;
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add unique folder name index: %w", err)
+	}
+
 	return &PostgresFolderStore{
 		db: db,
 	}, nil
 }
 
+// WithTx returns a copy of the store that runs its queries inside tx
+func (s *PostgresFolderStore) WithTx(tx *sql.Tx) *PostgresFolderStore {
+	return &PostgresFolderStore{
+		db: tx,
+	}
+}
+
 // Save stores folder metadata in PostgreSQL
 func (s *PostgresFolderStore) Save(folder *models.Folder) error {
 	_, err := s.db.Exec(
@@ -66,6 +161,9 @@ func (s *PostgresFolderStore) Save(folder *models.Folder) error {
 		folder.CreatedAt,
 		folder.UpdatedAt,
 	)
+	if isUniqueViolation(err) {
+		return models.ErrFolderNameExists
+	}
 	if err != nil {
 		return fmt.Errorf("failed to insert folder: %w", err)
 	}
@@ -73,6 +171,47 @@ func (s *PostgresFolderStore) Save(folder *models.Folder) error {
 	return nil
 }
 
+// SaveOrGet stores a folder unless its parent already has one of the same
+// name, and returns whichever folder is there. A folder being created with
+// the name concurrently is waited for rather than reported as a conflict,
+// which would abort the transaction.
+func (s *PostgresFolderStore) SaveOrGet(folder *models.Folder) (*models.Folder, error) {
+	_, err := s.db.Exec(
+		`INSERT INTO folders 
+		(id, name, description, parent_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (COALESCE(parent_id, ''), name) WHERE deleted_at IS NULL DO NOTHING`,
+		folder.ID,
+		folder.Name,
+		folder.Description,
+		folder.ParentID,
+		folder.CreatedAt,
+		folder.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert folder: %w", err)
+	}
+
+	// A fresh snapshot sees the other folder once its creator committed
+	folders, err := queryFolders(s.db,
+		`SELECT 
+			id, name, description, parent_id, created_at, updated_at
+		FROM folders
+		WHERE parent_id IS NOT DISTINCT FROM $1 AND name = $2 AND deleted_at IS NULL`,
+		folder.ParentID,
+		folder.Name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(folders) == 0 {
+		// The other folder was moved or renamed again meanwhile
+		return nil, models.ErrFolderNameExists
+	}
+
+	return folders[0], nil
+}
+
 // GetByID retrieves a folder by its ID
 func (s *PostgresFolderStore) GetByID(id string) (*models.Folder, error) {
 	var folder models.Folder
@@ -82,7 +221,7 @@ func (s *PostgresFolderStore) GetByID(id string) (*models.Folder, error) {
 		`SELECT 
 			id, name, description, parent_id, created_at, updated_at
 		FROM folders 
-		WHERE id = $1`,
+		WHERE id = $1 AND deleted_at IS NULL`,
 		id,
 	).Scan(
 		&folder.ID,
@@ -112,6 +251,7 @@ func (s *PostgresFolderStore) GetAll() ([]*models.Folder, error) {
 		`SELECT 
 			id, name, description, parent_id, created_at, updated_at
 		FROM folders
+		WHERE deleted_at IS NULL
 		ORDER BY name ASC`,
 	)
 	if err != nil {
@@ -162,7 +302,7 @@ func (s *PostgresFolderStore) GetByParentID(parentID *string) ([]*models.Folder,
 			`SELECT 
 				id, name, description, parent_id, created_at, updated_at
 			FROM folders
-			WHERE parent_id IS NULL
+			WHERE parent_id IS NULL AND deleted_at IS NULL
 			ORDER BY name ASC`,
 		)
 	} else {
@@ -171,7 +311,7 @@ func (s *PostgresFolderStore) GetByParentID(parentID *string) ([]*models.Folder,
 			`SELECT 
 				id, name, description, parent_id, created_at, updated_at
 			FROM folders
-			WHERE parent_id = $1
+			WHERE parent_id = $1 AND deleted_at IS NULL
 			ORDER BY name ASC`,
 			*parentID,
 		)
@@ -214,6 +354,301 @@ func (s *PostgresFolderStore) GetByParentID(parentID *string) ([]*models.Folder,
 	return folders, nil
 }
 
+// GetByPath walks a path of names down from the root in one recursive
+// query, one level per name
+func (s *PostgresFolderStore) GetByPath(names []string) ([]*models.Folder, error) {
+	return queryFolders(s.db,
+		`WITH RECURSIVE walk AS (
+			SELECT id, name, description, parent_id, created_at, updated_at, 1 AS depth
+			FROM folders
+			WHERE parent_id IS NULL AND name = ($1::varchar[])[1] AND deleted_at IS NULL
+			UNION ALL
+			SELECT f.id, f.name, f.description, f.parent_id, f.created_at, f.updated_at, w.depth + 1
+			FROM folders f
+			JOIN walk w ON f.parent_id = w.id
+			WHERE f.name = ($1::varchar[])[w.depth + 1] AND f.deleted_at IS NULL
+		)
+		SELECT id, name, description, parent_id, created_at, updated_at
+		FROM walk
+		ORDER BY depth`,
+		pq.Array(names),
+	)
+}
+
+// GetAncestors retrieves every folder above a folder in one recursive
+// query, root first. The IDs visited so far are carried along so a cycle
+// in corrupted data ends the walk instead of looping.
+func (s *PostgresFolderStore) GetAncestors(id string) ([]*models.Folder, error) {
+	return queryFolders(s.db,
+		`WITH RECURSIVE ancestors AS (
+			SELECT p.id, p.name, p.description, p.parent_id, p.created_at, p.updated_at, 1 AS depth,
+				ARRAY[f.id, p.id]::varchar[] AS path
+			FROM folders f
+			JOIN folders p ON p.id = f.parent_id
+			WHERE f.id = $1 AND p.deleted_at IS NULL
+			UNION ALL
+			SELECT p.id, p.name, p.description, p.parent_id, p.created_at, p.updated_at, a.depth + 1,
+				a.path || p.id
+			FROM folders p
+			JOIN ancestors a ON p.id = a.parent_id
+			WHERE p.deleted_at IS NULL AND p.id <> ALL(a.path)
+		)
+		SELECT id, name, description, parent_id, created_at, updated_at
+		FROM ancestors
+		ORDER BY depth DESC`,
+		id,
+	)
+}
+
+// GetDescendants retrieves every folder below a folder in one recursive
+// query, shallowest first and by name within a level
+func (s *PostgresFolderStore) GetDescendants(id string) ([]*models.Folder, error) {
+	return queryFolders(s.db,
+		`WITH RECURSIVE descendants AS (
+			SELECT id, name, description, parent_id, created_at, updated_at, 1 AS depth
+			FROM folders
+			WHERE parent_id = $1 AND deleted_at IS NULL
+			UNION ALL
+			SELECT f.id, f.name, f.description, f.parent_id, f.created_at, f.updated_at, d.depth + 1
+			FROM folders f
+			JOIN descendants d ON f.parent_id = d.id
+			WHERE f.deleted_at IS NULL
+		)
+		SELECT id, name, description, parent_id, created_at, updated_at
+		FROM descendants
+		ORDER BY depth, name, id`,
+		id,
+	)
+}
+
+// GetTree retrieves a folder tree with per-folder asset counts in one
+// recursive query and nests the rows by parent
+func (s *PostgresFolderStore) GetTree(rootID *string, maxDepth int) ([]*models.FolderTreeNode, error) {
+	// The anchor is the folder itself at depth 0, or the root folders at
+	// depth 1, so maxDepth always counts levels below the starting point
+	anchor, depth := "parent_id IS NULL", "1"
+	args := []interface{}{maxDepth}
+	if rootID != nil {
+		anchor, depth = "id = $2", "0"
+		args = append(args, *rootID)
+	}
+
+	rows, err := s.db.Query(
+		`WITH RECURSIVE tree AS (
+			SELECT id, name, description, parent_id, created_at, updated_at, `+depth+` AS depth
+			FROM folders
+			WHERE `+anchor+` AND deleted_at IS NULL
+			UNION ALL
+			SELECT f.id, f.name, f.description, f.parent_id, f.created_at, f.updated_at, t.depth + 1
+			FROM folders f
+			JOIN tree t ON f.parent_id = t.id
+			WHERE f.deleted_at IS NULL AND ($1 = 0 OR t.depth < $1)
+		)
+		SELECT id, name, description, parent_id, created_at, updated_at, depth,
+			(SELECT count(*) FROM assets a WHERE a.folder_id = tree.id AND a.deleted_at IS NULL)
+		FROM tree
+		ORDER BY depth, name, id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query folder tree: %w", err)
+	}
+	defer rows.Close()
+
+	var roots []*models.FolderTreeNode
+	rootDepth := -1
+	nodes := make(map[string]*models.FolderTreeNode)
+
+	for rows.Next() {
+		node := &models.FolderTreeNode{Children: []*models.FolderTreeNode{}}
+		var parentID sql.NullString
+		var depth int
+
+		err := rows.Scan(
+			&node.ID,
+			&node.Name,
+			&node.Description,
+			&parentID,
+			&node.CreatedAt,
+			&node.UpdatedAt,
+			&depth,
+			&node.AssetCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan folder tree row: %w", err)
+		}
+
+		if parentID.Valid {
+			node.ParentID = &parentID.String
+		}
+		nodes[node.ID] = node
+
+		// Rows come shallowest first, so a parent is always seen before
+		// its children
+		if rootDepth == -1 {
+			rootDepth = depth
+		}
+		if depth == rootDepth {
+			roots = append(roots, node)
+		} else if parent, ok := nodes[parentID.String]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating folder tree rows: %w", err)
+	}
+
+	return roots, nil
+}
+
+// GetStats aggregates a folder subtree in two queries, one over the
+// folders and one over the assets in them
+func (s *PostgresFolderStore) GetStats(id *string) (*models.FolderStats, error) {
+	// The folder itself is depth 0; for the root the top-level folders are
+	// depth 1 and assets without a folder sit directly in it
+	anchor, depth, inScope, direct := "parent_id IS NULL", "1", "TRUE", "folder_id IS NULL"
+	var args []interface{}
+	if id != nil {
+		anchor, depth = "id = $1", "0"
+		inScope, direct = "folder_id IN (SELECT id FROM tree)", "folder_id = $1"
+		args = append(args, *id)
+	}
+
+	tree := `WITH RECURSIVE tree AS (
+			SELECT id, updated_at, ` + depth + ` AS depth
+			FROM folders
+			WHERE ` + anchor + ` AND deleted_at IS NULL
+			UNION ALL
+			SELECT f.id, f.updated_at, t.depth + 1
+			FROM folders f
+			JOIN tree t ON f.parent_id = t.id
+			WHERE f.deleted_at IS NULL
+		)`
+
+	stats := &models.FolderStats{
+		FolderID: id,
+		Direct:   models.FolderUsage{AssetsByType: map[models.AssetType]int{}},
+		Subtree:  models.FolderUsage{AssetsByType: map[models.AssetType]int{}},
+	}
+
+	var found, maxDepth int
+	var directModified, subtreeModified sql.NullTime
+	err := s.db.QueryRow(
+		tree+`
+		SELECT
+			count(*) FILTER (WHERE depth = 0),
+			count(*) FILTER (WHERE depth = 1),
+			count(*) FILTER (WHERE depth > 0),
+			COALESCE(max(depth), 0),
+			max(updated_at) FILTER (WHERE depth <= 1),
+			max(updated_at)
+		FROM tree`,
+		args...,
+	).Scan(&found, &stats.Direct.FolderCount, &stats.Subtree.FolderCount, &maxDepth, &directModified, &subtreeModified)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate folders: %w", err)
+	}
+	if id != nil && found == 0 {
+		return nil, models.ErrFolderNotFound
+	}
+
+	stats.Subtree.MaxDepth = maxDepth
+	stats.Direct.MaxDepth = min(maxDepth, 1)
+	stats.Direct.LastModified = latest(stats.Direct.LastModified, directModified)
+	stats.Subtree.LastModified = latest(stats.Subtree.LastModified, subtreeModified)
+
+	rows, err := s.db.Query(
+		tree+`
+		SELECT type, `+direct+` AS direct, count(*), COALESCE(sum(size), 0), max(updated_at)
+		FROM assets
+		WHERE deleted_at IS NULL AND `+inScope+`
+		GROUP BY type, direct`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate assets: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var assetType models.AssetType
+		var isDirect bool
+		var count int
+		var bytes int64
+		var modified sql.NullTime
+
+		if err := rows.Scan(&assetType, &isDirect, &count, &bytes, &modified); err != nil {
+			return nil, fmt.Errorf("failed to scan asset aggregate row: %w", err)
+		}
+
+		usages := []*models.FolderUsage{&stats.Subtree}
+		if isDirect {
+			usages = append(usages, &stats.Direct)
+		}
+		for _, usage := range usages {
+			usage.TotalBytes += bytes
+			usage.AssetCount += count
+			usage.AssetsByType[assetType] += count
+			usage.LastModified = latest(usage.LastModified, modified)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating asset aggregate rows: %w", err)
+	}
+
+	return stats, nil
+}
+
+// latest returns the later of a time and a nullable one
+func latest(current *time.Time, candidate sql.NullTime) *time.Time {
+	if !candidate.Valid || (current != nil && !candidate.Time.After(*current)) {
+		return current
+	}
+	return &candidate.Time
+}
+
+// queryFolders runs a query selecting folder columns and scans the rows
+func queryFolders(db DBTX, query string, args ...interface{}) ([]*models.Folder, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query folders: %w", err)
+	}
+	defer rows.Close()
+
+	var folders []*models.Folder
+
+	for rows.Next() {
+		var folder models.Folder
+		var parentID sql.NullString
+
+		err := rows.Scan(
+			&folder.ID,
+			&folder.Name,
+			&folder.Description,
+			&parentID,
+			&folder.CreatedAt,
+			&folder.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan folder row: %w", err)
+		}
+
+		if parentID.Valid {
+			folder.ParentID = &parentID.String
+		}
+
+		folders = append(folders, &folder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating folder rows: %w", err)
+	}
+
+	return folders, nil
+}
+
 // Update updates an existing folder
 func (s *PostgresFolderStore) Update(folder *models.Folder) error {
 	folder.UpdatedAt = time.Now()
@@ -221,13 +656,16 @@ func (s *PostgresFolderStore) Update(folder *models.Folder) error {
 	result, err := s.db.Exec(
 		`UPDATE folders 
 		SET name = $2, description = $3, parent_id = $4, updated_at = $5
-		WHERE id = $1`,
+		WHERE id = $1 AND deleted_at IS NULL`,
 		folder.ID,
 		folder.Name,
 		folder.Description,
 		folder.ParentID,
 		folder.UpdatedAt,
 	)
+	if isUniqueViolation(err) {
+		return models.ErrFolderNameExists
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update folder: %w", err)
 	}
@@ -244,8 +682,20 @@ func (s *PostgresFolderStore) Update(folder *models.Folder) error {
 	return nil
 }
 
-// Delete removes a folder from the store
+// Delete permanently removes a folder, which must have no subfolders or
+// assets outside the trash. Trashed items that were in it lose it as
+// their parent; their trash items still hold the path to restore them to.
 func (s *PostgresFolderStore) Delete(id string) error {
+	_, err := s.db.Exec(`UPDATE folders SET parent_id = NULL WHERE parent_id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to detach trashed folders: %w", err)
+	}
+
+	_, err = s.db.Exec(`UPDATE assets SET folder_id = NULL WHERE folder_id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to detach trashed assets: %w", err)
+	}
+
 	result, err := s.db.Exec("DELETE FROM folders WHERE id = $1", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete folder: %w", err)
@@ -262,3 +712,10 @@ func (s *PostgresFolderStore) Delete(id string) error {
 
 	return nil
 }
+
+// isUniqueViolation reports whether err is PostgreSQL refusing a duplicate
+// in a unique index
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// jobColumns is the column list selected by every job query, in scanJob order
+const jobColumns = `id, type, asset_id, status, attempts, max_attempts, last_error, run_at, created_at, updated_at, started_at, finished_at, locked_until`
+
+// PostgresJobStore implements JobStore with PostgreSQL storage
+type PostgresJobStore struct {
+	db DBTX
+}
+
+// NewPostgresJobStore creates a new PostgresJobStore
+func NewPostgresJobStore(db *sql.DB) (*PostgresJobStore, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS jobs (
+			id VARCHAR(36) PRIMARY KEY,
+			type VARCHAR(50) NOT NULL,
+			asset_id VARCHAR(36),
+			status VARCHAR(20) NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			max_attempts INTEGER NOT NULL,
+			last_error TEXT NOT NULL DEFAULT '',
+			run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			locked_until TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			started_at TIMESTAMP WITH TIME ZONE,
+			finished_at TIMESTAMP WITH TIME ZONE
+		);
+		CREATE INDEX IF NOT EXISTS idx_jobs_queued ON jobs (run_at) WHERE status = 'queued';
+		CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs (locked_until) WHERE status = 'running';
+		CREATE INDEX IF NOT EXISTS idx_jobs_asset_id ON jobs (asset_id);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jobs table: %w", err)
+	}
+
+	return &PostgresJobStore{
+		db: db,
+	}, nil
+}
+
+// WithTx returns a copy of the store that runs its queries inside tx
+func (s *PostgresJobStore) WithTx(tx *sql.Tx) *PostgresJobStore {
+	return &PostgresJobStore{
+		db: tx,
+	}
+}
+
+// Enqueue stores a new job
+func (s *PostgresJobStore) Enqueue(job *models.Job) error {
+	_, err := s.db.Exec(
+		`INSERT INTO jobs
+		(id, type, asset_id, status, attempts, max_attempts, run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		job.ID,
+		job.Type,
+		job.AssetID,
+		job.Status,
+		job.Attempts,
+		job.MaxAttempts,
+		job.RunAt,
+		job.CreatedAt,
+		job.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a job by its ID
+func (s *PostgresJobStore) GetByID(id string) (*models.Job, error) {
+	job, err := scanJob(s.db.QueryRow(
+		`SELECT `+jobColumns+`
+		FROM jobs
+		WHERE id = $1`,
+		id,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, models.ErrJobNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+// GetByAssetID retrieves all jobs for an asset, oldest first
+func (s *PostgresJobStore) GetByAssetID(assetID string) ([]*models.Job, error) {
+	rows, err := s.db.Query(
+		`SELECT `+jobColumns+`
+		FROM jobs
+		WHERE asset_id = $1
+		ORDER BY created_at, id`,
+		assetID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job row: %w", err)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating job rows: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// Claim takes the next due job. SKIP LOCKED lets concurrent workers, in
+// this process or another, each claim a different job without waiting on
+// one another. A job whose lease ran out on its last attempt is dead
+// lettered rather than run once more than it may be.
+func (s *PostgresJobStore) Claim(lease time.Duration) (*models.Job, error) {
+	now := time.Now()
+
+	_, err := s.db.Exec(
+		`UPDATE jobs
+		SET status = 'dead', locked_until = NULL, last_error = 'lease expired on the last attempt',
+		    finished_at = $1, updated_at = $1
+		WHERE status = 'running' AND locked_until < $1 AND attempts >= max_attempts`,
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dead letter expired jobs: %w", err)
+	}
+
+	job, err := scanJob(s.db.QueryRow(
+		`UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_until = $2,
+		    started_at = $1, updated_at = $1
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = 'queued' AND run_at <= $1)
+			   OR (status = 'running' AND locked_until < $1 AND attempts < max_attempts)
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns,
+		now,
+		now.Add(lease),
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Complete marks a job as succeeded
+func (s *PostgresJobStore) Complete(id string, lease time.Time) error {
+	now := time.Now()
+	return s.finish(
+		`UPDATE jobs
+		SET status = 'succeeded', locked_until = NULL, finished_at = $3, updated_at = $3
+		WHERE id = $1 AND status = 'running' AND locked_until = $2`,
+		id, lease, now,
+	)
+}
+
+// Retry queues a failed job to run again at runAt
+func (s *PostgresJobStore) Retry(id string, lease time.Time, runAt time.Time, lastError string) error {
+	return s.finish(
+		`UPDATE jobs
+		SET status = 'queued', locked_until = NULL, run_at = $3, last_error = $4, updated_at = $5
+		WHERE id = $1 AND status = 'running' AND locked_until = $2`,
+		id, lease, runAt, lastError, time.Now(),
+	)
+}
+
+// Fail moves a job to the dead letter state
+func (s *PostgresJobStore) Fail(id string, lease time.Time, lastError string) error {
+	now := time.Now()
+	return s.finish(
+		`UPDATE jobs
+		SET status = 'dead', locked_until = NULL, last_error = $3, finished_at = $4, updated_at = $4
+		WHERE id = $1 AND status = 'running' AND locked_until = $2`,
+		id, lease, lastError, now,
+	)
+}
+
+// DeleteSucceededBefore removes jobs that succeeded before the given time
+func (s *PostgresJobStore) DeleteSucceededBefore(before time.Time) (int64, error) {
+	result, err := s.db.Exec(
+		`DELETE FROM jobs WHERE status = 'succeeded' AND finished_at < $1`,
+		before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete jobs: %w", err)
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return removed, nil
+}
+
+// finish runs an update that ends an attempt of a job. Nothing is
+// updated when the job no longer holds the lease the attempt started with.
+func (s *PostgresJobStore) finish(query string, args ...interface{}) error {
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrJobLeaseLost
+	}
+
+	return nil
+}
+
+// scanJob reads one job selected with jobColumns
+func scanJob(row rowScanner) (*models.Job, error) {
+	var job models.Job
+
+	err := row.Scan(
+		&job.ID,
+		&job.Type,
+		&job.AssetID,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.LastError,
+		&job.RunAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// renditionColumns lists the columns scanned by scanRendition, in order
+const renditionColumns = `asset_id, kind, content_type, size, digest, storage_key, created_at`
+
+// PostgresRenditionStore implements RenditionStore with PostgreSQL storage
+type PostgresRenditionStore struct {
+	db DBTX
+}
+
+// NewPostgresRenditionStore creates a new PostgresRenditionStore. Covers
+// saved before renditions existed are carried over, keeping their keys.
+func NewPostgresRenditionStore(db *sql.DB) (*PostgresRenditionStore, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS renditions (
+			asset_id VARCHAR(36) NOT NULL REFERENCES assets (id) ON DELETE CASCADE,
+			kind VARCHAR(50) NOT NULL,
+			content_type VARCHAR(100) NOT NULL,
+			size BIGINT NOT NULL,
+			digest VARCHAR(64) NOT NULL,
+			storage_key TEXT NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			PRIMARY KEY (asset_id, kind)
+		);
+
+		DO $$
+		BEGIN
+			IF EXISTS (
+				SELECT FROM information_schema.tables
+				WHERE table_name = 'asset_covers'
+			) THEN
+				INSERT INTO renditions (asset_id, kind, content_type, size, digest, storage_key, created_at)
+				SELECT asset_id, 'cover', content_type, size, digest, 'covers/' || asset_id, created_at
+				FROM asset_covers
+				ON CONFLICT (asset_id, kind) DO NOTHING;
+
+				DROP TABLE asset_covers;
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create renditions table: %w", err)
+	}
+
+	return &PostgresRenditionStore{
+		db: db,
+	}, nil
+}
+
+// WithTx returns a copy of the store that runs its queries inside tx
+func (s *PostgresRenditionStore) WithTx(tx *sql.Tx) *PostgresRenditionStore {
+	return &PostgresRenditionStore{
+		db: tx,
+	}
+}
+
+// Save stores a rendition, replacing any of the same kind for the asset
+func (s *PostgresRenditionStore) Save(rendition *models.Rendition) error {
+	_, err := s.db.Exec(
+		`INSERT INTO renditions (`+renditionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (asset_id, kind) DO UPDATE SET
+			content_type = EXCLUDED.content_type,
+			size = EXCLUDED.size,
+			digest = EXCLUDED.digest,
+			storage_key = EXCLUDED.storage_key,
+			created_at = EXCLUDED.created_at`,
+		rendition.AssetID,
+		rendition.Kind,
+		rendition.ContentType,
+		rendition.Size,
+		rendition.Digest,
+		rendition.Key,
+		rendition.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save rendition: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves the rendition of an asset of the given kind
+func (s *PostgresRenditionStore) Get(assetID string, kind models.RenditionKind) (*models.Rendition, error) {
+	rendition, err := scanRendition(s.db.QueryRow(
+		`SELECT `+renditionColumns+`
+		FROM renditions
+		WHERE asset_id = $1 AND kind = $2`,
+		assetID, kind,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, models.ErrRenditionNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get rendition: %w", err)
+	}
+
+	return rendition, nil
+}
+
+// GetByAssetID retrieves all renditions of an asset
+func (s *PostgresRenditionStore) GetByAssetID(assetID string) ([]*models.Rendition, error) {
+	return s.queryRenditions(
+		`SELECT `+renditionColumns+`
+		FROM renditions
+		WHERE asset_id = $1
+		ORDER BY kind`,
+		assetID,
+	)
+}
+
+// GetAll retrieves all renditions
+func (s *PostgresRenditionStore) GetAll() ([]*models.Rendition, error) {
+	return s.queryRenditions(
+		`SELECT ` + renditionColumns + `
+		FROM renditions
+		ORDER BY asset_id, kind`,
+	)
+}
+
+// DeleteByAssetID removes all renditions of an asset
+func (s *PostgresRenditionStore) DeleteByAssetID(assetID string) error {
+	if _, err := s.db.Exec(`DELETE FROM renditions WHERE asset_id = $1`, assetID); err != nil {
+		return fmt.Errorf("failed to delete renditions: %w", err)
+	}
+
+	return nil
+}
+
+// queryRenditions runs a query returning rendition rows
+func (s *PostgresRenditionStore) queryRenditions(query string, args ...interface{}) ([]*models.Rendition, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query renditions: %w", err)
+	}
+	defer rows.Close()
+
+	var renditions []*models.Rendition
+	for rows.Next() {
+		rendition, err := scanRendition(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rendition: %w", err)
+		}
+		renditions = append(renditions, rendition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating renditions: %w", err)
+	}
+
+	return renditions, nil
+}
+
+// scanRendition reads a row selected with renditionColumns
+func scanRendition(row rowScanner) (*models.Rendition, error) {
+	var rendition models.Rendition
+	err := row.Scan(
+		&rendition.AssetID,
+		&rendition.Kind,
+		&rendition.ContentType,
+		&rendition.Size,
+		&rendition.Digest,
+		&rendition.Key,
+		&rendition.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rendition, nil
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+const (
+	// searchConfig is the text search configuration used for indexing and
+	// parsing queries alike
+	searchConfig = "english"
+	// snippetSourceSize caps how much body text snippets are cut from, as
+	// ts_headline reparses the whole text it is given
+	snippetSourceSize = 64 << 10
+	// Snippets mark matches with private use characters, so the text can be
+	// escaped before the marks become HTML
+	snippetStart = "\ue000"
+	snippetStop  = "\ue001"
+)
+
+// PostgresSearchStore implements SearchStore with PostgreSQL full-text search
+type PostgresSearchStore struct {
+	db DBTX
+}
+
+// NewPostgresSearchStore creates a new PostgresSearchStore. The assets
+// table gets a generated search vector, which keeps names and metadata
+// indexed as assets are created, renamed and updated.
+func NewPostgresSearchStore(db *sql.DB) (*PostgresSearchStore, error) {
+	_, err := db.Exec(`
+		ALTER TABLE assets ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('` + searchConfig + `', COALESCE(name, '')), 'A') ||
+			setweight(to_tsvector('` + searchConfig + `', COALESCE(metadata->>'title', '')), 'A') ||
+			setweight(to_tsvector('` + searchConfig + `',
+				COALESCE(metadata->>'author', '') || ' ' ||
+				COALESCE(metadata->>'creators', '') || ' ' ||
+				COALESCE(metadata->>'artist', '') || ' ' ||
+				COALESCE(metadata->>'albumArtist', '')), 'B') ||
+			setweight(to_tsvector('` + searchConfig + `', COALESCE(metadata->>'album', '')), 'B')
+		) STORED;
+		CREATE INDEX IF NOT EXISTS idx_assets_search_vector ON assets USING GIN (search_vector);
+
+		CREATE TABLE IF NOT EXISTS asset_texts (
+			asset_id VARCHAR(36) PRIMARY KEY REFERENCES assets (id) ON DELETE CASCADE,
+			body TEXT NOT NULL,
+			body_vector tsvector NOT NULL,
+			indexed_at TIMESTAMP WITH TIME ZONE NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_asset_texts_body_vector ON asset_texts USING GIN (body_vector);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create search index: %w", err)
+	}
+
+	return &PostgresSearchStore{
+		db: db,
+	}, nil
+}
+
+// SaveText indexes the body text of an asset, replacing any indexed before
+func (s *PostgresSearchStore) SaveText(assetID string, text string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO asset_texts (asset_id, body, body_vector, indexed_at)
+		VALUES ($1, $2, to_tsvector('`+searchConfig+`', $2), $3)
+		ON CONFLICT (asset_id) DO UPDATE SET
+			body = EXCLUDED.body,
+			body_vector = EXCLUDED.body_vector,
+			indexed_at = EXCLUDED.indexed_at`,
+		assetID,
+		text,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to index asset text: %w", err)
+	}
+
+	return nil
+}
+
+// Search ranks matches in the name and metadata above matches in the body
+// text. Snippets are only cut for the page of results being returned.
+func (s *PostgresSearchStore) Search(query *models.SearchQuery) ([]*models.SearchResult, error) {
+	args := []interface{}{query.Text}
+	conditions := []string{
+		`(a.search_vector @@ q.query OR t.body_vector @@ q.query)`,
+		`a.deleted_at IS NULL`,
+	}
+
+	if query.Type != "" {
+		args = append(args, query.Type)
+		conditions = append(conditions, fmt.Sprintf(`a.type = $%d`, len(args)))
+	}
+	if query.RootFolder {
+		conditions = append(conditions, `a.folder_id IS NULL`)
+	} else if query.FolderID != nil {
+		args = append(args, *query.FolderID)
+		conditions = append(conditions, fmt.Sprintf(`a.folder_id = $%d`, len(args)))
+	}
+
+	args = append(args, query.Limit, query.Offset,
+		`MaxFragments=2, MinWords=8, MaxWords=24, FragmentDelimiter=" … ", `+
+			`StartSel="`+snippetStart+`", StopSel="`+snippetStop+`"`)
+	n := len(args)
+
+	rows, err := s.db.Query(
+		`SELECT `+assetColumns+`, rank,
+			ts_headline('`+searchConfig+`',
+				concat_ws(E'\n', name, metadata->>'title', left(body, `+fmt.Sprint(snippetSourceSize)+`)),
+				query, $`+fmt.Sprint(n)+`)
+		FROM (
+			SELECT a.*, t.body, q.query,
+				ts_rank_cd(a.search_vector, q.query) * 2 +
+				COALESCE(ts_rank_cd(t.body_vector, q.query), 0) AS rank
+			FROM assets a
+			LEFT JOIN asset_texts t ON t.asset_id = a.id
+			CROSS JOIN (SELECT websearch_to_tsquery('`+searchConfig+`', $1) AS query) q
+			WHERE `+strings.Join(conditions, " AND ")+`
+			ORDER BY rank DESC, a.id
+			LIMIT $`+fmt.Sprint(n-2)+` OFFSET $`+fmt.Sprint(n-1)+`
+		) assets
+		ORDER BY rank DESC, id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search assets: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*models.SearchResult{}
+
+	for rows.Next() {
+		var result models.SearchResult
+		var snippet string
+
+		asset, err := scanAsset(extraColumns{rows, []interface{}{&result.Rank, &snippet}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Asset = asset
+		result.Snippet = highlight(snippet)
+
+		results = append(results, &result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// extraColumns scans columns selected after the asset columns into extra
+type extraColumns struct {
+	rows  *sql.Rows
+	extra []interface{}
+}
+
+// Scan implements rowScanner
+func (e extraColumns) Scan(dest ...interface{}) error {
+	return e.rows.Scan(append(dest, e.extra...)...)
+}
+
+// highlight escapes a ts_headline snippet and turns its match marks into
+// <mark> elements
+func highlight(snippet string) string {
+	snippet = html.EscapeString(strings.TrimSpace(snippet))
+	snippet = strings.ReplaceAll(snippet, snippetStart, "<mark>")
+	return strings.ReplaceAll(snippet, snippetStop, "</mark>")
+}
@@ -4,28 +4,73 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
-	"mime/multipart"
-	"strings"
-
-	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"time"
 )
 
-// PostgresStorageProvider implements StorageProvider with PostgreSQL storage
+// postgresChunkSize is the size of each content chunk row (1MB).
+// Reads and writes never hold more than one chunk in memory.
+const postgresChunkSize = 1 << 20
+
+// PostgresStorageProvider implements StorageProvider with PostgreSQL storage.
+// Content is split into fixed-size chunks in content_chunks, with one
+// content_objects row per stored file.
 type PostgresStorageProvider struct {
 	db *sql.DB
+	tx *sql.Tx
 }
 
 // NewPostgresStorageProvider creates a new PostgresStorageProvider
 func NewPostgresStorageProvider(db *sql.DB) (*PostgresStorageProvider, error) {
 	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS file_contents (
-			asset_id VARCHAR(36) PRIMARY KEY,
-			content BYTEA NOT NULL,
-			FOREIGN KEY (asset_id) REFERENCES assets(id) ON DELETE CASCADE
+		CREATE TABLE IF NOT EXISTS content_objects (
+			key VARCHAR(255) PRIMARY KEY,
+			size BIGINT NOT NULL,
+			chunk_count INTEGER NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create content_objects table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS content_chunks (
+			key VARCHAR(255) NOT NULL,
+			seq INTEGER NOT NULL,
+			data BYTEA NOT NULL,
+			PRIMARY KEY (key, seq),
+			FOREIGN KEY (key) REFERENCES content_objects(key) ON DELETE CASCADE
 		)
 	`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create file_contents table: %w", err)
+		return nil, fmt.Errorf("failed to create content_chunks table: %w", err)
+	}
+
+	// Move content from the old single-row file_contents table into chunks
+	_, err = db.Exec(fmt.Sprintf(`
+		DO $$
+		BEGIN
+			IF EXISTS (
+				SELECT FROM information_schema.tables
+				WHERE table_name = 'file_contents'
+			) THEN
+				INSERT INTO content_objects (key, size, chunk_count, created_at)
+				SELECT asset_id, length(content), (length(content) + %[1]d - 1) / %[1]d, NOW()
+				FROM file_contents
+				ON CONFLICT (key) DO NOTHING;
+
+				INSERT INTO content_chunks (key, seq, data)
+				SELECT fc.asset_id, s.seq, substring(fc.content FROM s.seq * %[1]d + 1 FOR %[1]d)
+				FROM file_contents fc
+				CROSS JOIN LATERAL generate_series(0, (length(fc.content) + %[1]d - 1) / %[1]d - 1) AS s(seq)
+				ON CONFLICT (key, seq) DO NOTHING;
+
+				DROP TABLE file_contents;
+			END IF;
+		END $$;
+	`, postgresChunkSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate file_contents table: %w", err)
 	}
 
 	return &PostgresStorageProvider{
@@ -33,50 +78,131 @@ func NewPostgresStorageProvider(db *sql.DB) (*PostgresStorageProvider, error) {
 	}, nil
 }
 
-// Save stores a file in the PostgreSQL database
-func (ps *PostgresStorageProvider) Save(file multipart.File, asset *models.Asset) (string, error) {
-	// Read file content
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return "", fmt.Errorf("failed to read file content: %w", err)
+// WithTx returns a copy of the provider that runs its queries inside tx
+func (ps *PostgresStorageProvider) WithTx(tx *sql.Tx) StorageProvider {
+	return &PostgresStorageProvider{
+		db: ps.db,
+		tx: tx,
 	}
+}
 
-	// Insert file content into the database
-	_, err = ps.db.Exec(
-		`INSERT INTO file_contents (asset_id, content) VALUES ($1, $2)`,
-		asset.ID, content,
+// conn returns the transaction the provider is bound to, or the database
+func (ps *PostgresStorageProvider) conn() DBTX {
+	if ps.tx != nil {
+		return ps.tx
+	}
+	return ps.db
+}
+
+// Save streams a file into the PostgreSQL database one chunk at a time
+func (ps *PostgresStorageProvider) Save(key string, content io.Reader) (string, error) {
+	// Chunks must never be visible half-written, so use our own transaction
+	// unless we are already part of one
+	tx := ps.tx
+	if tx == nil {
+		ownTx, err := ps.db.Begin()
+		if err != nil {
+			return "", fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer ownTx.Rollback()
+		tx = ownTx
+	}
+
+	_, err := tx.Exec(
+		`INSERT INTO content_objects (key, size, chunk_count, created_at) VALUES ($1, 0, 0, $2)`,
+		key, time.Now(),
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to insert file content: %w", err)
 	}
 
+	buffer := make([]byte, postgresChunkSize)
+	var size int64
+	chunks := 0
+
+	for {
+		n, readErr := io.ReadFull(content, buffer)
+		if n > 0 {
+			_, err := tx.Exec(
+				`INSERT INTO content_chunks (key, seq, data) VALUES ($1, $2, $3)`,
+				key, chunks, buffer[:n],
+			)
+			if err != nil {
+				return "", fmt.Errorf("failed to insert file chunk: %w", err)
+			}
+			size += int64(n)
+			chunks++
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return "", fmt.Errorf("failed to read file content: %w", readErr)
+		}
+	}
+
+	_, err = tx.Exec(
+		`UPDATE content_objects SET size = $2, chunk_count = $3 WHERE key = $1`,
+		key, size, chunks,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to update file content: %w", err)
+	}
+
+	if ps.tx == nil {
+		if err := tx.Commit(); err != nil {
+			return "", fmt.Errorf("failed to commit file content: %w", err)
+		}
+	}
+
 	// Return a reference path (not actually used for file access)
-	return fmt.Sprintf("db://%s", asset.ID), nil
+	return fmt.Sprintf("db://%s", key), nil
 }
 
-// Get retrieves a file from the PostgreSQL database
-func (ps *PostgresStorageProvider) Get(assetID string) (io.ReadCloser, error) {
-	var content []byte
-	err := ps.db.QueryRow(
-		`SELECT content FROM file_contents WHERE asset_id = $1`,
-		assetID,
-	).Scan(&content)
+// Get returns a reader that streams a file from the PostgreSQL database chunk by chunk
+func (ps *PostgresStorageProvider) Get(key string) (io.ReadCloser, error) {
+	var chunkCount int
+	err := ps.conn().QueryRow(
+		`SELECT chunk_count FROM content_objects WHERE key = $1`,
+		key,
+	).Scan(&chunkCount)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("file content not found for asset: %s", assetID)
+		return nil, fmt.Errorf("file content not found for key: %s", key)
 	} else if err != nil {
 		return nil, fmt.Errorf("failed to get file content: %w", err)
 	}
 
-	// Create an in-memory reader from the content
-	return io.NopCloser(strings.NewReader(string(content))), nil
+	return &chunkReader{
+		db:         ps.conn(),
+		key:        key,
+		chunkCount: chunkCount,
+	}, nil
+}
+
+// GetRange streams length bytes from offset, starting at the chunk that holds offset
+func (ps *PostgresStorageProvider) GetRange(key string, offset, length int64) (io.ReadCloser, error) {
+	content, err := ps.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := content.(*chunkReader)
+	reader.next = int(offset / postgresChunkSize)
+	reader.skip = int(offset % postgresChunkSize)
+
+	return &limitedReadCloser{
+		Reader: io.LimitReader(reader, length),
+		Closer: reader,
+	}, nil
 }
 
 // Delete removes a file from the PostgreSQL database
-func (ps *PostgresStorageProvider) Delete(assetID string) error {
-	result, err := ps.db.Exec(
-		`DELETE FROM file_contents WHERE asset_id = $1`,
-		assetID,
+func (ps *PostgresStorageProvider) Delete(key string) error {
+	result, err := ps.conn().Exec(
+		`DELETE FROM content_objects WHERE key = $1`,
+		key,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to delete file content: %w", err)
@@ -88,8 +214,81 @@ func (ps *PostgresStorageProvider) Delete(assetID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("file content not found for asset: %s", assetID)
+		return fmt.Errorf("file content not found for key: %s", key)
+	}
+
+	return nil
+}
+
+// List reports every stored content object
+func (ps *PostgresStorageProvider) List(fn func(info ContentInfo) error) error {
+	rows, err := ps.conn().Query(
+		`SELECT key, size, created_at FROM content_objects ORDER BY key`,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query content objects: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var info ContentInfo
+		if err := rows.Scan(&info.Key, &info.Size, &info.ModTime); err != nil {
+			return fmt.Errorf("failed to scan content object row: %w", err)
+		}
+
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating content object rows: %w", err)
 	}
 
 	return nil
 }
+
+// chunkReader streams content_chunks rows in order, fetching one chunk per query
+type chunkReader struct {
+	db         DBTX
+	key        string
+	chunkCount int
+	next       int
+	skip       int // Bytes to drop from the next chunk read
+	buffer     []byte
+}
+
+// Read implements io.Reader
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buffer) == 0 {
+		if r.next >= r.chunkCount {
+			return 0, io.EOF
+		}
+
+		err := r.db.QueryRow(
+			`SELECT data FROM content_chunks WHERE key = $1 AND seq = $2`,
+			r.key, r.next,
+		).Scan(&r.buffer)
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("missing chunk %d for content: %s", r.next, r.key)
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to read file chunk: %w", err)
+		}
+		r.next++
+
+		if r.skip > 0 {
+			r.buffer = r.buffer[min(r.skip, len(r.buffer)):]
+			r.skip = 0
+		}
+	}
+
+	n := copy(p, r.buffer)
+	r.buffer = r.buffer[n:]
+	return n, nil
+}
+
+// Close implements io.Closer
+func (r *chunkReader) Close() error {
+	r.buffer = nil
+	return nil
+}
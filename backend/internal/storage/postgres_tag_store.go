@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+	"github.com/lib/pq"
+)
+
+// tagColumns lists the columns scanned by scanTag, in order. Assets in the
+// trash aren't counted.
+const tagColumns = `t.id, t.name, t.created_at,
+	(SELECT count(*) FROM asset_tags at JOIN assets a ON a.id = at.asset_id
+	WHERE at.tag_id = t.id AND a.deleted_at IS NULL)`
+
+// PostgresTagStore implements TagStore with PostgreSQL storage
+type PostgresTagStore struct {
+	db DBTX
+}
+
+// NewPostgresTagStore creates a new PostgresTagStore
+func NewPostgresTagStore(db *sql.DB) (*PostgresTagStore, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS tags (
+			id VARCHAR(36) PRIMARY KEY,
+			name VARCHAR(64) NOT NULL UNIQUE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS asset_tags (
+			asset_id VARCHAR(36) NOT NULL REFERENCES assets (id) ON DELETE CASCADE,
+			tag_id VARCHAR(36) NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			PRIMARY KEY (asset_id, tag_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_asset_tags_tag_id ON asset_tags (tag_id);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tags tables: %w", err)
+	}
+
+	return &PostgresTagStore{
+		db: db,
+	}, nil
+}
+
+// GetAll retrieves all tags with their usage counts, by name
+func (s *PostgresTagStore) GetAll() ([]*models.Tag, error) {
+	rows, err := s.db.Query(
+		`SELECT ` + tagColumns + `
+		FROM tags t
+		ORDER BY t.name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []*models.Tag
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// GetByID retrieves a tag by its ID
+func (s *PostgresTagStore) GetByID(id string) (*models.Tag, error) {
+	return s.getTag(`t.id = $1`, id)
+}
+
+// GetByName retrieves a tag by its normalized name
+func (s *PostgresTagStore) GetByName(name string) (*models.Tag, error) {
+	return s.getTag(`t.name = $1`, name)
+}
+
+// getTag retrieves the tag matching a condition on one argument
+func (s *PostgresTagStore) getTag(condition string, arg interface{}) (*models.Tag, error) {
+	tag, err := scanTag(s.db.QueryRow(
+		`SELECT `+tagColumns+`
+		FROM tags t
+		WHERE `+condition,
+		arg,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, models.ErrTagNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// AddToAsset attaches tags to an asset by name, creating missing tags, in
+// one statement. Tags the asset already has are left as they are.
+func (s *PostgresTagStore) AddToAsset(assetID string, tags []*models.Tag) error {
+	ids := make([]string, len(tags))
+	names := make([]string, len(tags))
+	createdAt := make([]string, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+		names[i] = tag.Name
+		createdAt[i] = tag.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	// The no-op update returns existing tags from the insert too, including
+	// ones another transaction created meanwhile, which the statement's
+	// snapshot wouldn't show. DISTINCT ON keeps a name from being updated
+	// twice.
+	_, err := s.db.Exec(
+		`WITH input AS (
+			SELECT DISTINCT ON (name) *
+			FROM unnest($2::varchar[], $3::varchar[], $4::timestamptz[]) AS i (id, name, created_at)
+		), upserted AS (
+			INSERT INTO tags (id, name, created_at)
+			SELECT id, name, created_at FROM input
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id, name
+		)
+		INSERT INTO asset_tags (asset_id, tag_id, created_at)
+		SELECT $1, u.id, i.created_at
+		FROM upserted u
+		JOIN input i ON i.name = u.name
+		ON CONFLICT (asset_id, tag_id) DO NOTHING`,
+		assetID,
+		pq.Array(ids),
+		pq.Array(names),
+		pq.Array(createdAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to tag asset: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveFromAsset detaches a tag from an asset. The tag itself is kept,
+// even when no asset uses it anymore.
+func (s *PostgresTagStore) RemoveFromAsset(assetID string, name string) error {
+	result, err := s.db.Exec(
+		`DELETE FROM asset_tags
+		WHERE asset_id = $1 AND tag_id = (SELECT id FROM tags WHERE name = $2)`,
+		assetID,
+		name,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to untag asset: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// Rename changes the name of a tag. It returns ErrTagExists when another
+// tag has the name.
+func (s *PostgresTagStore) Rename(id string, name string) error {
+	result, err := s.db.Exec(`UPDATE tags SET name = $2 WHERE id = $1`, id, name)
+	if isUniqueViolation(err) {
+		// Another tag took the name since the service checked
+		return models.ErrTagExists
+	} else if err != nil {
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// Merge moves the assets of one tag to another and removes the first, in
+// one statement. Assets that already have both tags keep a single link.
+func (s *PostgresTagStore) Merge(sourceID string, targetID string) error {
+	result, err := s.db.Exec(
+		`WITH moved AS (
+			INSERT INTO asset_tags (asset_id, tag_id, created_at)
+			SELECT asset_id, $2, created_at FROM asset_tags WHERE tag_id = $1
+			ON CONFLICT (asset_id, tag_id) DO NOTHING
+		)
+		DELETE FROM tags WHERE id = $1`,
+		sourceID,
+		targetID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to merge tags: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a tag, detaching it from every asset
+func (s *PostgresTagStore) Delete(id string) error {
+	result, err := s.db.Exec(`DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// scanTag reads a row selected with tagColumns
+func scanTag(row rowScanner) (*models.Tag, error) {
+	var tag models.Tag
+	err := row.Scan(
+		&tag.ID,
+		&tag.Name,
+		&tag.CreatedAt,
+		&tag.AssetCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+func TestPostgresTagStore(t *testing.T) {
+	db := newTestDB(t)
+	assets, err := NewPostgresAssetStore(db)
+	if err != nil {
+		t.Fatalf("NewPostgresAssetStore: %v", err)
+	}
+	tags, err := NewPostgresTagStore(db)
+	if err != nil {
+		t.Fatalf("NewPostgresTagStore: %v", err)
+	}
+
+	now := time.Now()
+	asset := &models.Asset{
+		ID:          "asset-1",
+		Name:        "a.pdf",
+		Type:        models.AssetTypePDF,
+		ContentType: "application/pdf",
+		Path:        "/dev/null",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Metadata:    map[string]interface{}{},
+	}
+	if err := assets.Save(asset); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	tag := func(id, name string) *models.Tag {
+		return &models.Tag{ID: id, Name: name, CreatedAt: now}
+	}
+
+	// A new tag and an existing one go in together, and adding them again
+	// changes nothing
+	if err := tags.AddToAsset(asset.ID, []*models.Tag{tag("tag-1", "red")}); err != nil {
+		t.Fatalf("AddToAsset: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := tags.AddToAsset(asset.ID, []*models.Tag{tag("tag-2", "red"), tag("tag-3", "blue")}); err != nil {
+			t.Fatalf("AddToAsset: %v", err)
+		}
+	}
+
+	all, err := tags.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	got := map[string]string{}
+	for _, tag := range all {
+		if tag.AssetCount != 1 {
+			t.Errorf("tag %s is used %d times, want 1", tag.Name, tag.AssetCount)
+		}
+		got[tag.Name] = tag.ID
+	}
+	if len(got) != 2 || got["red"] != "tag-1" || got["blue"] != "tag-3" {
+		t.Errorf("tags = %v, want red tag-1 and blue tag-3", got)
+	}
+
+	// The same new name twice in one call makes one tag
+	if err := tags.AddToAsset(asset.ID, []*models.Tag{tag("tag-4", "green"), tag("tag-5", "green")}); err != nil {
+		t.Fatalf("AddToAsset with a repeated name: %v", err)
+	}
+	if green, err := tags.GetByName("green"); err != nil || green.AssetCount != 1 {
+		t.Errorf("GetByName(green) = %+v, %v, want a tag used once", green, err)
+	}
+
+	if err := tags.Rename("tag-3", "red"); !errors.Is(err, models.ErrTagExists) {
+		t.Errorf("Rename to a taken name returned %v, want ErrTagExists", err)
+	}
+	if err := tags.Rename("missing", "green"); !errors.Is(err, models.ErrTagNotFound) {
+		t.Errorf("Rename of a missing tag returned %v, want ErrTagNotFound", err)
+	}
+}
@@ -0,0 +1,341 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// trashColumns lists the columns scanned by scanTrashItem, in order
+const trashColumns = `t.id, t.item_type, t.item_id, t.name, t.original_path,
+	(SELECT count(*) FROM assets a WHERE a.trash_id = t.id),
+	(SELECT count(*) FROM folders f WHERE f.trash_id = t.id),
+	t.deleted_at`
+
+// PostgresTrashStore implements TrashStore with PostgreSQL storage. Trashed
+// assets and folders carry the ID of their trash item in trash_id.
+type PostgresTrashStore struct {
+	db DBTX
+}
+
+// NewPostgresTrashStore creates a new PostgresTrashStore
+func NewPostgresTrashStore(db *sql.DB) (*PostgresTrashStore, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS trash_items (
+			id VARCHAR(36) PRIMARY KEY,
+			item_type VARCHAR(20) NOT NULL,
+			item_id VARCHAR(36) NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			original_path JSONB NOT NULL,
+			deleted_at TIMESTAMP WITH TIME ZONE NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_trash_items_deleted_at ON trash_items (deleted_at);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trash table: %w", err)
+	}
+
+	return &PostgresTrashStore{
+		db: db,
+	}, nil
+}
+
+// WithTx returns a copy of the store that runs its queries inside tx
+func (s *PostgresTrashStore) WithTx(tx *sql.Tx) *PostgresTrashStore {
+	return &PostgresTrashStore{
+		db: tx,
+	}
+}
+
+// TrashAsset records a trash item for an asset and hides the asset
+func (s *PostgresTrashStore) TrashAsset(item *models.TrashItem) error {
+	result, err := s.db.Exec(
+		`UPDATE assets SET deleted_at = $3, trash_id = $2
+		WHERE id = $1 AND deleted_at IS NULL`,
+		item.ItemID,
+		item.ID,
+		item.DeletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to trash asset: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrAssetNotFound
+	}
+
+	return s.save(item)
+}
+
+// TrashFolder records a trash item for a folder and hides the folder with
+// its whole subtree. Anything in it that was trashed before keeps its own
+// trash item.
+func (s *PostgresTrashStore) TrashFolder(item *models.TrashItem) error {
+	result, err := s.db.Exec(
+		`WITH RECURSIVE subtree AS (
+			SELECT id FROM folders WHERE id = $1 AND deleted_at IS NULL
+			UNION ALL
+			SELECT f.id FROM folders f
+			JOIN subtree ON f.parent_id = subtree.id
+			WHERE f.deleted_at IS NULL
+		), trashed_assets AS (
+			UPDATE assets SET deleted_at = $3, trash_id = $2
+			WHERE folder_id IN (SELECT id FROM subtree) AND deleted_at IS NULL
+		)
+		UPDATE folders SET deleted_at = $3, trash_id = $2
+		WHERE id IN (SELECT id FROM subtree)`,
+		item.ItemID,
+		item.ID,
+		item.DeletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to trash folder: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrFolderNotFound
+	}
+
+	return s.save(item)
+}
+
+// save inserts a trash item
+func (s *PostgresTrashStore) save(item *models.TrashItem) error {
+	pathJSON, err := json.Marshal(item.OriginalPath)
+	if err != nil {
+		return fmt.Errorf("failed to marshal original path: %w", err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO trash_items (id, item_type, item_id, name, original_path, deleted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		item.ID,
+		item.ItemType,
+		item.ItemID,
+		item.Name,
+		pathJSON,
+		item.DeletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert trash item: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a trash item by its ID
+func (s *PostgresTrashStore) GetByID(id string) (*models.TrashItem, error) {
+	item, err := scanTrashItem(s.db.QueryRow(
+		`SELECT `+trashColumns+`
+		FROM trash_items t
+		WHERE t.id = $1`,
+		id,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, models.ErrTrashItemNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get trash item: %w", err)
+	}
+
+	return item, nil
+}
+
+// GetAll retrieves all trash items, most recently deleted first
+func (s *PostgresTrashStore) GetAll() ([]*models.TrashItem, error) {
+	return s.queryTrashItems(
+		`SELECT ` + trashColumns + `
+		FROM trash_items t
+		ORDER BY t.deleted_at DESC, t.id`,
+	)
+}
+
+// GetDeletedBefore retrieves the trash items deleted before a time, oldest
+// first
+func (s *PostgresTrashStore) GetDeletedBefore(before time.Time) ([]*models.TrashItem, error) {
+	return s.queryTrashItems(
+		`SELECT `+trashColumns+`
+		FROM trash_items t
+		WHERE t.deleted_at < $1
+		ORDER BY t.deleted_at, t.id`,
+		before,
+	)
+}
+
+// GetAssets retrieves the assets hidden by a trash item
+func (s *PostgresTrashStore) GetAssets(id string) ([]*models.Asset, error) {
+	return queryAssets(s.db,
+		`SELECT `+assetColumns+`
+		FROM assets
+		WHERE trash_id = $1`,
+		id,
+	)
+}
+
+// Restore unhides everything a trash item hid and puts the item itself in
+// the given folder. The rest keep their places inside the item.
+func (s *PostgresTrashStore) Restore(item *models.TrashItem, folderID *string) error {
+	_, err := s.db.Exec(
+		`UPDATE folders
+		SET deleted_at = NULL, trash_id = NULL,
+			parent_id = CASE WHEN id = $2 THEN $3::varchar ELSE parent_id END
+		WHERE trash_id = $1`,
+		item.ID,
+		item.ItemID,
+		folderID,
+	)
+	if isUniqueViolation(err) {
+		// A folder with the same name took the restored one's place
+		return models.ErrFolderNameExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore folders: %w", err)
+	}
+
+	_, err = s.db.Exec(
+		`UPDATE assets
+		SET deleted_at = NULL, trash_id = NULL,
+			folder_id = CASE WHEN id = $2 THEN $3::varchar ELSE folder_id END
+		WHERE trash_id = $1`,
+		item.ID,
+		item.ItemID,
+		folderID,
+	)
+	if isUniqueViolation(err) {
+		// An asset with the same name took the restored one's place
+		return models.ErrPathExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore assets: %w", err)
+	}
+
+	return s.delete(item.ID)
+}
+
+// Purge permanently removes the folders of a trash item and the item.
+// Items trashed on their own before lose their parent folder here, but
+// their original path still names it.
+func (s *PostgresTrashStore) Purge(id string) error {
+	_, err := s.db.Exec(
+		`UPDATE folders SET parent_id = NULL
+		WHERE parent_id IN (SELECT id FROM folders WHERE trash_id = $1)
+			AND trash_id IS DISTINCT FROM $1`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to detach folders: %w", err)
+	}
+
+	_, err = s.db.Exec(
+		`UPDATE assets SET folder_id = NULL
+		WHERE folder_id IN (SELECT id FROM folders WHERE trash_id = $1)
+			AND trash_id IS DISTINCT FROM $1`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to detach assets: %w", err)
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM folders WHERE trash_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete folders: %w", err)
+	}
+
+	return s.delete(id)
+}
+
+// DeleteAssetItem removes the trash item of an asset trashed on its own.
+// Items of folders the asset was trashed with are left alone.
+func (s *PostgresTrashStore) DeleteAssetItem(assetID string) error {
+	_, err := s.db.Exec(
+		`DELETE FROM trash_items WHERE item_type = $1 AND item_id = $2`,
+		models.TrashItemTypeAsset,
+		assetID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete trash item: %w", err)
+	}
+
+	return nil
+}
+
+// delete removes a trash item
+func (s *PostgresTrashStore) delete(id string) error {
+	result, err := s.db.Exec(`DELETE FROM trash_items WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete trash item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrTrashItemNotFound
+	}
+
+	return nil
+}
+
+// queryTrashItems runs a query selecting trashColumns
+func (s *PostgresTrashStore) queryTrashItems(query string, args ...interface{}) ([]*models.TrashItem, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*models.TrashItem{}
+	for rows.Next() {
+		item, err := scanTrashItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trash item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trash items: %w", err)
+	}
+
+	return items, nil
+}
+
+// scanTrashItem reads a row selected with trashColumns
+func scanTrashItem(row rowScanner) (*models.TrashItem, error) {
+	var item models.TrashItem
+	var pathJSON []byte
+
+	err := row.Scan(
+		&item.ID,
+		&item.ItemType,
+		&item.ItemID,
+		&item.Name,
+		&pathJSON,
+		&item.AssetCount,
+		&item.FolderCount,
+		&item.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(pathJSON, &item.OriginalPath); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal original path: %w", err)
+	}
+
+	return &item, nil
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// PostgresUnitOfWork implements UnitOfWork with a shared *sql.Tx
+type PostgresUnitOfWork struct {
+	db          *sql.DB
+	assets      *PostgresAssetStore
+	folders     *PostgresFolderStore
+	blobs       *PostgresBlobStore
+	renditions  *PostgresRenditionStore
+	jobs        *PostgresJobStore
+	collections *PostgresCollectionStore
+	trash       *PostgresTrashStore
+	content     StorageProvider
+}
+
+// NewPostgresUnitOfWork creates a new PostgresUnitOfWork
+func NewPostgresUnitOfWork(db *sql.DB, assets *PostgresAssetStore, folders *PostgresFolderStore, blobs *PostgresBlobStore, renditions *PostgresRenditionStore, jobs *PostgresJobStore, collections *PostgresCollectionStore, trash *PostgresTrashStore, content StorageProvider) *PostgresUnitOfWork {
+	return &PostgresUnitOfWork{
+		db:          db,
+		assets:      assets,
+		folders:     folders,
+		blobs:       blobs,
+		renditions:  renditions,
+		jobs:        jobs,
+		collections: collections,
+		trash:       trash,
+		content:     content,
+	}
+}
+
+// Do runs fn inside a database transaction.
+// Providers that can't join the transaction get compensating cleanup instead.
+func (u *PostgresUnitOfWork) Do(fn func(tx *Tx) error) error {
+	sqlTx, err := u.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	tx := &Tx{
+		Assets:      u.assets.WithTx(sqlTx),
+		Folders:     u.folders.WithTx(sqlTx),
+		Blobs:       u.blobs.WithTx(sqlTx),
+		Renditions:  u.renditions.WithTx(sqlTx),
+		Jobs:        u.jobs.WithTx(sqlTx),
+		Collections: u.collections.WithTx(sqlTx),
+		Trash:       u.trash.WithTx(sqlTx),
+	}
+	if provider, ok := u.content.(TransactionalStorageProvider); ok {
+		tx.Content = provider.WithTx(sqlTx)
+	} else {
+		tx.Content = &compensatingStorageProvider{
+			provider: u.content,
+			tx:       tx,
+			remove:   u.removeUnreferenced,
+		}
+	}
+
+	// Compensations run before the rollback, while the transaction still
+	// holds its locks, so they can't undo a concurrent writer's content
+	defer func() {
+		if p := recover(); p != nil {
+			tx.rolledBack()
+			sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.rolledBack()
+		sqlTx.Rollback()
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		tx.rolledBack()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	tx.committed()
+	return nil
+}
+
+// removeUnreferenced deletes content after a commit unless a blob referencing
+// the same key was created in the meantime
+func (u *PostgresUnitOfWork) removeUnreferenced(key string) error {
+	sqlTx, err := u.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	if err := lockDigest(sqlTx, key); err != nil {
+		return err
+	}
+
+	var referenced bool
+	err = sqlTx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM content_blobs WHERE digest = $1)`,
+		key,
+	).Scan(&referenced)
+	if err != nil {
+		return fmt.Errorf("failed to check blob references: %w", err)
+	}
+	if referenced {
+		return nil
+	}
+
+	if err := u.content.Delete(key); err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
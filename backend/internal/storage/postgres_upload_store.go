@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// PostgresUploadStore implements UploadStore with PostgreSQL storage
+type PostgresUploadStore struct {
+	db *sql.DB
+}
+
+// NewPostgresUploadStore creates a new PostgresUploadStore
+func NewPostgresUploadStore(db *sql.DB) (*PostgresUploadStore, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS uploads (
+			id VARCHAR(36) PRIMARY KEY,
+			type VARCHAR(50) NOT NULL,
+			length BIGINT NOT NULL,
+			upload_offset BIGINT NOT NULL DEFAULT 0,
+			metadata JSONB,
+			asset_id VARCHAR(36),
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_uploads_expires_at ON uploads (expires_at);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create uploads table: %w", err)
+	}
+
+	return &PostgresUploadStore{
+		db: db,
+	}, nil
+}
+
+// Save stores a new upload
+func (s *PostgresUploadStore) Save(upload *models.Upload) error {
+	metadataJSON, err := json.Marshal(upload.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO uploads
+		(id, type, length, upload_offset, metadata, asset_id, created_at, updated_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		upload.ID,
+		upload.Type,
+		upload.Length,
+		upload.Offset,
+		metadataJSON,
+		upload.AssetID,
+		upload.CreatedAt,
+		upload.UpdatedAt,
+		upload.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert upload: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an upload by its ID
+func (s *PostgresUploadStore) GetByID(id string) (*models.Upload, error) {
+	upload, err := scanUpload(s.db.QueryRow(
+		`SELECT `+uploadColumns+`
+		FROM uploads
+		WHERE id = $1`,
+		id,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, models.ErrUploadNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+
+	return upload, nil
+}
+
+// Update stores the offset, expiry and asset of an existing upload
+func (s *PostgresUploadStore) Update(upload *models.Upload) error {
+	upload.UpdatedAt = time.Now()
+
+	result, err := s.db.Exec(
+		`UPDATE uploads
+		SET upload_offset = $2, asset_id = $3, updated_at = $4, expires_at = $5
+		WHERE id = $1`,
+		upload.ID,
+		upload.Offset,
+		upload.AssetID,
+		upload.UpdatedAt,
+		upload.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update upload: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUploadNotFound
+	}
+
+	return nil
+}
+
+// Delete removes an upload
+func (s *PostgresUploadStore) Delete(id string) error {
+	result, err := s.db.Exec("DELETE FROM uploads WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUploadNotFound
+	}
+
+	return nil
+}
+
+// GetExpired retrieves all uploads that expired before the given time
+func (s *PostgresUploadStore) GetExpired(before time.Time) ([]*models.Upload, error) {
+	rows, err := s.db.Query(
+		`SELECT `+uploadColumns+`
+		FROM uploads
+		WHERE expires_at < $1`,
+		before,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var uploads []*models.Upload
+
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan upload row: %w", err)
+		}
+
+		uploads = append(uploads, upload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating upload rows: %w", err)
+	}
+
+	return uploads, nil
+}
+
+// uploadColumns is the column list selected by every upload query, in scanUpload order
+const uploadColumns = `id, type, length, upload_offset, metadata, asset_id, created_at, updated_at, expires_at`
+
+// scanUpload reads one upload selected with uploadColumns
+func scanUpload(row rowScanner) (*models.Upload, error) {
+	var upload models.Upload
+	var metadataJSON []byte
+
+	err := row.Scan(
+		&upload.ID,
+		&upload.Type,
+		&upload.Length,
+		&upload.Offset,
+		&metadataJSON,
+		&upload.AssetID,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+		&upload.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if metadataJSON != nil {
+		if err := json.Unmarshal(metadataJSON, &upload.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+
+	return &upload, nil
+}
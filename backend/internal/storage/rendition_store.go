@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// RenditionStore is an interface for tracking the files derived from assets
+type RenditionStore interface {
+	// Save stores a rendition, replacing any of the same kind for the asset
+	Save(rendition *models.Rendition) error
+
+	// Get retrieves the rendition of an asset of the given kind
+	Get(assetID string, kind models.RenditionKind) (*models.Rendition, error)
+
+	// GetByAssetID retrieves all renditions of an asset
+	GetByAssetID(assetID string) ([]*models.Rendition, error)
+
+	// GetAll retrieves all renditions
+	GetAll() ([]*models.Rendition, error)
+
+	// DeleteByAssetID removes all renditions of an asset
+	DeleteByAssetID(assetID string) error
+}
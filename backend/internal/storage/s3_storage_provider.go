@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3PartSize is the part size used for multipart uploads (16MB).
+// Objects smaller than this are sent with a single PutObject.
+const s3PartSize uint64 = 16 << 20
+
+// S3Config holds the connection parameters for an S3-compatible store
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+	ForcePathStyle  bool
+}
+
+// S3StorageProvider implements StorageProvider on top of any S3-compatible
+// object store (AWS S3, MinIO, ...)
+type S3StorageProvider struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3StorageProvider creates a new S3StorageProvider and makes sure the bucket exists
+func NewS3StorageProvider(cfg S3Config) (*S3StorageProvider, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+
+	// Accept both "host:port" and "http(s)://host:port" endpoints
+	endpoint := cfg.Endpoint
+	secure := cfg.UseSSL
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		endpoint = u.Host
+		secure = u.Scheme == "https"
+	}
+
+	options := &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure: secure,
+		Region: cfg.Region,
+	}
+	if cfg.ForcePathStyle {
+		options.BucketLookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(endpoint, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check S3 bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("failed to create S3 bucket: %w", err)
+		}
+	}
+
+	return &S3StorageProvider{
+		client: client,
+		bucket: cfg.Bucket,
+		prefix: strings.Trim(cfg.Prefix, "/"),
+	}, nil
+}
+
+// Save uploads content to the bucket and returns its s3:// URI.
+// Large files are sent as a multipart upload in s3PartSize chunks.
+func (s *S3StorageProvider) Save(key string, content io.Reader) (string, error) {
+	objectKey := s.keyFor(key)
+
+	// The size is only known up front for seekable content; otherwise the
+	// client buffers one part at a time
+	size := int64(-1)
+	if seeker, ok := content.(io.Seeker); ok {
+		current, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			if end, err := seeker.Seek(0, io.SeekEnd); err == nil {
+				size = end - current
+			}
+			if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+				return "", fmt.Errorf("failed to rewind file content: %w", err)
+			}
+		}
+	}
+
+	_, err := s.client.PutObject(context.Background(), s.bucket, objectKey, content, size, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+		PartSize:    s3PartSize,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file content: %w", err)
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.bucket, objectKey), nil
+}
+
+// Get retrieves content from the bucket
+func (s *S3StorageProvider) Get(key string) (io.ReadCloser, error) {
+	ctx := context.Background()
+
+	object, err := s.client.GetObject(ctx, s.bucket, s.keyFor(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file content: %w", err)
+	}
+
+	// GetObject is lazy, so stat it to surface missing objects right away
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if isS3NotFound(err) {
+			return nil, fmt.Errorf("file content not found for key: %s", key)
+		}
+		return nil, fmt.Errorf("failed to get file content: %w", err)
+	}
+
+	return object, nil
+}
+
+// GetRange reads length bytes from offset. The object issues a ranged GET
+// starting at the seek position on its first read.
+func (s *S3StorageProvider) GetRange(key string, offset, length int64) (io.ReadCloser, error) {
+	content, err := s.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	object := content.(*minio.Object)
+	if _, err := object.Seek(offset, io.SeekStart); err != nil {
+		object.Close()
+		return nil, fmt.Errorf("failed to seek file content: %w", err)
+	}
+
+	return &limitedReadCloser{
+		Reader: io.LimitReader(object, length),
+		Closer: object,
+	}, nil
+}
+
+// Delete removes content from the bucket
+func (s *S3StorageProvider) Delete(key string) error {
+	ctx := context.Background()
+	objectKey := s.keyFor(key)
+
+	// RemoveObject succeeds for missing keys, so check first to match the other providers
+	if _, err := s.client.StatObject(ctx, s.bucket, objectKey, minio.StatObjectOptions{}); err != nil {
+		if isS3NotFound(err) {
+			return fmt.Errorf("file content not found for key: %s", key)
+		}
+		return fmt.Errorf("failed to get file content: %w", err)
+	}
+
+	if err := s.client.RemoveObject(ctx, s.bucket, objectKey, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete file content: %w", err)
+	}
+
+	return nil
+}
+
+// List reports every object under the configured prefix
+func (s *S3StorageProvider) List(fn func(info ContentInfo) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listPrefix := ""
+	if s.prefix != "" {
+		listPrefix = s.prefix + "/"
+	}
+
+	objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    listPrefix,
+		Recursive: true,
+	})
+	for object := range objects {
+		if object.Err != nil {
+			return fmt.Errorf("failed to list objects: %w", object.Err)
+		}
+
+		err := fn(ContentInfo{
+			Key:     strings.TrimPrefix(object.Key, listPrefix),
+			Size:    object.Size,
+			ModTime: object.LastModified,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keyFor maps a storage key to its object key
+func (s *S3StorageProvider) keyFor(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return path.Join(s.prefix, key)
+}
+
+// isS3NotFound reports whether err means the object does not exist
+func isS3NotFound(err error) bool {
+	code := minio.ToErrorResponse(err).Code
+	return code == "NoSuchKey" || code == "NotFound"
+}
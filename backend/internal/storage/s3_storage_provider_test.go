@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+)
+
+// newTestS3Provider starts an in-process fake S3 server and returns a
+// provider that talks to it
+func newTestS3Provider(t *testing.T, prefix string) *S3StorageProvider {
+	t.Helper()
+
+	server := httptest.NewServer(unchunk(gofakes3.New(s3mem.New()).Server()))
+	t.Cleanup(server.Close)
+
+	provider, err := NewS3StorageProvider(S3Config{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "mediahub-test",
+		Prefix:          prefix,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+		ForcePathStyle:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3StorageProvider: %v", err)
+	}
+
+	return provider
+}
+
+// unchunk decodes aws-chunked request bodies before they reach the fake
+// server, which only understands them on PutObject and not on UploadPart.
+// The client signs payloads this way whenever it talks plain HTTP.
+func unchunk(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var body bytes.Buffer
+		reader := bufio.NewReader(r.Body)
+		for {
+			// Each chunk is "<hex size>;chunk-signature=<sig>\r\n<data>\r\n"
+			header, err := reader.ReadString('\n')
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var size int
+			if _, err := fmt.Sscanf(header, "%x;", &size); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(&body, reader, int64(size)); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if _, err := reader.Discard(2); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		r.Body = io.NopCloser(&body)
+		r.ContentLength = int64(body.Len())
+		r.Header.Set("Content-Length", fmt.Sprint(body.Len()))
+		r.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+		r.Header.Del("X-Amz-Decoded-Content-Length")
+		r.Header.Del("Content-Encoding")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// testContent returns size bytes of a repeating, position-dependent pattern
+func testContent(size int) []byte {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+func readAll(t *testing.T, content io.ReadCloser) []byte {
+	t.Helper()
+	defer content.Close()
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		t.Fatalf("read content: %v", err)
+	}
+	return data
+}
+
+func TestS3StorageProviderSaveAndGet(t *testing.T) {
+	provider := newTestS3Provider(t, "assets")
+
+	tests := []struct {
+		name string
+		size int
+		// Hide the size from the provider, as for a streamed upload
+		unseekable bool
+	}{
+		{name: "empty", size: 0},
+		{name: "single part", size: 1 << 10},
+		{name: "multipart", size: int(s3PartSize) + 1<<10},
+		{name: "multipart of unknown size", size: int(s3PartSize) + 1<<10, unseekable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := testContent(tt.size)
+			key := strings.ReplaceAll(tt.name, " ", "-")
+
+			var reader io.Reader = bytes.NewReader(content)
+			if tt.unseekable {
+				reader = io.MultiReader(reader)
+			}
+
+			uri, err := provider.Save(key, reader)
+			if err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if want := "s3://mediahub-test/assets/" + key; uri != want {
+				t.Errorf("Save returned %q, want %q", uri, want)
+			}
+
+			got, err := provider.Get(key)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if data := readAll(t, got); !bytes.Equal(data, content) {
+				t.Errorf("Get returned %d bytes that differ from the %d saved", len(data), len(content))
+			}
+		})
+	}
+}
+
+func TestS3StorageProviderGetMissing(t *testing.T) {
+	provider := newTestS3Provider(t, "")
+
+	if _, err := provider.Get("missing"); err == nil {
+		t.Fatal("Get of a missing key succeeded")
+	}
+}
+
+func TestS3StorageProviderGetRange(t *testing.T) {
+	provider := newTestS3Provider(t, "")
+	content := testContent(4096)
+
+	if _, err := provider.Save("ranged", bytes.NewReader(content)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		offset, length int64
+		want           []byte
+	}{
+		{name: "start", offset: 0, length: 10, want: content[:10]},
+		{name: "middle", offset: 1000, length: 500, want: content[1000:1500]},
+		{name: "end", offset: 4000, length: 96, want: content[4000:]},
+		{name: "past the end", offset: 4090, length: 100, want: content[4090:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.GetRange("ranged", tt.offset, tt.length)
+			if err != nil {
+				t.Fatalf("GetRange: %v", err)
+			}
+			if data := readAll(t, got); !bytes.Equal(data, tt.want) {
+				t.Errorf("GetRange(%d, %d) returned %d bytes, want %d matching bytes", tt.offset, tt.length, len(data), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestS3StorageProviderDelete(t *testing.T) {
+	provider := newTestS3Provider(t, "")
+
+	if _, err := provider.Save("doomed", bytes.NewReader([]byte("content"))); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := provider.Delete("doomed"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := provider.Get("doomed"); err == nil {
+		t.Error("Get succeeded after Delete")
+	}
+
+	err := provider.Delete("missing")
+	if err == nil {
+		t.Fatal("Delete of a missing key succeeded")
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Delete of a missing key returned %q, want a not found error", err)
+	}
+}
+
+func TestS3StorageProviderList(t *testing.T) {
+	provider := newTestS3Provider(t, "library")
+
+	saved := map[string]int{
+		"a/one":   10,
+		"a/two":   20,
+		"b/three": 30,
+	}
+	for key, size := range saved {
+		if _, err := provider.Save(key, bytes.NewReader(testContent(size))); err != nil {
+			t.Fatalf("Save %s: %v", key, err)
+		}
+	}
+
+	// Objects outside the prefix belong to someone else
+	other := &S3StorageProvider{client: provider.client, bucket: provider.bucket, prefix: "elsewhere"}
+	if _, err := other.Save("a/one", bytes.NewReader([]byte("other"))); err != nil {
+		t.Fatalf("Save outside prefix: %v", err)
+	}
+
+	var keys []string
+	err := provider.List(func(info ContentInfo) error {
+		keys = append(keys, info.Key)
+		if want, ok := saved[info.Key]; !ok || info.Size != int64(want) {
+			t.Errorf("List reported %s with size %d", info.Key, info.Size)
+		}
+		if info.ModTime.IsZero() {
+			t.Errorf("List reported %s without a modification time", info.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	sort.Strings(keys)
+	if got, want := strings.Join(keys, ","), "a/one,a/two,b/three"; got != want {
+		t.Errorf("List reported keys %s, want %s", got, want)
+	}
+}
+
+func TestS3StorageProviderListStopsOnError(t *testing.T) {
+	provider := newTestS3Provider(t, "")
+
+	for _, key := range []string{"one", "two"} {
+		if _, err := provider.Save(key, bytes.NewReader([]byte(key))); err != nil {
+			t.Fatalf("Save %s: %v", key, err)
+		}
+	}
+
+	stop := io.ErrUnexpectedEOF
+	calls := 0
+	err := provider.List(func(info ContentInfo) error {
+		calls++
+		return stop
+	})
+	if err != stop {
+		t.Errorf("List returned %v, want the callback's error", err)
+	}
+	if calls != 1 {
+		t.Errorf("List called back %d times after an error, want 1", calls)
+	}
+}
@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// SearchStore is an interface for the full-text index of assets. Names and
+// metadata are indexed along with the assets themselves; the extracted body
+// text of documents is indexed separately.
+type SearchStore interface {
+	// SaveText indexes the body text of an asset, replacing any indexed before
+	SaveText(assetID string, text string) error
+
+	// Search retrieves the assets matching a query, best matches first
+	Search(query *models.SearchQuery) ([]*models.SearchResult, error)
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// TagStore is an interface for managing tags and the assets they label
+type TagStore interface {
+	// GetAll retrieves all tags with the number of assets using each
+	GetAll() ([]*models.Tag, error)
+
+	// GetByID retrieves a tag by its ID
+	GetByID(id string) (*models.Tag, error)
+
+	// GetByName retrieves a tag by its normalized name
+	GetByName(name string) (*models.Tag, error)
+
+	// AddToAsset attaches tags to an asset by name. Tags that don't exist
+	// yet are created with the given IDs.
+	AddToAsset(assetID string, tags []*models.Tag) error
+
+	// RemoveFromAsset detaches a tag from an asset
+	RemoveFromAsset(assetID string, name string) error
+
+	// Rename changes the name of a tag, returning ErrTagExists when
+	// another tag has the name
+	Rename(id string, name string) error
+
+	// Merge moves the assets of one tag to another and removes the first
+	Merge(sourceID string, targetID string) error
+
+	// Delete removes a tag from every asset and from the store
+	Delete(id string) error
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// TrashStore is an interface for moving assets and folders in and out of
+// the trash. Trashed rows stay in their tables, hidden from the other
+// stores, until they are restored or purged.
+type TrashStore interface {
+	// TrashAsset records a trash item for an asset and hides the asset
+	TrashAsset(item *models.TrashItem) error
+
+	// TrashFolder records a trash item for a folder and hides the folder
+	// with every subfolder and asset in it
+	TrashFolder(item *models.TrashItem) error
+
+	// GetByID retrieves a trash item by its ID
+	GetByID(id string) (*models.TrashItem, error)
+
+	// GetAll retrieves all trash items, most recently deleted first
+	GetAll() ([]*models.TrashItem, error)
+
+	// GetDeletedBefore retrieves the trash items deleted before a time
+	GetDeletedBefore(before time.Time) ([]*models.TrashItem, error)
+
+	// GetAssets retrieves the assets hidden by a trash item
+	GetAssets(id string) ([]*models.Asset, error)
+
+	// Restore brings back everything hidden by a trash item, putting the
+	// item itself in the given folder, and removes the trash item
+	Restore(item *models.TrashItem, folderID *string) error
+
+	// Purge permanently removes the folders of a trash item and the trash
+	// item. Its assets must have been deleted first.
+	Purge(id string) error
+
+	// DeleteAssetItem removes the trash item of an asset that was trashed
+	// on its own, if there is one, after the asset was deleted elsewhere
+	DeleteAssetItem(assetID string) error
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"database/sql"
+	"io"
+	"log"
+)
+
+// DBTX is the subset of *sql.DB and *sql.Tx used by the Postgres stores,
+// so the same store code can run inside or outside a transaction
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// UnitOfWork runs a function against a set of stores that commit or roll back together
+type UnitOfWork interface {
+	// Do calls fn with stores bound to a new unit of work. The work is committed
+	// if fn returns nil and rolled back otherwise.
+	Do(fn func(tx *Tx) error) error
+}
+
+// TransactionalStorageProvider is implemented by storage providers that can
+// take part in a database transaction
+type TransactionalStorageProvider interface {
+	StorageProvider
+
+	// WithTx returns a provider that runs its queries inside tx
+	WithTx(tx *sql.Tx) StorageProvider
+}
+
+// Tx holds the stores bound to a single unit of work
+type Tx struct {
+	Assets      AssetStore
+	Folders     FolderStore
+	Blobs       BlobStore
+	Renditions  RenditionStore
+	Jobs        JobStore
+	Collections CollectionStore
+	Trash       TrashStore
+	Content     StorageProvider
+
+	onCommit   []func()
+	onRollback []func()
+}
+
+// OnCommit registers fn to run after the unit of work commits
+func (tx *Tx) OnCommit(fn func()) {
+	tx.onCommit = append(tx.onCommit, fn)
+}
+
+// OnRollback registers fn to run after the unit of work rolls back
+func (tx *Tx) OnRollback(fn func()) {
+	tx.onRollback = append(tx.onRollback, fn)
+}
+
+// committed runs the commit hooks in registration order
+func (tx *Tx) committed() {
+	for _, fn := range tx.onCommit {
+		fn()
+	}
+}
+
+// rolledBack runs the rollback hooks in reverse registration order
+func (tx *Tx) rolledBack() {
+	for i := len(tx.onRollback) - 1; i >= 0; i-- {
+		tx.onRollback[i]()
+	}
+}
+
+// compensatingStorageProvider wraps a non-transactional provider for use in a Tx.
+// Saved content is removed again if the unit of work rolls back, and deletes
+// are postponed until it commits, so a rollback never loses content.
+type compensatingStorageProvider struct {
+	provider StorageProvider
+	tx       *Tx
+
+	// remove performs a postponed delete once the unit of work has committed
+	remove func(key string) error
+}
+
+// Save stores the content right away and schedules its removal on rollback
+func (c *compensatingStorageProvider) Save(key string, content io.Reader) (string, error) {
+	path, err := c.provider.Save(key, content)
+	if err != nil {
+		return "", err
+	}
+
+	c.tx.OnRollback(func() {
+		if err := c.provider.Delete(key); err != nil {
+			log.Printf("Failed to remove content %s after rollback: %v", key, err)
+		}
+	})
+
+	return path, nil
+}
+
+// Get reads straight from the wrapped provider
+func (c *compensatingStorageProvider) Get(key string) (io.ReadCloser, error) {
+	return c.provider.Get(key)
+}
+
+// Delete schedules the removal for when the unit of work commits
+func (c *compensatingStorageProvider) Delete(key string) error {
+	c.tx.OnCommit(func() {
+		if err := c.remove(key); err != nil {
+			log.Printf("Failed to remove content %s after commit: %v", key, err)
+		}
+	})
+
+	return nil
+}
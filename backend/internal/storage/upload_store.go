@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/SaadBeidourii/MediaHub.git/internal/models"
+)
+
+// UploadStore is an interface for tracking resumable uploads
+type UploadStore interface {
+	// Save stores a new upload
+	Save(upload *models.Upload) error
+
+	// GetByID retrieves an upload by its ID
+	GetByID(id string) (*models.Upload, error)
+
+	// Update stores the offset, expiry and asset of an existing upload
+	Update(upload *models.Upload) error
+
+	// Delete removes an upload
+	Delete(id string) error
+
+	// GetExpired retrieves all uploads that expired before the given time
+	GetExpired(before time.Time) ([]*models.Upload, error)
+}
@@ -0,0 +1,214 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Container formats recognized by Read
+const (
+	FormatMP3  = "mp3"
+	FormatFLAC = "flac"
+	FormatOgg  = "ogg"
+	FormatMP4  = "mp4"
+	FormatWAV  = "wav"
+)
+
+// maxBlockSize caps how much of a single tag, block or box is read
+const maxBlockSize = 16 << 20
+
+// PictureFrontCover is the picture type of a front cover, numbered as in
+// ID3v2 APIC frames and FLAC PICTURE blocks
+const PictureFrontCover = 3
+
+var (
+	// ErrUnsupportedFormat is returned for audio that none of the parsers understand
+	ErrUnsupportedFormat = errors.New("unsupported audio format")
+)
+
+// Metadata holds the tags and stream properties of an audio file
+type Metadata struct {
+	Format      string
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Genre       string
+	Year        int
+	Track       int
+	TrackTotal  int
+	Disc        int
+	DiscTotal   int
+	Duration    time.Duration
+	Bitrate     int // Average bits per second
+	SampleRate  int
+	Channels    int
+	Picture     *Picture // Embedded artwork, preferably the front cover
+}
+
+// Picture is artwork embedded in the tags
+type Picture struct {
+	MIMEType string // As declared by the tag, which may be empty or wrong
+	Type     int
+	Data     []byte
+}
+
+// Read parses the tags and stream headers of an MP3, FLAC, Ogg Vorbis or
+// Opus, MP4/M4A or WAV file. The duration comes from headers such as
+// Xing/VBRI, STREAMINFO or mvhd, the audio itself is never decoded.
+func Read(r io.ReaderAt, size int64) (*Metadata, error) {
+	head := make([]byte, 12)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read audio header: %w", err)
+	}
+	head = head[:n]
+
+	meta := &Metadata{}
+	switch {
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		meta.Format = FormatFLAC
+		err = readFLAC(r, 0, size, meta)
+	case bytes.HasPrefix(head, []byte("OggS")):
+		meta.Format = FormatOgg
+		err = readOgg(r, size, meta)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		meta.Format = FormatMP4
+		err = readMP4(r, size, meta)
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		meta.Format = FormatWAV
+		err = readWAV(r, size, meta)
+	case bytes.HasPrefix(head, []byte("ID3")) || isFrameSync(head):
+		err = readTagged(r, size, meta)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+// readTagged reads a file starting with ID3v2 tags, which is usually an
+// MP3 but may also be a FLAC stream some taggers prefixed with ID3
+func readTagged(r io.ReaderAt, size int64, meta *Metadata) error {
+	// Several tags may be stacked at the start of the file
+	offset := int64(0)
+	for offset < size {
+		length, err := readID3v2(r, offset, size, meta)
+		if err != nil {
+			return err
+		}
+		if length == 0 {
+			break
+		}
+		offset += length
+	}
+
+	magic := make([]byte, 4)
+	if _, err := r.ReadAt(magic, offset); err == nil && string(magic) == "fLaC" {
+		meta.Format = FormatFLAC
+		return readFLAC(r, offset, size, meta)
+	}
+
+	meta.Format = FormatMP3
+	end := size
+	if readID3v1(r, size, meta) {
+		end -= id3v1Size
+	}
+	return readMPEG(r, offset, end, meta)
+}
+
+// readAt reads length bytes at offset, refusing lengths over maxBlockSize
+func readAt(r io.ReaderAt, offset int64, length int64) ([]byte, error) {
+	if length < 0 || length > maxBlockSize {
+		return nil, fmt.Errorf("block of %d bytes at offset %d is too large", length, offset)
+	}
+
+	data := make([]byte, length)
+	n, err := r.ReadAt(data, offset)
+	if int64(n) < length {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("failed to read %d bytes at offset %d: %w", length, offset, err)
+	}
+	return data, nil
+}
+
+// setDuration sets the duration from a sample count and rate
+func (m *Metadata) setDuration(samples int64, rate int) {
+	if samples > 0 && rate > 0 {
+		m.Duration = time.Duration(float64(samples) / float64(rate) * float64(time.Second))
+	}
+}
+
+// setAverageBitrate derives the bitrate from the size of the audio data
+func (m *Metadata) setAverageBitrate(audioBytes int64) {
+	if seconds := m.Duration.Seconds(); seconds > 0 && audioBytes > 0 {
+		m.Bitrate = int(float64(audioBytes) * 8 / seconds)
+	}
+}
+
+// addPicture keeps the first picture found unless a front cover comes later
+func (m *Metadata) addPicture(picture *Picture) {
+	if picture == nil || len(picture.Data) == 0 {
+		return
+	}
+	if m.Picture == nil || (m.Picture.Type != PictureFrontCover && picture.Type == PictureFrontCover) {
+		m.Picture = picture
+	}
+}
+
+// fill sets an empty string field, so tags read first take precedence
+func fill(field *string, value string) {
+	if *field == "" {
+		*field = strings.TrimSpace(value)
+	}
+}
+
+// fillNumber sets a zero number field
+func fillNumber(field *int, value int) {
+	if *field == 0 {
+		*field = value
+	}
+}
+
+// parsePosition parses a track or disc position such as "3" or "3/12"
+func parsePosition(s string) (int, int) {
+	number, total, _ := strings.Cut(strings.TrimSpace(s), "/")
+	n, _ := strconv.Atoi(strings.TrimSpace(number))
+	t, _ := strconv.Atoi(strings.TrimSpace(total))
+	return n, t
+}
+
+// parseYear reads the year from a date such as "2004" or "2004-05-06T10:00"
+func parseYear(s string) int {
+	s = strings.TrimSpace(s)
+	if len(s) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(s[:4])
+	if err != nil || year <= 0 {
+		return 0
+	}
+	return year
+}
+
+// latin1 decodes ISO-8859-1 text, stopping at the first NUL
+func latin1(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return strings.TrimSpace(string(runes))
+}
@@ -0,0 +1,370 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// png is the image data the fixtures embed as artwork
+var png = []byte("\x89PNG\r\n\x1a\nfakeimage")
+
+// readFixture reads a file from testdata
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return data
+}
+
+func TestRead(t *testing.T) {
+	cover := &Picture{MIMEType: "image/png", Type: PictureFrontCover, Data: png}
+
+	tests := []struct {
+		fixture string
+		want    Metadata
+	}{
+		{
+			// The ID3v1 tag at the end only fills fields ID3v2 left empty
+			fixture: "id3v23.mp3",
+			want: Metadata{
+				Format: FormatMP3, Title: "Song Title", Artist: "Artïst", Album: "The Album",
+				AlbumArtist: "Album Artist", Genre: "Rock", Year: 2004,
+				Track: 3, TrackTotal: 12, Disc: 1, DiscTotal: 2,
+				// Ten constant bitrate frames of 417 bytes at 128 kbit/s
+				Duration: 260625 * time.Microsecond, Bitrate: 128000, SampleRate: 44100, Channels: 2,
+				Picture: cover,
+			},
+		},
+		{
+			// TLEN is replaced by the duration the frames give
+			fixture: "id3v24.mp3",
+			want: Metadata{
+				Format: FormatMP3, Title: "Überschrift", Artist: "One; Two", Genre: "Rock; Jazz", Year: 2019,
+				Duration: 260625 * time.Microsecond, Bitrate: 128000, SampleRate: 44100, Channels: 2,
+				Picture: &Picture{MIMEType: "image/jpeg", Type: 0, Data: []byte("back")},
+			},
+		},
+		{
+			fixture: "id3v22.mp3",
+			want: Metadata{
+				Format: FormatMP3, Title: "Old Style", Artist: "Legacy", Track: 5,
+				Duration: 260625 * time.Microsecond, Bitrate: 128000, SampleRate: 44100, Channels: 2,
+				Picture: cover,
+			},
+		},
+		{
+			fixture: "id3v1.mp3",
+			want: Metadata{
+				Format: FormatMP3, Title: "V1 Title", Artist: "V1 Artist", Album: "V1 Album",
+				Genre: "Rock", Year: 1987, Track: 7,
+				Duration: 260625 * time.Microsecond, Bitrate: 128000, SampleRate: 44100, Channels: 2,
+			},
+		},
+		{
+			// 1000 frames of 1152 samples at 48 kHz in 3,000,000 bytes
+			fixture: "xing.mp3",
+			want: Metadata{
+				Format: FormatMP3, Duration: 24 * time.Second, Bitrate: 1000000, SampleRate: 48000, Channels: 2,
+			},
+		},
+		{
+			// 500 frames of 1152 samples at 48 kHz in 600,000 bytes
+			fixture: "vbri.mp3",
+			want: Metadata{
+				Format: FormatMP3, Duration: 12 * time.Second, Bitrate: 400000, SampleRate: 48000, Channels: 2,
+			},
+		},
+		{
+			// STREAMINFO holds 441,000 samples at 44.1 kHz, followed by
+			// 1000 bytes of audio. The front cover wins over the back
+			// cover before it.
+			fixture: "tagged.flac",
+			want: Metadata{
+				Format: FormatFLAC, Title: "Flac Title", Artist: "First; Second", Album: "Flac Album",
+				Genre: "Ambient", Year: 2010, Track: 2, TrackTotal: 9, Disc: 1, DiscTotal: 2,
+				Duration: 10 * time.Second, Bitrate: 800, SampleRate: 44100, Channels: 2,
+				Picture: cover,
+			},
+		},
+		{
+			fixture: "id3.flac",
+			want: Metadata{
+				Format: FormatFLAC, Title: "ID3 Title",
+				Duration: 2 * time.Second, Bitrate: 400, SampleRate: 48000, Channels: 1,
+			},
+		},
+		{
+			// The comment header spans several segments, and the granule
+			// position of the last page counts 441,000 samples
+			fixture: "vorbis.ogg",
+			want: Metadata{
+				Format: FormatOgg, Title: "Ogg Title", Artist: "Ogg Artist", AlbumArtist: "Ogg Band",
+				Year: 2015, Track: 4, TrackTotal: 10,
+				Duration: 10 * time.Second, Bitrate: 128000, SampleRate: 44100, Channels: 2,
+				Picture: cover,
+			},
+		},
+		{
+			// Opus granules run at 48 kHz less the pre-skip, and a page
+			// of another stream at the end is ignored
+			fixture: "opus.ogg",
+			want: Metadata{
+				Format: FormatOgg, Title: "Opus Title",
+				Duration: 5 * time.Second, Bitrate: 344 * 8 / 5, SampleRate: 44100, Channels: 2,
+				Picture: &Picture{MIMEType: "image/png", Data: png},
+			},
+		},
+		{
+			// mvhd gives 5000 units of a 1000 per second timescale
+			fixture: "itunes.m4a",
+			want: Metadata{
+				Format: FormatMP4, Title: "Mp4 Title", Artist: "Mp4 Artist", Album: "Mp4 Album",
+				AlbumArtist: "Mp4 Band", Genre: "Rock", Year: 2021, Track: 6, TrackTotal: 14, Disc: 2, DiscTotal: 3,
+				Duration: 5 * time.Second, Bitrate: 1600, SampleRate: 44100, Channels: 2,
+				Picture: cover,
+			},
+		},
+		{
+			// A version 1 mvhd with a 64 bit duration and a QuickTime
+			// meta box without the full box header
+			fixture: "quicktime.m4a",
+			want: Metadata{
+				Format: FormatMP4, Title: "QT Title",
+				Duration: 3 * time.Second, Bitrate: 1600, SampleRate: 48000, Channels: 1,
+			},
+		},
+		{
+			// 1600 bytes of data at 32,000 bytes per second
+			fixture: "info.wav",
+			want: Metadata{
+				Format: FormatWAV, Title: "Wav Title", Artist: "Wav Artist", Year: 2001, Track: 8, TrackTotal: 9,
+				Duration: 50 * time.Millisecond, Bitrate: 256000, SampleRate: 8000, Channels: 2,
+			},
+		},
+		{
+			// An odd sized data chunk is padded before the ID3v2 chunk
+			fixture: "id3.wav",
+			want: Metadata{
+				Format: FormatWAV, Title: "Chunk Title", Artist: "Chunk Artist",
+				Duration: 50125 * time.Microsecond, Bitrate: 64000, SampleRate: 8000, Channels: 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data := readFixture(t, tt.fixture)
+			got, err := Read(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			if (got.Picture == nil) != (tt.want.Picture == nil) ||
+				got.Picture != nil && (got.Picture.MIMEType != tt.want.Picture.MIMEType ||
+					got.Picture.Type != tt.want.Picture.Type || !bytes.Equal(got.Picture.Data, tt.want.Picture.Data)) {
+				t.Errorf("Picture = %+v, want %+v", got.Picture, tt.want.Picture)
+			}
+			got.Picture, tt.want.Picture = nil, nil
+
+			if *got != tt.want {
+				t.Errorf("Read() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    error
+		message string
+	}{
+		{name: "empty", data: "", want: ErrUnsupportedFormat},
+		{name: "text", data: "just some text", want: ErrUnsupportedFormat},
+		{name: "flac without streaminfo", data: "fLaC\x81\x00\x00\x04\x00\x00\x00\x00", message: "no STREAMINFO"},
+		{name: "mp4 without moov", data: "\x00\x00\x00\x10ftypM4A \x00\x00\x00\x00", message: "no moov"},
+		{name: "wav without fmt", data: "RIFF\x00\x00\x00\x00WAVEdata\x00\x00\x00\x00", message: "no fmt"},
+		{name: "mp3 without frames", data: "ID3\x03\x00\x00\x00\x00\x00\x00" + strings.Repeat("\x00", 64), message: "no mpeg audio frames"},
+		{name: "ogg missing headers", data: "OggS\x00\x02" + strings.Repeat("\x00", 20) + "\x01\x00", message: "missing its header packets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.data), int64(len(tt.data)))
+			switch {
+			case err == nil:
+				t.Fatal("Read succeeded")
+			case tt.want != nil && !errors.Is(err, tt.want):
+				t.Errorf("Read returned %v, want %v", err, tt.want)
+			case tt.message != "" && !strings.Contains(err.Error(), tt.message):
+				t.Errorf("Read returned %q, want an error containing %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestWaveform(t *testing.T) {
+	tests := []struct {
+		fixture string
+		points  int
+		want    []float64
+	}{
+		// The first half peaks at half scale, the second at full scale
+		{fixture: "info.wav", points: 2, want: []float64{0.5, 1}},
+		{fixture: "info.wav", points: 4, want: []float64{0.5, 0.5, 1, 1}},
+		{fixture: "info.wav", points: 0, want: []float64{}},
+		// Unsigned 8 bit samples of 128, 255, 0 and 192 repeated
+		{fixture: "id3.wav", points: 1, want: []float64{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data := readFixture(t, tt.fixture)
+			got, err := Waveform(bytes.NewReader(data), int64(len(data)), tt.points)
+			if err != nil {
+				t.Fatalf("Waveform: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Waveform(%d) = %v, want %v", tt.points, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Waveform(%d) = %v, want %v", tt.points, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestWaveformUnsupported(t *testing.T) {
+	for _, fixture := range []string{"id3v23.mp3", "tagged.flac"} {
+		data := readFixture(t, fixture)
+		if _, err := Waveform(bytes.NewReader(data), int64(len(data)), 10); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("Waveform(%s) returned %v, want ErrUnsupportedFormat", fixture, err)
+		}
+	}
+}
+
+func TestSampleDecoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		tag    uint16
+		bits   int
+		sample []byte
+		want   float64
+	}{
+		{name: "8 bit silence", tag: wavePCM, bits: 8, sample: []byte{0x80}, want: 0},
+		{name: "8 bit minimum", tag: wavePCM, bits: 8, sample: []byte{0x00}, want: -1},
+		{name: "16 bit", tag: wavePCM, bits: 16, sample: []byte{0x00, 0x40}, want: 0.5},
+		{name: "24 bit negative", tag: wavePCM, bits: 24, sample: []byte{0x00, 0x00, 0xc0}, want: -0.5},
+		{name: "32 bit", tag: wavePCM, bits: 32, sample: []byte{0x00, 0x00, 0x00, 0x40}, want: 0.5},
+		{name: "32 bit float", tag: waveFloat, bits: 32, sample: []byte{0x00, 0x00, 0x00, 0x3f}, want: 0.5},
+		{name: "64 bit float", tag: waveFloat, bits: 64, sample: []byte{0, 0, 0, 0, 0, 0, 0xe0, 0xbf}, want: -0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decode := sampleDecoder(tt.tag, tt.bits)
+			if decode == nil {
+				t.Fatal("sampleDecoder returned nil")
+			}
+			if got := decode(tt.sample); got != tt.want {
+				t.Errorf("sample = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if sampleDecoder(wavePCM, 12) != nil {
+		t.Error("sampleDecoder accepted 12 bit samples")
+	}
+}
+
+func TestParseGenre(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "17", want: "Rock"},
+		{in: "(17)", want: "Rock"},
+		{in: "(17)Hard Stuff", want: "Hard Stuff"},
+		{in: "(RX)", want: "Remix"},
+		{in: "(CR)", want: "Cover"},
+		// A doubled parenthesis escapes one in the text
+		{in: "((Parenthesised)", want: "(Parenthesised)"},
+		{in: "Jazz", want: "Jazz"},
+		{in: "(999)", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseGenre(tt.in); got != tt.want {
+			t.Errorf("parseGenre(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		in            string
+		number, total int
+	}{
+		{in: "3", number: 3},
+		{in: "3/12", number: 3, total: 12},
+		{in: " 4 / 5 ", number: 4, total: 5},
+		{in: "/7", total: 7},
+		{in: "side A", number: 0},
+	}
+
+	for _, tt := range tests {
+		if number, total := parsePosition(tt.in); number != tt.number || total != tt.total {
+			t.Errorf("parsePosition(%q) = %d, %d, want %d, %d", tt.in, number, total, tt.number, tt.total)
+		}
+	}
+}
+
+// addFixtures seeds a fuzz target with every file in testdata
+func addFixtures(f *testing.F) {
+	fixtures, _ := filepath.Glob(filepath.Join("testdata", "*"))
+	for _, fixture := range fixtures {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			f.Fatalf("read fixture: %v", err)
+		}
+		f.Add(data)
+	}
+}
+
+// FuzzRead checks that no input makes reading the tags panic or hang
+func FuzzRead(f *testing.F) {
+	addFixtures(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Read(bytes.NewReader(data), int64(len(data)))
+	})
+}
+
+// FuzzWaveform checks that no input makes computing a waveform panic or
+// return peaks outside [0, 1]
+func FuzzWaveform(f *testing.F) {
+	addFixtures(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		peaks, err := Waveform(bytes.NewReader(data), int64(len(data)), 16)
+		if err != nil {
+			return
+		}
+		for _, peak := range peaks {
+			if peak < 0 || peak > 1 {
+				t.Fatalf("peak %v is out of range", peak)
+			}
+		}
+	})
+}
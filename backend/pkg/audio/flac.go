@@ -0,0 +1,149 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+// FLAC metadata block types
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+	flacPicture       = 6
+)
+
+// readFLAC reads the metadata blocks of a FLAC stream whose "fLaC" marker
+// is at offset. STREAMINFO gives the sample count, so the duration needs
+// no decoding.
+func readFLAC(r io.ReaderAt, offset int64, size int64, meta *Metadata) error {
+	pos := offset + 4
+	haveStreamInfo := false
+
+	for {
+		header := make([]byte, 4)
+		if _, err := r.ReadAt(header, pos); err != nil {
+			return errors.New("flac metadata blocks are truncated")
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		pos += 4
+
+		switch blockType {
+		case flacStreamInfo:
+			block, err := readAt(r, pos, length)
+			if err != nil {
+				return err
+			}
+			if err := parseStreamInfo(block, meta); err != nil {
+				return err
+			}
+			haveStreamInfo = true
+		case flacVorbisComment:
+			block, err := readAt(r, pos, length)
+			if err != nil {
+				return err
+			}
+			comments, err := parseVorbisComments(block)
+			if err != nil {
+				return err
+			}
+			comments.apply(meta)
+		case flacPicture:
+			block, err := readAt(r, pos, length)
+			if err != nil {
+				return err
+			}
+			// A malformed picture should not hide the stream properties
+			if picture, err := parseFLACPicture(block); err == nil {
+				meta.addPicture(picture)
+			}
+		}
+
+		pos += length
+		if last || pos >= size {
+			break
+		}
+	}
+
+	if !haveStreamInfo {
+		return errors.New("flac stream has no STREAMINFO block")
+	}
+
+	meta.setAverageBitrate(size - pos)
+	return nil
+}
+
+// parseStreamInfo reads the sample rate, channels and sample count
+func parseStreamInfo(block []byte, meta *Metadata) error {
+	if len(block) < 18 {
+		return errors.New("flac STREAMINFO block is too short")
+	}
+
+	meta.SampleRate = int(block[10])<<12 | int(block[11])<<4 | int(block[12])>>4
+	meta.Channels = int(block[12]>>1&0x07) + 1
+	samples := int64(block[13]&0x0f)<<32 | int64(block[14])<<24 | int64(block[15])<<16 |
+		int64(block[16])<<8 | int64(block[17])
+	meta.setDuration(samples, meta.SampleRate)
+
+	return nil
+}
+
+// parseFLACPicture reads a PICTURE block, which Ogg streams also embed
+// base64 encoded in a METADATA_BLOCK_PICTURE comment
+func parseFLACPicture(block []byte) (*Picture, error) {
+	errTruncated := errors.New("flac PICTURE block is truncated")
+
+	next := func(length uint64) ([]byte, error) {
+		if length > uint64(len(block)) {
+			return nil, errTruncated
+		}
+		value := block[:length]
+		block = block[length:]
+		return value, nil
+	}
+	field := func() (uint32, error) {
+		value, err := next(4)
+		if err != nil {
+			return 0, err
+		}
+		return binary.BigEndian.Uint32(value), nil
+	}
+
+	pictureType, err := field()
+	if err != nil {
+		return nil, err
+	}
+	mimeLength, err := field()
+	if err != nil {
+		return nil, err
+	}
+	mimeType, err := next(uint64(mimeLength))
+	if err != nil {
+		return nil, err
+	}
+	descriptionLength, err := field()
+	if err != nil {
+		return nil, err
+	}
+	// Description, then width, height, colour depth and palette size
+	if _, err := next(uint64(descriptionLength) + 16); err != nil {
+		return nil, err
+	}
+	dataLength, err := field()
+	if err != nil {
+		return nil, err
+	}
+	data, err := next(uint64(dataLength))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Picture{
+		MIMEType: strings.ToLower(string(mimeType)),
+		Type:     int(pictureType),
+		Data:     data,
+	}, nil
+}
@@ -0,0 +1,459 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// id3v1Size is the size of the ID3v1 tag at the end of a file
+const id3v1Size = 128
+
+// id3v22Frames maps the three character frame IDs of ID3v2.2 to their
+// ID3v2.3 equivalents
+var id3v22Frames = map[string]string{
+	"TT2": "TIT2",
+	"TP1": "TPE1",
+	"TP2": "TPE2",
+	"TAL": "TALB",
+	"TCO": "TCON",
+	"TRK": "TRCK",
+	"TPA": "TPOS",
+	"TYE": "TYER",
+	"TOR": "TORY",
+	"TLE": "TLEN",
+}
+
+// readID3v2 reads an ID3v2 tag at offset and returns its length, or zero
+// if there is no tag there
+func readID3v2(r io.ReaderAt, offset int64, size int64, meta *Metadata) (int64, error) {
+	header := make([]byte, 10)
+	if _, err := r.ReadAt(header, offset); err != nil || string(header[:3]) != "ID3" {
+		return 0, nil
+	}
+
+	major := header[3]
+	flags := header[5]
+	tagSize := syncsafe(header[6:10])
+	length := 10 + tagSize
+	if flags&0x10 != 0 {
+		length += 10 // Footer
+	}
+	if offset+length > size {
+		return 0, nil
+	}
+
+	// Unknown versions are skipped over rather than parsed
+	if major < 2 || major > 4 {
+		return length, nil
+	}
+
+	body, err := readAt(r, offset+10, tagSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// Before version 4 unsynchronisation applies to the whole tag
+	if major < 4 && flags&0x80 != 0 {
+		body = unsynchronise(body)
+	}
+
+	if flags&0x40 != 0 && len(body) >= 4 {
+		var extended int64
+		if major == 3 {
+			extended = 4 + int64(binary.BigEndian.Uint32(body[:4]))
+		} else {
+			extended = syncsafe(body[:4])
+		}
+		if extended > int64(len(body)) {
+			return length, nil
+		}
+		body = body[extended:]
+	}
+
+	for _, frame := range id3Frames(body, major) {
+		applyID3Frame(frame.id, frame.data, meta)
+	}
+
+	return length, nil
+}
+
+// id3Frame is a frame with its flags already handled
+type id3Frame struct {
+	id   string
+	data []byte
+}
+
+// id3Frames splits the frames of a tag body
+func id3Frames(body []byte, major byte) []id3Frame {
+	idLength, headerLength := 4, 10
+	if major == 2 {
+		idLength, headerLength = 3, 6
+	}
+
+	var frames []id3Frame
+	for len(body) >= headerLength && body[0] != 0 {
+		id := string(body[:idLength])
+
+		var frameSize int64
+		switch major {
+		case 2:
+			frameSize = int64(body[3])<<16 | int64(body[4])<<8 | int64(body[5])
+		case 3:
+			frameSize = int64(binary.BigEndian.Uint32(body[4:8]))
+		default:
+			// Some writers put plain sizes in version 4 tags
+			frameSize = syncsafe(body[4:8])
+			if body[4]|body[5]|body[6]|body[7] >= 0x80 {
+				frameSize = int64(binary.BigEndian.Uint32(body[4:8]))
+			}
+		}
+		if frameSize > int64(len(body)-headerLength) {
+			break
+		}
+
+		data := body[headerLength : headerLength+int(frameSize)]
+		var formatFlags byte
+		if major > 2 {
+			formatFlags = body[9]
+		}
+		body = body[headerLength+int(frameSize):]
+
+		if major == 2 {
+			if mapped, ok := id3v22Frames[id]; ok {
+				id = mapped
+			}
+		}
+
+		data, ok := frameData(data, formatFlags, major)
+		if ok {
+			frames = append(frames, id3Frame{id: id, data: data})
+		}
+	}
+
+	return frames
+}
+
+// frameData undoes the per frame format flags, reporting false for
+// compressed or encrypted frames
+func frameData(data []byte, flags byte, major byte) ([]byte, bool) {
+	switch major {
+	case 3:
+		if flags&0xc0 != 0 {
+			return nil, false
+		}
+		if flags&0x20 != 0 && len(data) > 0 {
+			data = data[1:] // Group identifier
+		}
+	case 4:
+		if flags&0x0c != 0 {
+			return nil, false
+		}
+		if flags&0x40 != 0 && len(data) > 0 {
+			data = data[1:] // Group identifier
+		}
+		if flags&0x01 != 0 && len(data) >= 4 {
+			data = data[4:] // Data length indicator
+		}
+		if flags&0x02 != 0 {
+			data = unsynchronise(data)
+		}
+	}
+	return data, true
+}
+
+// applyID3Frame stores the value of a frame the metadata cares about
+func applyID3Frame(id string, data []byte, meta *Metadata) {
+	if id == "APIC" || id == "PIC" {
+		meta.addPicture(id3Picture(data, id == "PIC"))
+		return
+	}
+	if !strings.HasPrefix(id, "T") || len(data) == 0 {
+		return
+	}
+	values := id3Text(data)
+	if len(values) == 0 {
+		return
+	}
+	text := strings.Join(values, "; ")
+
+	switch id {
+	case "TIT2":
+		fill(&meta.Title, text)
+	case "TPE1":
+		fill(&meta.Artist, text)
+	case "TPE2":
+		fill(&meta.AlbumArtist, text)
+	case "TALB":
+		fill(&meta.Album, text)
+	case "TCON":
+		genres := make([]string, 0, len(values))
+		for _, value := range values {
+			if genre := parseGenre(value); genre != "" {
+				genres = append(genres, genre)
+			}
+		}
+		fill(&meta.Genre, strings.Join(genres, "; "))
+	case "TRCK":
+		track, total := parsePosition(values[0])
+		fillNumber(&meta.Track, track)
+		fillNumber(&meta.TrackTotal, total)
+	case "TPOS":
+		disc, total := parsePosition(values[0])
+		fillNumber(&meta.Disc, disc)
+		fillNumber(&meta.DiscTotal, total)
+	case "TYER", "TDRC", "TORY", "TDOR":
+		fillNumber(&meta.Year, parseYear(values[0]))
+	case "TLEN":
+		// Only a fallback, the stream headers are read afterwards
+		if ms, err := strconv.ParseInt(strings.TrimSpace(values[0]), 10, 64); err == nil && meta.Duration == 0 {
+			meta.Duration = time.Duration(ms) * time.Millisecond
+		}
+	}
+}
+
+// id3Picture reads an APIC frame, or a PIC frame of ID3v2.2 which has a
+// three letter image format instead of a MIME type
+func id3Picture(data []byte, v22 bool) *Picture {
+	if len(data) < 2 {
+		return nil
+	}
+	encoding, body := data[0], data[1:]
+
+	var mimeType string
+	if v22 {
+		if len(body) < 3 {
+			return nil
+		}
+		switch strings.ToUpper(string(body[:3])) {
+		case "JPG":
+			mimeType = "image/jpeg"
+		case "PNG":
+			mimeType = "image/png"
+		case "-->":
+			// The picture is only linked to by URL
+			return nil
+		}
+		body = body[3:]
+	} else {
+		end := bytes.IndexByte(body, 0)
+		if end < 0 {
+			return nil
+		}
+		mimeType = strings.ToLower(latin1(body[:end]))
+		if mimeType == "-->" {
+			return nil
+		}
+		body = body[end+1:]
+	}
+
+	if len(body) < 1 {
+		return nil
+	}
+	pictureType := int(body[0])
+	body = body[1:]
+
+	// Skip the description, terminated by a NUL of the frame's encoding
+	if encoding == 1 || encoding == 2 {
+		end := -1
+		for i := 0; i+1 < len(body); i += 2 {
+			if body[i] == 0 && body[i+1] == 0 {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return nil
+		}
+		body = body[end+2:]
+	} else {
+		end := bytes.IndexByte(body, 0)
+		if end < 0 {
+			return nil
+		}
+		body = body[end+1:]
+	}
+
+	return &Picture{MIMEType: mimeType, Type: pictureType, Data: body}
+}
+
+// id3Text decodes the values of a text frame, which version 4 separates
+// with NUL characters
+func id3Text(data []byte) []string {
+	encoding, body := data[0], data[1:]
+
+	var text string
+	switch encoding {
+	case 0:
+		runes := make([]rune, len(body))
+		for i, c := range body {
+			runes[i] = rune(c)
+		}
+		text = string(runes)
+	case 1, 2:
+		text = decodeUTF16(body, encoding == 2)
+	case 3:
+		text = strings.ToValidUTF8(string(body), "�")
+	default:
+		return nil
+	}
+
+	var values []string
+	for _, value := range strings.Split(text, "\x00") {
+		// Each UTF-16 value may carry its own byte order mark
+		value = strings.TrimSpace(strings.TrimPrefix(value, "\ufeff"))
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// decodeUTF16 decodes UTF-16 text with an optional byte order mark
+// followed by more values, each of which may have its own mark
+func decodeUTF16(b []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(b)/2)
+	order := binary.ByteOrder(binary.LittleEndian)
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	for i := 0; i+1 < len(b); i += 2 {
+		switch {
+		case b[i] == 0xff && b[i+1] == 0xfe:
+			order = binary.LittleEndian
+			continue
+		case b[i] == 0xfe && b[i+1] == 0xff:
+			order = binary.BigEndian
+			continue
+		}
+		units = append(units, order.Uint16(b[i:i+2]))
+	}
+
+	return string(utf16.Decode(units))
+}
+
+// parseGenre resolves ID3v1 genre references, written as "(17)", "17" or
+// "(17)Rock" in ID3v2.3 and as "17" in ID3v2.4
+func parseGenre(s string) string {
+	s = strings.TrimSpace(s)
+
+	var reference string
+	for strings.HasPrefix(s, "(") && !strings.HasPrefix(s, "((") {
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			break
+		}
+		if reference == "" {
+			reference = s[1:end]
+		}
+		s = s[end+1:]
+	}
+	// Refinements in text take precedence over the references
+	if s = strings.TrimPrefix(strings.TrimSpace(s), "("); s != "" {
+		if index, err := strconv.Atoi(s); err == nil {
+			return genreName(index)
+		}
+		return s
+	}
+
+	switch reference {
+	case "RX":
+		return "Remix"
+	case "CR":
+		return "Cover"
+	}
+	if index, err := strconv.Atoi(reference); err == nil {
+		return genreName(index)
+	}
+	return ""
+}
+
+// genreName returns the name of an ID3v1 genre index
+func genreName(index int) string {
+	if index < 0 || index >= len(id3v1Genres) {
+		return ""
+	}
+	return id3v1Genres[index]
+}
+
+// readID3v1 reads the ID3v1 tag at the end of the file into fields still
+// unset, and reports whether there was one
+func readID3v1(r io.ReaderAt, size int64, meta *Metadata) bool {
+	if size < id3v1Size {
+		return false
+	}
+	tag := make([]byte, id3v1Size)
+	if _, err := r.ReadAt(tag, size-id3v1Size); err != nil || string(tag[:3]) != "TAG" {
+		return false
+	}
+
+	fill(&meta.Title, latin1(tag[3:33]))
+	fill(&meta.Artist, latin1(tag[33:63]))
+	fill(&meta.Album, latin1(tag[63:93]))
+	fillNumber(&meta.Year, parseYear(latin1(tag[93:97])))
+
+	// ID3v1.1 keeps the track number in the last byte of the comment
+	comment := tag[97:127]
+	if comment[28] == 0 && comment[29] != 0 {
+		fillNumber(&meta.Track, int(comment[29]))
+	}
+
+	if tag[127] != 0xff {
+		fill(&meta.Genre, genreName(int(tag[127])))
+	}
+
+	return true
+}
+
+// syncsafe decodes a 28 bit integer stored in the low seven bits of four bytes
+func syncsafe(b []byte) int64 {
+	return int64(b[0]&0x7f)<<21 | int64(b[1]&0x7f)<<14 | int64(b[2]&0x7f)<<7 | int64(b[3]&0x7f)
+}
+
+// unsynchronise removes the zero bytes inserted after each 0xFF
+func unsynchronise(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+// id3v1Genres lists the ID3v1 genres including the Winamp extensions
+var id3v1Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge",
+	"Hip-Hop", "Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B",
+	"Rap", "Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska",
+	"Death Metal", "Pranks", "Soundtrack", "Euro-Techno", "Ambient",
+	"Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance", "Classical",
+	"Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"Alternative Rock", "Bass", "Soul", "Punk", "Space", "Meditative",
+	"Instrumental Pop", "Instrumental Rock", "Ethnic", "Gothic", "Darkwave",
+	"Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap",
+	"Pop/Funk", "Jungle", "Native American", "Cabaret", "New Wave",
+	"Psychedelic", "Rave", "Showtunes", "Trailer", "Lo-Fi", "Tribal",
+	"Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll",
+	"Hard Rock", "Folk", "Folk-Rock", "National Folk", "Swing", "Fast Fusion",
+	"Bebop", "Latin", "Revival", "Celtic", "Bluegrass", "Avantgarde",
+	"Gothic Rock", "Progressive Rock", "Psychedelic Rock", "Symphonic Rock",
+	"Slow Rock", "Big Band", "Chorus", "Easy Listening", "Acoustic", "Humour",
+	"Speech", "Chanson", "Opera", "Chamber Music", "Sonata", "Symphony",
+	"Booty Bass", "Primus", "Porn Groove", "Satire", "Slow Jam", "Club",
+	"Tango", "Samba", "Folklore", "Ballad", "Power Ballad", "Rhythmic Soul",
+	"Freestyle", "Duet", "Punk Rock", "Drum Solo", "A Cappella", "Euro-House",
+	"Dance Hall", "Goa", "Drum & Bass", "Club-House", "Hardcore Techno",
+	"Terror", "Indie", "BritPop", "Negerpunk", "Polsk Punk", "Beat",
+	"Christian Gangsta Rap", "Heavy Metal", "Black Metal", "Crossover",
+	"Contemporary Christian", "Christian Rock", "Merengue", "Salsa",
+	"Thrash Metal", "Anime", "Jpop", "Synthpop", "Abstract", "Art Rock",
+	"Baroque", "Bhangra", "Big Beat", "Breakbeat", "Chillout", "Downtempo",
+	"Dub", "EBM", "Eclectic", "Electro", "Electroclash", "Emo",
+	"Experimental", "Garage", "Global", "IDM", "Illbient", "Industro-Goth",
+	"Jam Band", "Krautrock", "Leftfield", "Lounge", "Math Rock", "New Romantic",
+	"Nu-Breakz", "Post-Punk", "Post-Rock", "Psytrance", "Shoegaze",
+	"Space Rock", "Trop Rock", "World Music", "Neoclassical", "Audiobook",
+	"Audio Theatre", "Neue Deutsche Welle", "Podcast", "Indie Rock",
+	"G-Funk", "Dubstep", "Garage Rock", "Psybient",
+}
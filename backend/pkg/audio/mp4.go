@@ -0,0 +1,272 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+// mp4Box is an ISO base media box located by the offset and size of its content
+type mp4Box struct {
+	kind   string
+	offset int64
+	size   int64
+}
+
+// mp4Boxes lists the boxes between start and end without reading their content
+func mp4Boxes(r io.ReaderAt, start int64, end int64) ([]mp4Box, error) {
+	var boxes []mp4Box
+	header := make([]byte, 16)
+
+	for pos := start; pos+8 <= end; {
+		if _, err := r.ReadAt(header[:8], pos); err != nil {
+			return nil, err
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		kind := string(header[4:8])
+		headerSize := int64(8)
+
+		switch size {
+		case 0:
+			// The last box may extend to the end of its parent
+			size = end - pos
+		case 1:
+			if _, err := r.ReadAt(header[8:16], pos+8); err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if size < headerSize || pos+size > end {
+			return nil, errors.New("mp4 box extends past its parent")
+		}
+
+		boxes = append(boxes, mp4Box{kind: kind, offset: pos + headerSize, size: size - headerSize})
+		pos += size
+	}
+
+	return boxes, nil
+}
+
+// mp4Child returns the first child box of the given kind
+func mp4Child(r io.ReaderAt, parent mp4Box, kind string) (mp4Box, bool) {
+	children, err := mp4Boxes(r, parent.offset, parent.offset+parent.size)
+	if err != nil {
+		return mp4Box{}, false
+	}
+	for _, child := range children {
+		if child.kind == kind {
+			return child, true
+		}
+	}
+	return mp4Box{}, false
+}
+
+// mp4Path follows a path of box kinds down from parent
+func mp4Path(r io.ReaderAt, parent mp4Box, kinds ...string) (mp4Box, bool) {
+	box := parent
+	for _, kind := range kinds {
+		var ok bool
+		if box, ok = mp4Child(r, box, kind); !ok {
+			return mp4Box{}, false
+		}
+	}
+	return box, true
+}
+
+// read loads the content of a box
+func (b mp4Box) read(r io.ReaderAt) ([]byte, error) {
+	return readAt(r, b.offset, b.size)
+}
+
+// readMP4 reads an MP4 or M4A file. The duration comes from the movie
+// header, the stream properties from the sound track's sample description
+// and the tags from the iTunes style ilst atoms.
+func readMP4(r io.ReaderAt, size int64, meta *Metadata) error {
+	top, err := mp4Boxes(r, 0, size)
+	if err != nil {
+		return err
+	}
+
+	var moov mp4Box
+	var mediaBytes int64
+	found := false
+	for _, box := range top {
+		switch box.kind {
+		case "moov":
+			moov, found = box, true
+		case "mdat":
+			mediaBytes += box.size
+		}
+	}
+	if !found {
+		return errors.New("mp4 file has no moov box")
+	}
+
+	if mvhd, ok := mp4Child(r, moov, "mvhd"); ok {
+		if data, err := mvhd.read(r); err == nil {
+			parseMovieHeader(data, meta)
+		}
+	}
+
+	readSoundTrack(r, moov, meta)
+
+	if ilst, ok := mp4Ilst(r, moov); ok {
+		if err := readIlst(r, ilst, meta); err != nil {
+			return err
+		}
+	}
+
+	meta.setAverageBitrate(mediaBytes)
+	return nil
+}
+
+// parseMovieHeader reads the duration from an mvhd box
+func parseMovieHeader(data []byte, meta *Metadata) {
+	var timescale, duration int64
+	switch {
+	case len(data) >= 32 && data[0] == 1:
+		timescale = int64(binary.BigEndian.Uint32(data[20:24]))
+		duration = int64(binary.BigEndian.Uint64(data[24:32]))
+	case len(data) >= 20:
+		timescale = int64(binary.BigEndian.Uint32(data[12:16]))
+		duration = int64(binary.BigEndian.Uint32(data[16:20]))
+	default:
+		return
+	}
+	meta.setDuration(duration, int(timescale))
+}
+
+// readSoundTrack reads the channels and sample rate from the sample
+// description of the first sound track
+func readSoundTrack(r io.ReaderAt, moov mp4Box, meta *Metadata) {
+	tracks, err := mp4Boxes(r, moov.offset, moov.offset+moov.size)
+	if err != nil {
+		return
+	}
+
+	for _, trak := range tracks {
+		if trak.kind != "trak" {
+			continue
+		}
+
+		hdlr, ok := mp4Path(r, trak, "mdia", "hdlr")
+		if !ok {
+			continue
+		}
+		handler, err := readAt(r, hdlr.offset+8, 4)
+		if err != nil || string(handler) != "soun" {
+			continue
+		}
+
+		stsd, ok := mp4Path(r, trak, "mdia", "minf", "stbl", "stsd")
+		if !ok {
+			return
+		}
+		// Full box header and entry count, then the first sample entry
+		// with the audio fields after its box header and data reference
+		content, err := readAt(r, stsd.offset, 8+36)
+		if err != nil {
+			return
+		}
+		entry := content[8:]
+		meta.Channels = int(binary.BigEndian.Uint16(entry[24:26]))
+		meta.SampleRate = int(binary.BigEndian.Uint32(entry[32:36]) >> 16)
+		return
+	}
+}
+
+// mp4Ilst finds the ilst box under moov/udta/meta. The meta box is a full
+// box in MP4 files but a plain box in QuickTime files.
+func mp4Ilst(r io.ReaderAt, moov mp4Box) (mp4Box, bool) {
+	metaBox, ok := mp4Path(r, moov, "udta", "meta")
+	if !ok {
+		return mp4Box{}, false
+	}
+
+	probe, err := readAt(r, metaBox.offset, 8)
+	if err != nil {
+		return mp4Box{}, false
+	}
+	if string(probe[4:8]) != "hdlr" && metaBox.size >= 4 {
+		metaBox.offset += 4
+		metaBox.size -= 4
+	}
+
+	return mp4Child(r, metaBox, "ilst")
+}
+
+// readIlst reads the iTunes metadata items
+func readIlst(r io.ReaderAt, ilst mp4Box, meta *Metadata) error {
+	items, err := mp4Boxes(r, ilst.offset, ilst.offset+ilst.size)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		data, ok := mp4Child(r, item, "data")
+		if !ok || data.size < 8 {
+			continue
+		}
+		content, err := data.read(r)
+		if err != nil {
+			continue
+		}
+		// Type indicator and locale precede the value
+		value := content[8:]
+		if item.kind == "covr" {
+			meta.addPicture(mp4Picture(binary.BigEndian.Uint32(content[:4])&0xffffff, value))
+			continue
+		}
+		text := strings.TrimSpace(strings.ToValidUTF8(string(value), "\ufffd"))
+
+		switch item.kind {
+		case "\xa9nam":
+			fill(&meta.Title, text)
+		case "\xa9ART":
+			fill(&meta.Artist, text)
+		case "aART":
+			fill(&meta.AlbumArtist, text)
+		case "\xa9alb":
+			fill(&meta.Album, text)
+		case "\xa9gen":
+			fill(&meta.Genre, text)
+		case "gnre":
+			// ID3v1 genre index plus one
+			if len(value) >= 2 {
+				fill(&meta.Genre, genreName(int(binary.BigEndian.Uint16(value))-1))
+			}
+		case "\xa9day":
+			fillNumber(&meta.Year, parseYear(text))
+		case "trkn":
+			if len(value) >= 6 {
+				fillNumber(&meta.Track, int(binary.BigEndian.Uint16(value[2:4])))
+				fillNumber(&meta.TrackTotal, int(binary.BigEndian.Uint16(value[4:6])))
+			}
+		case "disk":
+			if len(value) >= 6 {
+				fillNumber(&meta.Disc, int(binary.BigEndian.Uint16(value[2:4])))
+				fillNumber(&meta.DiscTotal, int(binary.BigEndian.Uint16(value[4:6])))
+			}
+		}
+	}
+
+	return nil
+}
+
+// mp4Picture reads a covr item, whose well-known data type tells the
+// image format
+func mp4Picture(dataType uint32, value []byte) *Picture {
+	var mimeType string
+	switch dataType {
+	case 13:
+		mimeType = "image/jpeg"
+	case 14:
+		mimeType = "image/png"
+	case 27:
+		mimeType = "image/bmp"
+	}
+	// iTunes has no picture types, its artwork is the front cover
+	return &Picture{MIMEType: mimeType, Type: PictureFrontCover, Data: value}
+}
@@ -0,0 +1,219 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// mpegSearchSize is how far past the tags the first frame is looked for
+const mpegSearchSize = 64 << 10
+
+// MPEG versions as encoded in the frame header
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+// mpegBitrates holds bitrates in kbit/s by MPEG-1 or MPEG-2/2.5, layer and index
+var mpegBitrates = [2][3][15]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+// mpegSampleRates holds sample rates by version and index
+var mpegSampleRates = map[int][3]int{
+	mpeg1:  {44100, 48000, 32000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg25: {11025, 12000, 8000},
+}
+
+// mpegFrame is a decoded MPEG audio frame header
+type mpegFrame struct {
+	version    int
+	layer      int // 1, 2 or 3
+	bitrate    int // Bits per second
+	sampleRate int
+	padding    int
+	channels   int
+}
+
+// isFrameSync reports whether b starts with an MPEG frame sync
+func isFrameSync(b []byte) bool {
+	return len(b) >= 2 && b[0] == 0xff && b[1]&0xe0 == 0xe0
+}
+
+// parseMPEGHeader decodes a four byte frame header
+func parseMPEGHeader(b []byte) (mpegFrame, bool) {
+	if len(b) < 4 || !isFrameSync(b) {
+		return mpegFrame{}, false
+	}
+
+	version := int(b[1]>>3) & 0x03
+	layerBits := int(b[1]>>1) & 0x03
+	bitrateIndex := int(b[2] >> 4)
+	rateIndex := int(b[2]>>2) & 0x03
+	if version == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mpegFrame{}, false
+	}
+
+	frame := mpegFrame{
+		version:    version,
+		layer:      4 - layerBits,
+		sampleRate: mpegSampleRates[version][rateIndex],
+		padding:    int(b[2]>>1) & 0x01,
+		channels:   2,
+	}
+	if b[3]>>6 == 3 {
+		frame.channels = 1
+	}
+
+	table := 0
+	if version != mpeg1 {
+		table = 1
+	}
+	frame.bitrate = mpegBitrates[table][frame.layer-1][bitrateIndex] * 1000
+
+	return frame, true
+}
+
+// samples returns the number of samples per channel in a frame
+func (f mpegFrame) samples() int {
+	switch {
+	case f.layer == 1:
+		return 384
+	case f.layer == 3 && f.version != mpeg1:
+		return 576
+	default:
+		return 1152
+	}
+}
+
+// length returns the size of the frame in bytes
+func (f mpegFrame) length() int {
+	if f.layer == 1 {
+		return (12*f.bitrate/f.sampleRate + f.padding) * 4
+	}
+	return f.samples()/8*f.bitrate/f.sampleRate + f.padding
+}
+
+// sideInfoSize returns the size of the layer III side information, which
+// the Xing header follows
+func (f mpegFrame) sideInfoSize() int {
+	switch {
+	case f.version == mpeg1 && f.channels == 1:
+		return 17
+	case f.version == mpeg1:
+		return 32
+	case f.channels == 1:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// readMPEG finds the first audio frame between start and end and derives
+// the stream properties from it. VBR files carry the frame count in a Xing
+// or VBRI header, CBR files are timed by their size.
+func readMPEG(r io.ReaderAt, start int64, end int64, meta *Metadata) error {
+	window := end - start
+	if window > mpegSearchSize {
+		window = mpegSearchSize
+	}
+	if window <= 4 {
+		return errors.New("no mpeg audio frames found")
+	}
+	data, err := readAt(r, start, window)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i+4 <= len(data); i++ {
+		frame, ok := parseMPEGHeader(data[i:])
+		if !ok {
+			continue
+		}
+
+		// Random data matches a frame header often enough that the next
+		// header has to line up too when it is within reach
+		next := i + frame.length()
+		if next+4 <= len(data) {
+			following, ok := parseMPEGHeader(data[next:])
+			if !ok || following.version != frame.version || following.layer != frame.layer ||
+				following.sampleRate != frame.sampleRate {
+				continue
+			}
+		}
+
+		offset := start + int64(i)
+		first := data[i:]
+		if len(first) > frame.length() {
+			first = first[:frame.length()]
+		}
+		applyMPEGFrame(frame, first, end-offset, meta)
+		return nil
+	}
+
+	return errors.New("no mpeg audio frames found")
+}
+
+// applyMPEGFrame sets the stream properties from the first frame
+func applyMPEGFrame(frame mpegFrame, first []byte, audioBytes int64, meta *Metadata) {
+	meta.SampleRate = frame.sampleRate
+	meta.Channels = frame.channels
+
+	frames, bytes := vbrHeader(frame, first)
+	if frames > 0 {
+		if bytes > 0 {
+			audioBytes = bytes
+		}
+		meta.setDuration(frames*int64(frame.samples()), frame.sampleRate)
+		meta.setAverageBitrate(audioBytes)
+		return
+	}
+
+	meta.Bitrate = frame.bitrate
+	meta.setDuration(audioBytes*8, frame.bitrate)
+}
+
+// vbrHeader reads the frame and byte counts of a Xing, Info or VBRI
+// header in the first frame
+func vbrHeader(frame mpegFrame, first []byte) (int64, int64) {
+	xing := 4 + frame.sideInfoSize()
+	if len(first) >= xing+8 {
+		tag := string(first[xing : xing+4])
+		if tag == "Xing" || tag == "Info" {
+			flags := binary.BigEndian.Uint32(first[xing+4 : xing+8])
+			pos := xing + 8
+
+			var frames, bytes int64
+			if flags&0x01 != 0 && len(first) >= pos+4 {
+				frames = int64(binary.BigEndian.Uint32(first[pos : pos+4]))
+				pos += 4
+			}
+			if flags&0x02 != 0 && len(first) >= pos+4 {
+				bytes = int64(binary.BigEndian.Uint32(first[pos : pos+4]))
+			}
+			return frames, bytes
+		}
+	}
+
+	// VBRI always sits 32 bytes after the frame header
+	const vbri = 4 + 32
+	if len(first) >= vbri+18 && string(first[vbri:vbri+4]) == "VBRI" {
+		bytes := int64(binary.BigEndian.Uint32(first[vbri+10 : vbri+14]))
+		frames := int64(binary.BigEndian.Uint32(first[vbri+14 : vbri+18]))
+		return frames, bytes
+	}
+
+	return 0, 0
+}
@@ -0,0 +1,274 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+// oggTailSize is how much of the end of an Ogg file is searched for the
+// last page, whose granule position gives the duration
+const oggTailSize = 64 << 10
+
+// opusRate is the rate of Opus granule positions whatever the input rate
+const opusRate = 48000
+
+// vorbisComments maps upper case field names to their values
+type vorbisComments map[string][]string
+
+// parseVorbisComments reads a Vorbis comment header as used by Ogg Vorbis,
+// Opus and FLAC
+func parseVorbisComments(data []byte) (vorbisComments, error) {
+	errTruncated := errors.New("vorbis comments are truncated")
+
+	next := func() ([]byte, error) {
+		if len(data) < 4 {
+			return nil, errTruncated
+		}
+		length := binary.LittleEndian.Uint32(data)
+		data = data[4:]
+		if uint64(length) > uint64(len(data)) {
+			return nil, errTruncated
+		}
+		value := data[:length]
+		data = data[length:]
+		return value, nil
+	}
+
+	// Vendor string
+	if _, err := next(); err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, errTruncated
+	}
+	count := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+
+	comments := make(vorbisComments)
+	for i := uint32(0); i < count; i++ {
+		comment, err := next()
+		if err != nil {
+			return nil, err
+		}
+		name, value, ok := strings.Cut(string(comment), "=")
+		if !ok {
+			continue
+		}
+		name = strings.ToUpper(name)
+		if value = strings.TrimSpace(value); value != "" {
+			comments[name] = append(comments[name], value)
+		}
+	}
+
+	return comments, nil
+}
+
+// get returns the values of the first of names that is present
+func (c vorbisComments) get(names ...string) []string {
+	for _, name := range names {
+		if values := c[name]; len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+// first returns the first value of the first of names that is present
+func (c vorbisComments) first(names ...string) string {
+	if values := c.get(names...); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// apply copies the comments into fields that are still unset
+func (c vorbisComments) apply(meta *Metadata) {
+	fill(&meta.Title, c.first("TITLE"))
+	fill(&meta.Artist, strings.Join(c.get("ARTIST"), "; "))
+	fill(&meta.Album, c.first("ALBUM"))
+	fill(&meta.AlbumArtist, strings.Join(c.get("ALBUMARTIST", "ALBUM ARTIST"), "; "))
+	fill(&meta.Genre, strings.Join(c.get("GENRE"), "; "))
+	fillNumber(&meta.Year, parseYear(c.first("DATE", "YEAR", "ORIGINALDATE")))
+
+	track, total := parsePosition(c.first("TRACKNUMBER"))
+	fillNumber(&meta.Track, track)
+	fillNumber(&meta.TrackTotal, total)
+	total, _ = parsePosition(c.first("TRACKTOTAL", "TOTALTRACKS"))
+	fillNumber(&meta.TrackTotal, total)
+
+	disc, total := parsePosition(c.first("DISCNUMBER"))
+	fillNumber(&meta.Disc, disc)
+	fillNumber(&meta.DiscTotal, total)
+	total, _ = parsePosition(c.first("DISCTOTAL", "TOTALDISCS"))
+	fillNumber(&meta.DiscTotal, total)
+
+	for _, value := range c.get("METADATA_BLOCK_PICTURE") {
+		if block, err := base64.StdEncoding.DecodeString(value); err == nil {
+			if picture, err := parseFLACPicture(block); err == nil {
+				meta.addPicture(picture)
+			}
+		}
+	}
+	// Older taggers wrote the bare image in COVERART
+	if data, err := base64.StdEncoding.DecodeString(c.first("COVERART")); err == nil {
+		meta.addPicture(&Picture{MIMEType: strings.ToLower(c.first("COVERARTMIME")), Data: data})
+	}
+}
+
+// oggPage is the header of an Ogg page
+type oggPage struct {
+	serial   uint32
+	segments []int
+	body     int64 // Offset of the page body
+}
+
+// readOggPage reads the page header at offset
+func readOggPage(r io.ReaderAt, offset int64) (*oggPage, error) {
+	header := make([]byte, 27)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, err
+	}
+	if string(header[:4]) != "OggS" {
+		return nil, errors.New("ogg page is missing its capture pattern")
+	}
+
+	table := make([]byte, header[26])
+	if _, err := r.ReadAt(table, offset+27); err != nil {
+		return nil, err
+	}
+
+	page := &oggPage{
+		serial: binary.LittleEndian.Uint32(header[14:18]),
+		body:   offset + 27 + int64(len(table)),
+	}
+	for _, segment := range table {
+		page.segments = append(page.segments, int(segment))
+	}
+	return page, nil
+}
+
+// readOggPackets reassembles the first count packets of the first logical
+// stream, which hold its identification and comment headers
+func readOggPackets(r io.ReaderAt, size int64, count int) ([][]byte, uint32, error) {
+	var packets [][]byte
+	var packet []byte
+	var serial uint32
+	offset := int64(0)
+
+	for offset < size && len(packets) < count {
+		page, err := readOggPage(r, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		if offset == 0 {
+			serial = page.serial
+		}
+
+		length := 0
+		for _, segment := range page.segments {
+			length += segment
+		}
+		offset = page.body + int64(length)
+		if page.serial != serial {
+			continue
+		}
+
+		body, err := readAt(r, page.body, int64(length))
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, segment := range page.segments {
+			if len(packet)+segment > maxBlockSize {
+				return nil, 0, errors.New("ogg header packet is too large")
+			}
+			packet = append(packet, body[:segment]...)
+			body = body[segment:]
+
+			// A segment shorter than 255 bytes ends the packet
+			if segment < 255 {
+				packets = append(packets, packet)
+				packet = nil
+			}
+		}
+	}
+
+	if len(packets) < count {
+		return nil, 0, errors.New("ogg stream is missing its header packets")
+	}
+	return packets, serial, nil
+}
+
+// readOgg reads an Ogg Vorbis or Opus file. The duration is the granule
+// position of the last page, which counts samples.
+func readOgg(r io.ReaderAt, size int64, meta *Metadata) error {
+	packets, serial, err := readOggPackets(r, size, 2)
+	if err != nil {
+		return err
+	}
+	identification, comments := packets[0], packets[1]
+
+	var rate int
+	var preSkip int64
+	var nominalBitrate int
+	switch {
+	case bytes.HasPrefix(identification, []byte("\x01vorbis")) && len(identification) >= 24:
+		meta.Channels = int(identification[11])
+		meta.SampleRate = int(binary.LittleEndian.Uint32(identification[12:16]))
+		nominalBitrate = int(int32(binary.LittleEndian.Uint32(identification[20:24])))
+		rate = meta.SampleRate
+		comments = bytes.TrimPrefix(comments, []byte("\x03vorbis"))
+	case bytes.HasPrefix(identification, []byte("OpusHead")) && len(identification) >= 16:
+		meta.Channels = int(identification[9])
+		preSkip = int64(binary.LittleEndian.Uint16(identification[10:12]))
+		meta.SampleRate = int(binary.LittleEndian.Uint32(identification[12:16]))
+		rate = opusRate
+		comments = bytes.TrimPrefix(comments, []byte("OpusTags"))
+	default:
+		return ErrUnsupportedFormat
+	}
+
+	tags, err := parseVorbisComments(comments)
+	if err != nil {
+		return err
+	}
+	tags.apply(meta)
+
+	if granule, ok := lastGranule(r, size, serial); ok {
+		meta.setDuration(granule-preSkip, rate)
+	}
+
+	if nominalBitrate > 0 {
+		meta.Bitrate = nominalBitrate
+	} else {
+		meta.setAverageBitrate(size)
+	}
+	return nil
+}
+
+// lastGranule finds the granule position of the last page of a stream
+func lastGranule(r io.ReaderAt, size int64, serial uint32) (int64, bool) {
+	start := size - oggTailSize
+	if start < 0 {
+		start = 0
+	}
+	tail, err := readAt(r, start, size-start)
+	if err != nil {
+		return 0, false
+	}
+
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		if i+27 > len(tail) {
+			continue
+		}
+		granule := int64(binary.LittleEndian.Uint64(tail[i+6 : i+14]))
+		// Pages on which no packet ends have a granule position of -1
+		if binary.LittleEndian.Uint32(tail[i+14:i+18]) == serial && granule >= 0 {
+			return granule, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,124 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// wavInfoFields maps RIFF INFO chunk IDs to the fields they fill
+var wavInfoFields = map[string]func(meta *Metadata, value string){
+	"INAM": func(meta *Metadata, value string) { fill(&meta.Title, value) },
+	"IART": func(meta *Metadata, value string) { fill(&meta.Artist, value) },
+	"IPRD": func(meta *Metadata, value string) { fill(&meta.Album, value) },
+	"IGNR": func(meta *Metadata, value string) { fill(&meta.Genre, value) },
+	"ICRD": func(meta *Metadata, value string) { fillNumber(&meta.Year, parseYear(value)) },
+	"ITRK": func(meta *Metadata, value string) {
+		track, total := parsePosition(value)
+		fillNumber(&meta.Track, track)
+		fillNumber(&meta.TrackTotal, total)
+	},
+}
+
+// readWAV reads a RIFF WAVE file. The duration follows from the size of
+// the data chunk and the byte rate of the fmt chunk. Tags come from an
+// embedded ID3v2 chunk or a LIST INFO chunk.
+func readWAV(r io.ReaderAt, size int64, meta *Metadata) error {
+	var byteRate, dataSize int64
+
+	err := walkRIFF(r, size, func(id string, content int64, length int64) error {
+		switch id {
+		case "fmt ":
+			format, err := readAt(r, content, 16)
+			if err != nil {
+				return err
+			}
+			meta.Channels = int(binary.LittleEndian.Uint16(format[2:4]))
+			meta.SampleRate = int(binary.LittleEndian.Uint32(format[4:8]))
+			byteRate = int64(binary.LittleEndian.Uint32(format[8:12]))
+		case "data":
+			dataSize = length
+		case "id3 ", "ID3 ":
+			if _, err := readID3v2(r, content, content+length, meta); err != nil {
+				return err
+			}
+		case "LIST":
+			if err := readInfoList(r, content, length, meta); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if byteRate == 0 {
+		return errors.New("wav file has no fmt chunk")
+	}
+
+	meta.Bitrate = int(byteRate * 8)
+	meta.setDuration(dataSize, int(byteRate))
+	return nil
+}
+
+// walkRIFF calls fn with the ID, content offset and length of each chunk
+// of a RIFF file
+func walkRIFF(r io.ReaderAt, size int64, fn func(id string, content int64, length int64) error) error {
+	header := make([]byte, 8)
+
+	for pos := int64(12); pos+8 <= size; {
+		if _, err := r.ReadAt(header, pos); err != nil {
+			return err
+		}
+		id := string(header[:4])
+		length := int64(binary.LittleEndian.Uint32(header[4:8]))
+		content := pos + 8
+
+		// Streams written on the fly may leave the data size unset
+		if id == "data" && (length == 0 || content+length > size) {
+			length = size - content
+		}
+
+		if err := fn(id, content, length); err != nil {
+			return err
+		}
+
+		// Chunks are padded to an even size
+		pos = content + length + length%2
+	}
+
+	return nil
+}
+
+// readInfoList reads the text chunks of a LIST INFO chunk
+func readInfoList(r io.ReaderAt, offset int64, length int64, meta *Metadata) error {
+	list, err := readAt(r, offset, length)
+	if err != nil {
+		return err
+	}
+	if len(list) < 4 || string(list[:4]) != "INFO" {
+		return nil
+	}
+
+	for list = list[4:]; len(list) >= 8; {
+		id := string(list[:4])
+		size := int(binary.LittleEndian.Uint32(list[4:8]))
+		list = list[8:]
+		if size > len(list) {
+			break
+		}
+
+		if apply, ok := wavInfoFields[id]; ok {
+			apply(meta, latin1(list[:size]))
+		}
+
+		size += size % 2
+		if size > len(list) {
+			break
+		}
+		list = list[size:]
+	}
+
+	return nil
+}
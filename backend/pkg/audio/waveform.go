@@ -0,0 +1,115 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// WAVE format tags
+const (
+	wavePCM        = 1
+	waveFloat      = 3
+	waveExtensible = 0xfffe
+)
+
+// Waveform computes the peak amplitude of each of points equal slices of
+// the audio, from 0 for silence to 1 for full scale. Only PCM and floating
+// point WAV files can be read without a decoder, other formats return
+// ErrUnsupportedFormat.
+func Waveform(r io.ReaderAt, size int64, points int) ([]float64, error) {
+	head := make([]byte, 12)
+	if _, err := r.ReadAt(head, 0); err != nil || string(head[:4]) != "RIFF" || string(head[8:12]) != "WAVE" {
+		return nil, ErrUnsupportedFormat
+	}
+
+	var format []byte
+	var dataOffset, dataSize int64
+	err := walkRIFF(r, size, func(id string, content int64, length int64) error {
+		switch id {
+		case "fmt ":
+			var err error
+			format, err = readAt(r, content, min(length, 40))
+			return err
+		case "data":
+			dataOffset, dataSize = content, length
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(format) < 16 {
+		return nil, errors.New("wav file has no fmt chunk")
+	}
+
+	tag := binary.LittleEndian.Uint16(format[0:2])
+	channels := int(binary.LittleEndian.Uint16(format[2:4]))
+	blockAlign := int(binary.LittleEndian.Uint16(format[12:14]))
+	bits := int(binary.LittleEndian.Uint16(format[14:16]))
+	// The sub format GUID of an extensible header starts with the tag
+	if tag == waveExtensible && len(format) >= 26 {
+		tag = binary.LittleEndian.Uint16(format[24:26])
+	}
+
+	sample := sampleDecoder(tag, bits)
+	if sample == nil || channels == 0 || blockAlign < channels*bits/8 {
+		return nil, ErrUnsupportedFormat
+	}
+
+	frames := dataSize / int64(blockAlign)
+	if frames == 0 || points <= 0 {
+		return []float64{}, nil
+	}
+	if int64(points) > frames {
+		points = int(frames)
+	}
+
+	peaks := make([]float64, points)
+	reader := bufio.NewReaderSize(io.NewSectionReader(r, dataOffset, frames*int64(blockAlign)), 64<<10)
+	frame := make([]byte, blockAlign)
+	width := bits / 8
+
+	for i := int64(0); i < frames; i++ {
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			return nil, err
+		}
+		point := int(i * int64(points) / frames)
+		for c := 0; c < channels; c++ {
+			// Written so that NaN samples in float files are ignored
+			if amplitude := math.Abs(sample(frame[c*width:])); amplitude > peaks[point] {
+				peaks[point] = amplitude
+			}
+		}
+	}
+
+	for i, peak := range peaks {
+		peaks[i] = math.Min(peak, 1)
+	}
+	return peaks, nil
+}
+
+// sampleDecoder returns a function reading one sample scaled to [-1, 1],
+// or nil for sample formats that aren't supported
+func sampleDecoder(tag uint16, bits int) func(b []byte) float64 {
+	switch {
+	case tag == wavePCM && bits == 8:
+		// 8 bit samples are unsigned
+		return func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case tag == wavePCM && bits == 16:
+		return func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15) }
+	case tag == wavePCM && bits == 24:
+		return func(b []byte) float64 {
+			return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / (1 << 23)
+		}
+	case tag == wavePCM && bits == 32:
+		return func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }
+	case tag == waveFloat && bits == 32:
+		return func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	case tag == waveFloat && bits == 64:
+		return func(b []byte) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(b)) }
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// maxCoverSize caps how much of a cover image is read
+const maxCoverSize = 10 << 20
+
+var (
+	// ErrNoCover is returned when the package document declares no cover image
+	ErrNoCover = errors.New("epub has no cover image")
+)
+
+// Cover reads the cover image and returns it with the media type the
+// manifest declares for it. The EPUB 3 cover-image property is preferred,
+// then the EPUB 2 cover meta, then any image whose name mentions a cover.
+func (b *Book) Cover() ([]byte, string, error) {
+	item, ok := b.coverItem()
+	if !ok {
+		return nil, "", ErrNoCover
+	}
+
+	name, err := b.resolve(item.Href)
+	if err != nil {
+		return nil, "", err
+	}
+
+	file, err := b.open(name)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxCoverSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(data) > maxCoverSize {
+		return nil, "", fmt.Errorf("cover image %s is larger than %d bytes", name, maxCoverSize)
+	}
+
+	return data, item.MediaType, nil
+}
+
+// coverItem finds the manifest item of the cover image
+func (b *Book) coverItem() (opfItem, bool) {
+	items := b.pkg.Manifest.Items
+
+	for _, item := range items {
+		for _, property := range strings.Fields(item.Properties) {
+			if property == "cover-image" {
+				return item, true
+			}
+		}
+	}
+
+	// EPUB 2 names the item by ID, though some books give its href instead
+	for _, meta := range b.pkg.Metadata.Metas {
+		if meta.Name != "cover" {
+			continue
+		}
+		content := strings.TrimSpace(meta.Content)
+		for _, item := range items {
+			if content != "" && (item.ID == content || item.Href == content) {
+				return item, true
+			}
+		}
+	}
+
+	for _, item := range items {
+		if !strings.HasPrefix(item.MediaType, "image/") {
+			continue
+		}
+		if strings.Contains(strings.ToLower(item.ID), "cover") ||
+			strings.Contains(strings.ToLower(path.Base(item.Href)), "cover") {
+			return item, true
+		}
+	}
+
+	return opfItem{}, false
+}
+
+// resolve turns an href relative to the package document into a path in
+// the archive
+func (b *Book) resolve(href string) (string, error) {
+	href, _, _ = strings.Cut(href, "#")
+	unescaped, err := url.PathUnescape(href)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", href, err)
+	}
+	return path.Join(path.Dir(b.opfPath), unescaped), nil
+}
@@ -0,0 +1,290 @@
+package epub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// containerPath is where every EPUB points to its package document
+	containerPath = "META-INF/container.xml"
+	// packageMediaType identifies the package document among the rootfiles
+	packageMediaType = "application/oebps-package+xml"
+	// maxDocumentSize caps how much of container.xml or the OPF is read
+	maxDocumentSize = 4 << 20
+)
+
+var (
+	// ErrNoPackageDocument is returned when container.xml names no OPF
+	ErrNoPackageDocument = errors.New("epub has no package document")
+)
+
+// Book is an opened EPUB with its parsed package document
+type Book struct {
+	zip     *zip.Reader
+	opfPath string
+	pkg     *opfPackage
+}
+
+// Open reads the container and package document of an EPUB
+func Open(r io.ReaderAt, size int64) (*Book, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open epub archive: %w", err)
+	}
+
+	book := &Book{zip: archive}
+
+	var container opfContainer
+	if err := book.decode(containerPath, &container); err != nil {
+		return nil, err
+	}
+
+	for _, rootfile := range container.Rootfiles {
+		if rootfile.MediaType == packageMediaType || rootfile.MediaType == "" {
+			book.opfPath = rootfile.FullPath
+			break
+		}
+	}
+	if book.opfPath == "" {
+		return nil, ErrNoPackageDocument
+	}
+
+	book.pkg = &opfPackage{}
+	if err := book.decode(book.opfPath, book.pkg); err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// decode parses an XML document stored in the archive
+func (b *Book) decode(name string, v interface{}) error {
+	file, err := b.open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(file, maxDocumentSize))
+	// Non UTF-8 package documents are rare, so read them as they are
+	// rather than rejecting the book
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// open opens a file in the archive by its path
+func (b *Book) open(name string) (io.ReadCloser, error) {
+	for _, file := range b.zip.File {
+		if file.Name == name {
+			return file.Open()
+		}
+	}
+	return nil, fmt.Errorf("epub is missing %s", name)
+}
+
+// Metadata holds the publication metadata of an EPUB
+type Metadata struct {
+	Version     string
+	Title       string
+	Creators    []string
+	Language    string
+	Publisher   string
+	Identifiers []Identifier
+	ISBN        string
+	Date        string
+	Subjects    []string
+	Series      string
+	SeriesIndex *float64
+	SpineLength int
+}
+
+// Identifier is a dc:identifier with the scheme it was declared with, if any
+type Identifier struct {
+	Scheme string
+	Value  string
+}
+
+// Metadata collects the publication metadata from the package document,
+// understanding both EPUB 2 attributes and EPUB 3 refinements
+func (b *Book) Metadata() *Metadata {
+	pkg := b.pkg
+	refines := pkg.Metadata.refinements()
+
+	meta := &Metadata{
+		Version:     pkg.Version,
+		Language:    firstText(pkg.Metadata.Languages),
+		Publisher:   firstText(pkg.Metadata.Publishers),
+		SpineLength: len(pkg.Spine.Itemrefs),
+	}
+
+	// EPUB 3 may mark one of several titles as the main one
+	for _, title := range pkg.Metadata.Titles {
+		text := strings.TrimSpace(title.Text)
+		if text == "" {
+			continue
+		}
+		if meta.Title == "" || refines.get(title.ID, "title-type") == "main" {
+			meta.Title = text
+		}
+	}
+
+	for _, creator := range pkg.Metadata.Creators {
+		if name := strings.TrimSpace(creator.Text); name != "" {
+			meta.Creators = append(meta.Creators, name)
+		}
+	}
+
+	for _, subject := range pkg.Metadata.Subjects {
+		if text := strings.TrimSpace(subject.Text); text != "" {
+			meta.Subjects = append(meta.Subjects, text)
+		}
+	}
+
+	for _, identifier := range pkg.Metadata.Identifiers {
+		value := strings.TrimSpace(identifier.Text)
+		if value == "" {
+			continue
+		}
+
+		scheme := identifier.Scheme
+		if scheme == "" {
+			scheme = refines.get(identifier.ID, "identifier-type")
+		}
+		meta.Identifiers = append(meta.Identifiers, Identifier{Scheme: scheme, Value: value})
+
+		if meta.ISBN == "" {
+			if isbn, ok := parseISBN(scheme, value); ok {
+				meta.ISBN = isbn
+			}
+		}
+	}
+
+	meta.Date = publicationDate(pkg.Metadata.Dates)
+	meta.Series, meta.SeriesIndex = series(pkg.Metadata, refines)
+
+	return meta
+}
+
+// publicationDate prefers a date marked as the publication event, as EPUB 2
+// allows several dates with different events
+func publicationDate(dates []opfText) string {
+	var fallback string
+	for _, date := range dates {
+		text := strings.TrimSpace(date.Text)
+		if text == "" {
+			continue
+		}
+		switch strings.ToLower(date.Event) {
+		case "publication", "original-publication":
+			return text
+		case "":
+			if fallback == "" {
+				fallback = text
+			}
+		}
+	}
+	if fallback == "" && len(dates) > 0 {
+		fallback = strings.TrimSpace(dates[0].Text)
+	}
+	return fallback
+}
+
+// series reads the Calibre series meta tags or an EPUB 3 series collection
+func series(metadata opfMetadata, refines refinements) (string, *float64) {
+	var name string
+	var index *float64
+
+	for _, meta := range metadata.Metas {
+		switch meta.Name {
+		case "calibre:series":
+			name = strings.TrimSpace(meta.Content)
+		case "calibre:series_index":
+			if value, err := strconv.ParseFloat(strings.TrimSpace(meta.Content), 64); err == nil {
+				index = &value
+			}
+		}
+	}
+	if name != "" {
+		return name, index
+	}
+
+	for _, meta := range metadata.Metas {
+		if meta.Property != "belongs-to-collection" {
+			continue
+		}
+
+		collectionType := refines.get(meta.ID, "collection-type")
+		if collectionType != "" && collectionType != "series" {
+			continue
+		}
+
+		name = strings.TrimSpace(meta.Text)
+		if value, err := strconv.ParseFloat(refines.get(meta.ID, "group-position"), 64); err == nil {
+			index = &value
+		}
+		return name, index
+	}
+
+	return "", nil
+}
+
+// parseISBN recognizes an ISBN by its declared scheme or URN prefix and
+// returns it without separators
+func parseISBN(scheme string, value string) (string, bool) {
+	lower := strings.ToLower(value)
+	isISBN := strings.EqualFold(scheme, "isbn") ||
+		// ONIX codelist 5 values for ISBN-10 and ISBN-13
+		scheme == "02" || scheme == "15"
+
+	for _, prefix := range []string{"urn:isbn:", "isbn:", "isbn "} {
+		if strings.HasPrefix(lower, prefix) {
+			value = value[len(prefix):]
+			isISBN = true
+			break
+		}
+	}
+	if !isISBN {
+		return "", false
+	}
+
+	var digits strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == 'x' || r == 'X':
+			digits.WriteRune('X')
+		case r == '-' || r == ' ':
+		default:
+			return "", false
+		}
+	}
+
+	isbn := digits.String()
+	if len(isbn) != 10 && len(isbn) != 13 {
+		return "", false
+	}
+	return isbn, true
+}
+
+// firstText returns the first non-empty text among elements
+func firstText(elements []opfText) string {
+	for _, element := range elements {
+		if text := strings.TrimSpace(element.Text); text != "" {
+			return text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,291 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// openFixture opens an EPUB from testdata
+func openFixture(t *testing.T, name string) *Book {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	book, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Open(%s): %v", name, err)
+	}
+	return book
+}
+
+// archive builds a zip holding files, keyed by path
+func archive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestMetadata(t *testing.T) {
+	two, threeAndAHalf := 2.0, 3.5
+
+	tests := []struct {
+		fixture string
+		want    Metadata
+	}{
+		{
+			// The title refined as main wins, the ISBN is found through an
+			// identifier-type refinement and the series is a collection
+			fixture: "epub3.epub",
+			want: Metadata{
+				Version:   "3.0",
+				Title:     "The Main Title",
+				Creators:  []string{"Ann Author", "Bob Writer"},
+				Language:  "en-GB",
+				Publisher: "Fixture Press",
+				Identifiers: []Identifier{
+					{Value: "urn:uuid:12345678-1234-1234-1234-123456789abc"},
+					{Scheme: "15", Value: "978-0-306-40615-7"},
+				},
+				ISBN:        "9780306406157",
+				Date:        "2020-01-15",
+				Subjects:    []string{"Fiction", "Testing"},
+				Series:      "The Saga",
+				SeriesIndex: &two,
+				SpineLength: 5,
+			},
+		},
+		{
+			// EPUB 2 schemes, the publication event among several dates
+			// and Calibre series metadata
+			fixture: "epub2.epub",
+			want: Metadata{
+				Version:  "2.0",
+				Title:    "Old Book",
+				Creators: []string{"Carl Classic"},
+				Language: "fr",
+				Identifiers: []Identifier{
+					{Scheme: "UUID", Value: "0a1b2c3d"},
+					{Scheme: "ISBN", Value: "urn:isbn:0-306-40615-2"},
+				},
+				ISBN:        "0306406152",
+				Date:        "2001-06-30",
+				Series:      "Old Series",
+				SeriesIndex: &threeAndAHalf,
+				SpineLength: 1,
+			},
+		},
+		{
+			fixture: "cover-by-name.epub",
+			want: Metadata{
+				Version: "2.0",
+				Title:   "Unnamed Cover",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got := openFixture(t, tt.fixture).Metadata()
+			if !reflect.DeepEqual(got, &tt.want) {
+				t.Errorf("Metadata() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCover(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		data      string
+		mediaType string
+	}{
+		// The cover-image property wins over an image named cover.jpg,
+		// and its href is unescaped relative to the OPF
+		{fixture: "epub3.epub", data: "\x89PNG\r\n\x1a\nfakecover", mediaType: "image/png"},
+		{fixture: "epub2.epub", data: "GIF89afront", mediaType: "image/gif"},
+		{fixture: "cover-by-name.epub", data: "\xff\xd8\xffjpeg", mediaType: "image/jpeg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, mediaType, err := openFixture(t, tt.fixture).Cover()
+			if err != nil {
+				t.Fatalf("Cover: %v", err)
+			}
+			if string(data) != tt.data || mediaType != tt.mediaType {
+				t.Errorf("Cover() = %q, %q, want %q, %q", data, mediaType, tt.data, tt.mediaType)
+			}
+		})
+	}
+}
+
+func TestCoverMissing(t *testing.T) {
+	data := archive(t, map[string]string{
+		containerPath: `<container><rootfiles><rootfile full-path="a.opf"/></rootfiles></container>`,
+		"a.opf":       `<package version="3.0"><manifest><item id="x" href="x.png" media-type="image/png"/></manifest></package>`,
+	})
+	book, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, _, err := book.Cover(); !errors.Is(err, ErrNoCover) {
+		t.Errorf("Cover returned %v, want ErrNoCover", err)
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		fixture string
+		limit   int
+		want    string
+	}{
+		// Head, script and style are dropped, spine items that aren't
+		// HTML or can't be found are skipped and the nav is not read
+		{
+			fixture: "epub3.epub",
+			want:    "Chapter One\nFish & chips, café au lait.\nLine\nbreak\n\nSecond chapter\nUnclosed paragraph",
+		},
+		{fixture: "epub3.epub", limit: 20, want: "Chapter One\nFish & c"},
+		// The limit never splits a character
+		{fixture: "epub3.epub", limit: 30, want: "Chapter One\nFish & chips, caf"},
+		{fixture: "epub2.epub", want: "Loose HTML text\nwith breaks"},
+		{fixture: "cover-by-name.epub", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got, err := openFixture(t, tt.fixture).Text(tt.limit)
+			if err != nil {
+				t.Fatalf("Text: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Text(%d) = %q, want %q", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    error
+		message string
+	}{
+		{name: "not a zip", data: []byte("%PDF-1.4"), message: "failed to open epub archive"},
+		{name: "no container", data: archive(t, map[string]string{"mimetype": "application/epub+zip"}), message: "missing " + containerPath},
+		{
+			name: "no package rootfile",
+			data: archive(t, map[string]string{
+				containerPath: `<container><rootfiles><rootfile full-path="x.pdf" media-type="application/pdf"/></rootfiles></container>`,
+			}),
+			want: ErrNoPackageDocument,
+		},
+		{
+			name: "missing package document",
+			data: archive(t, map[string]string{
+				containerPath: `<container><rootfiles><rootfile full-path="gone.opf"/></rootfiles></container>`,
+			}),
+			message: "missing gone.opf",
+		},
+		{
+			name: "malformed package document",
+			data: archive(t, map[string]string{
+				containerPath: `<container><rootfiles><rootfile full-path="a.opf"/></rootfiles></container>`,
+				"a.opf":       `<package><metadata>`,
+			}),
+			message: "failed to parse a.opf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(bytes.NewReader(tt.data), int64(len(tt.data)))
+			switch {
+			case err == nil:
+				t.Fatal("Open succeeded")
+			case tt.want != nil && !errors.Is(err, tt.want):
+				t.Errorf("Open returned %v, want %v", err, tt.want)
+			case tt.message != "" && !strings.Contains(err.Error(), tt.message):
+				t.Errorf("Open returned %q, want an error containing %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseISBN(t *testing.T) {
+	tests := []struct {
+		scheme, value string
+		want          string
+		ok            bool
+	}{
+		{scheme: "ISBN", value: "978-0-306-40615-7", want: "9780306406157", ok: true},
+		{scheme: "", value: "urn:isbn:0-306-40615-2", want: "0306406152", ok: true},
+		{scheme: "02", value: "0 306 40615 x", want: "030640615X", ok: true},
+		{scheme: "", value: "978-0-306-40615-7", ok: false},
+		{scheme: "isbn", value: "12345", ok: false},
+		{scheme: "isbn", value: "978-0-306-4061A-7", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseISBN(tt.scheme, tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseISBN(%q, %q) = %q, %v, want %q, %v", tt.scheme, tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// FuzzOpen checks that no input makes reading a book panic or hang
+func FuzzOpen(f *testing.F) {
+	fixtures, _ := filepath.Glob(filepath.Join("testdata", "*.epub"))
+	for _, fixture := range fixtures {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			f.Fatalf("read fixture: %v", err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		book, err := Open(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+		book.Metadata()
+		book.Cover()
+		book.Text(4096)
+	})
+}
+
+// FuzzDocumentText checks that no markup makes text extraction panic or hang
+func FuzzDocumentText(f *testing.F) {
+	f.Add("<p>Fish &amp; chips<br>caf&eacute;</p>")
+	f.Add("<html><head><style>x</style></head><body><div><p>a<p>b</div></body></html>")
+	f.Add("<svg><text>hidden</text></svg></svg></svg>visible")
+
+	f.Fuzz(func(t *testing.T, markup string) {
+		documentText(strings.NewReader(markup))
+	})
+}
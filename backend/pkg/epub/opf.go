@@ -0,0 +1,96 @@
+package epub
+
+import (
+	"strings"
+)
+
+// opfContainer is META-INF/container.xml
+type opfContainer struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// opfPackage is the package document. Element names are matched without
+// their namespace, so dc:title and title are the same to the decoder.
+type opfPackage struct {
+	Version  string      `xml:"version,attr"`
+	Metadata opfMetadata `xml:"metadata"`
+	Manifest struct {
+		Items []opfItem `xml:"item"`
+	} `xml:"manifest"`
+	Spine struct {
+		Itemrefs []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// opfItem is a publication resource listed in the manifest
+type opfItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+// opfMetadata is the metadata section of the package document
+type opfMetadata struct {
+	Titles      []opfText `xml:"title"`
+	Creators    []opfText `xml:"creator"`
+	Languages   []opfText `xml:"language"`
+	Publishers  []opfText `xml:"publisher"`
+	Identifiers []opfText `xml:"identifier"`
+	Dates       []opfText `xml:"date"`
+	Subjects    []opfText `xml:"subject"`
+	Metas       []opfMeta `xml:"meta"`
+}
+
+// opfText is a Dublin Core element along with its EPUB 2 attributes
+type opfText struct {
+	ID     string `xml:"id,attr"`
+	Scheme string `xml:"scheme,attr"`
+	Event  string `xml:"event,attr"`
+	Text   string `xml:",chardata"`
+}
+
+// opfMeta is an EPUB 2 name/content meta or an EPUB 3 property meta
+type opfMeta struct {
+	Name     string `xml:"name,attr"`
+	Content  string `xml:"content,attr"`
+	ID       string `xml:"id,attr"`
+	Property string `xml:"property,attr"`
+	Refines  string `xml:"refines,attr"`
+	Text     string `xml:",chardata"`
+}
+
+// refinements maps an element ID to the EPUB 3 properties refining it
+type refinements map[string]map[string]string
+
+// refinements indexes every meta that refines another element
+func (m opfMetadata) refinements() refinements {
+	refines := make(refinements)
+	for _, meta := range m.Metas {
+		if meta.Refines == "" || meta.Property == "" {
+			continue
+		}
+
+		id := strings.TrimPrefix(meta.Refines, "#")
+		if refines[id] == nil {
+			refines[id] = make(map[string]string)
+		}
+		if _, exists := refines[id][meta.Property]; !exists {
+			refines[id][meta.Property] = strings.TrimSpace(meta.Text)
+		}
+	}
+	return refines
+}
+
+// get returns a refining property of an element, or "" if there is none
+func (r refinements) get(id string, property string) string {
+	if id == "" {
+		return ""
+	}
+	return r[id][property]
+}
@@ -0,0 +1,131 @@
+package epub
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// blockElements end a line of extracted text
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"br": true, "dd": true, "div": true, "dl": true, "dt": true,
+	"figcaption": true, "figure": true, "footer": true, "h1": true,
+	"h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "ol": true, "p": true,
+	"pre": true, "section": true, "table": true, "td": true, "th": true,
+	"tr": true, "ul": true,
+}
+
+// skippedElements hold no readable text
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "svg": true, "math": true,
+}
+
+// Text extracts the text of the content documents in spine order,
+// stopping once limit bytes have been collected. Markup is dropped and
+// block elements end lines. Documents that can't be read are skipped.
+func (b *Book) Text(limit int) (string, error) {
+	items := make(map[string]opfItem)
+	for _, item := range b.pkg.Manifest.Items {
+		items[item.ID] = item
+	}
+
+	var out strings.Builder
+	for _, itemref := range b.pkg.Spine.Itemrefs {
+		if limit > 0 && out.Len() >= limit {
+			break
+		}
+
+		item, ok := items[itemref.IDRef]
+		if !ok || !strings.Contains(item.MediaType, "html") {
+			continue
+		}
+		name, err := b.resolve(item.Href)
+		if err != nil {
+			continue
+		}
+		file, err := b.open(name)
+		if err != nil {
+			continue
+		}
+		text := documentText(io.LimitReader(file, maxDocumentSize))
+		file.Close()
+
+		if text == "" {
+			continue
+		}
+		if out.Len() > 0 {
+			out.WriteString("\n\n")
+		}
+		out.WriteString(text)
+	}
+
+	text := out.String()
+	if limit > 0 && len(text) > limit {
+		text = strings.ToValidUTF8(text[:limit], "")
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// documentText collects the text of an XHTML document. The decoder is
+// lenient so the HTML found in real books still yields its text.
+func documentText(r io.Reader) string {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var lines []string
+	var line strings.Builder
+	skipping := 0
+
+	endLine := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if skippedElements[name] {
+				skipping++
+			} else if blockElements[name] {
+				endLine()
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			if skippedElements[name] {
+				if skipping > 0 {
+					skipping--
+				}
+			} else if blockElements[name] {
+				endLine()
+			}
+		case xml.CharData:
+			if skipping == 0 {
+				line.WriteString(strings.Map(func(r rune) rune {
+					if unicode.IsSpace(r) {
+						return ' '
+					}
+					return r
+				}, string(t)))
+			}
+		}
+	}
+	endLine()
+
+	return strings.Join(lines, "\n")
+}
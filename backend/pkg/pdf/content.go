@@ -0,0 +1,401 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+)
+
+// maxFormDepth bounds form XObjects drawing other forms
+const maxFormDepth = 8
+
+var (
+	// ErrEncrypted is returned for content that can't be read without the
+	// document's key
+	ErrEncrypted = errors.New("pdf document is encrypted")
+)
+
+// page is a leaf of the page tree with the resources it inherits
+type page struct {
+	dict      Dict
+	resources Dict
+}
+
+// pages lists the pages in document order
+func (d *Document) pages() []page {
+	catalog, _ := d.resolve(d.trailer["Root"]).(Dict)
+	root, ok := d.resolve(catalog["Pages"]).(Dict)
+	if !ok {
+		return nil
+	}
+
+	var pages []page
+	d.collectPages(root, nil, make(map[int]bool), 0, &pages)
+	return pages
+}
+
+// collectPages walks a page tree node, passing inherited resources down
+func (d *Document) collectPages(node Dict, resources Dict, visited map[int]bool, depth int, pages *[]page) {
+	if own, ok := d.resolve(node["Resources"]).(Dict); ok {
+		resources = own
+	}
+
+	kids, ok := d.resolve(node["Kids"]).(Array)
+	if !ok {
+		if node["Type"] != Name("Pages") {
+			*pages = append(*pages, page{dict: node, resources: resources})
+		}
+		return
+	}
+	if depth > maxPageTreeDepth {
+		return
+	}
+
+	for _, kid := range kids {
+		if ref, ok := kid.(Ref); ok {
+			if visited[ref.Num] {
+				continue
+			}
+			visited[ref.Num] = true
+		}
+		if child, ok := d.resolve(kid).(Dict); ok {
+			d.collectPages(child, resources, visited, depth+1, pages)
+		}
+	}
+}
+
+// Text extracts the text of every page in reading order as far as the
+// content streams allow, stopping once limit bytes have been collected.
+// Pages are separated by blank lines. Glyphs of fonts without a usable
+// encoding or ToUnicode map are left out.
+func (d *Document) Text(limit int) (string, error) {
+	if d.trailer["Encrypt"] != nil {
+		return "", ErrEncrypted
+	}
+
+	e := &textExtractor{doc: d, fonts: make(map[int]*font), limit: limit}
+	for _, pg := range d.pages() {
+		if e.full() {
+			break
+		}
+		e.run(d.pageContents(pg.dict), pg.resources, 0)
+		e.endPage()
+	}
+
+	return strings.TrimSpace(e.out.String()), nil
+}
+
+// pageContents decodes the content of a page, which may be split over an
+// array of streams
+func (d *Document) pageContents(pg Dict) []byte {
+	var streams []*Stream
+	switch contents := d.resolve(pg["Contents"]).(type) {
+	case *Stream:
+		streams = append(streams, contents)
+	case Array:
+		for _, item := range contents {
+			if stream, ok := d.resolve(item).(*Stream); ok {
+				streams = append(streams, stream)
+			}
+		}
+	}
+
+	// Operators may straddle the boundary between two streams, so the
+	// parts are joined before parsing
+	var buf bytes.Buffer
+	for _, stream := range streams {
+		data, err := d.decodeStream(stream)
+		if err != nil {
+			continue
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// textExtractor turns text showing operators into plain text, guessing
+// line breaks and word spaces from how the text position moves
+type textExtractor struct {
+	doc   *Document
+	fonts map[int]*font
+	out   bytes.Buffer
+	limit int
+
+	font     *font
+	fontSize float64
+	lineY    float64 // Vertical position of the current line
+	hasLine  bool
+}
+
+// full reports whether the limit has been reached
+func (e *textExtractor) full() bool {
+	return e.limit > 0 && e.out.Len() >= e.limit
+}
+
+// write appends text without going past the limit
+func (e *textExtractor) write(s string) {
+	if e.full() || s == "" {
+		return
+	}
+	if e.limit > 0 && e.out.Len()+len(s) > e.limit {
+		s = strings.ToValidUTF8(s[:e.limit-e.out.Len()], "")
+	}
+	e.out.WriteString(s)
+}
+
+// last returns the last byte written, or zero before any output
+func (e *textExtractor) last() byte {
+	if e.out.Len() == 0 {
+		return 0
+	}
+	return e.out.Bytes()[e.out.Len()-1]
+}
+
+// space separates words unless the output already ends in white space
+func (e *textExtractor) space() {
+	if c := e.last(); c != 0 && c != ' ' && c != '\n' {
+		e.write(" ")
+	}
+}
+
+// newline ends the current line unless it is empty, dropping any space
+// left at its end
+func (e *textExtractor) newline() {
+	for e.last() == ' ' {
+		e.out.Truncate(e.out.Len() - 1)
+	}
+	if c := e.last(); c != 0 && c != '\n' {
+		e.write("\n")
+	}
+}
+
+// endPage separates pages with a blank line
+func (e *textExtractor) endPage() {
+	e.newline()
+	if e.out.Len() >= 2 && !bytes.HasSuffix(e.out.Bytes(), []byte("\n\n")) {
+		e.write("\n")
+	}
+	e.hasLine = false
+}
+
+// moveTo handles a new text position, starting a line when it moved
+// vertically by more than a fraction of the font size
+func (e *textExtractor) moveTo(y float64) {
+	if e.hasLine {
+		threshold := math.Max(e.fontSize*0.5, 1)
+		if math.Abs(y-e.lineY) > threshold {
+			e.newline()
+		} else {
+			e.space()
+		}
+	}
+	e.lineY = y
+	e.hasLine = true
+}
+
+// run interprets a content stream with the given resources
+func (e *textExtractor) run(data []byte, resources Dict, depth int) {
+	var y float64
+	leading := 0.0
+
+	scanContent(data, func(op string, args []interface{}) bool {
+		switch op {
+		case "BT":
+			y = 0
+		case "Tf":
+			if len(args) == 2 {
+				e.font = e.loadFont(resources, args[0])
+				e.fontSize = math.Abs(number(args[1]))
+			}
+		case "TL":
+			if len(args) == 1 {
+				leading = number(args[0])
+			}
+		case "Td", "TD":
+			if len(args) == 2 {
+				ty := number(args[1])
+				if op == "TD" {
+					leading = -ty
+				}
+				y += ty
+				e.moveTo(y)
+			}
+		case "Tm":
+			if len(args) == 6 {
+				y = number(args[5])
+				e.moveTo(y)
+			}
+		case "T*":
+			y -= leading
+			e.newline()
+			e.lineY = y
+		case "Tj":
+			if len(args) == 1 {
+				e.show(args[0])
+			}
+		case "'", "\"":
+			y -= leading
+			e.newline()
+			e.lineY = y
+			if len(args) > 0 {
+				e.show(args[len(args)-1])
+			}
+		case "TJ":
+			if len(args) == 1 {
+				items, _ := args[0].(Array)
+				for _, item := range items {
+					// Large negative adjustments move the next glyph
+					// right by a word space or more
+					if _, isString := item.(String); !isString && number(item) < -200 {
+						e.space()
+					}
+					e.show(item)
+				}
+			}
+		case "Do":
+			if len(args) == 1 && depth < maxFormDepth {
+				e.form(resources, args[0], depth)
+			}
+		}
+		return !e.full()
+	})
+}
+
+// show appends the text of a string operand in the current font
+func (e *textExtractor) show(value interface{}) {
+	s, ok := value.(String)
+	if !ok || e.font == nil {
+		return
+	}
+	e.write(e.font.decode(s))
+}
+
+// loadFont resolves a font resource, caching fonts by object number
+func (e *textExtractor) loadFont(resources Dict, name interface{}) *font {
+	fonts, _ := e.doc.resolve(resources["Font"]).(Dict)
+	key, _ := name.(Name)
+	value := fonts[key]
+
+	ref, isRef := value.(Ref)
+	if isRef {
+		if cached, ok := e.fonts[ref.Num]; ok {
+			return cached
+		}
+	}
+
+	dict, ok := e.doc.resolve(value).(Dict)
+	if !ok {
+		return nil
+	}
+	f := e.doc.newFont(dict)
+	if isRef {
+		e.fonts[ref.Num] = f
+	}
+	return f
+}
+
+// form runs a form XObject drawn with Do
+func (e *textExtractor) form(resources Dict, name interface{}, depth int) {
+	objects, _ := e.doc.resolve(resources["XObject"]).(Dict)
+	key, _ := name.(Name)
+	stream, ok := e.doc.resolve(objects[key]).(*Stream)
+	if !ok || stream.Dict["Subtype"] != Name("Form") {
+		return
+	}
+
+	data, err := e.doc.decodeStream(stream)
+	if err != nil {
+		return
+	}
+
+	// Forms without resources of their own use those of the page
+	formResources, ok := e.doc.resolve(stream.Dict["Resources"]).(Dict)
+	if !ok {
+		formResources = resources
+	}
+
+	saved, savedSize := e.font, e.fontSize
+	e.run(data, formResources, depth+1)
+	e.font, e.fontSize = saved, savedSize
+}
+
+// scanContent calls fn with each operator of a content stream or CMap and
+// the operands before it, until fn returns false. Malformed tokens are
+// skipped so a damaged stream still yields what can be read.
+func scanContent(data []byte, fn func(op string, args []interface{}) bool) {
+	p := &parser{data: data}
+	var args []interface{}
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return
+		}
+
+		c := p.data[p.pos]
+		switch {
+		case c == '/' || c == '(' || c == '<' || c == '[' ||
+			c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			value, err := p.object()
+			if err != nil {
+				p.pos++
+				args = args[:0]
+				continue
+			}
+			args = append(args, value)
+			continue
+		case isDelimiter(c):
+			// Stray delimiters such as the braces of PostScript procedures
+			p.pos++
+			continue
+		}
+
+		switch op := p.keyword(); op {
+		case "true":
+			args = append(args, true)
+		case "false":
+			args = append(args, false)
+		case "null":
+			args = append(args, nil)
+		case "ID":
+			skipInlineImage(p)
+			args = args[:0]
+		default:
+			if !fn(op, args) {
+				return
+			}
+			args = args[:0]
+		}
+	}
+}
+
+// skipInlineImage moves past the binary data of an inline image, which
+// ends at an EI surrounded by white space
+func skipInlineImage(p *parser) {
+	p.pos++ // Single white space after ID
+	for p.pos+2 <= len(p.data) {
+		i := bytes.Index(p.data[p.pos:], []byte("EI"))
+		if i < 0 {
+			p.pos = len(p.data)
+			return
+		}
+		end := p.pos + i
+		p.pos = end + 2
+		if end > 0 && isWhitespace(p.data[end-1]) && (p.pos == len(p.data) || isDelimiter(p.data[p.pos])) {
+			return
+		}
+	}
+}
+
+// number converts an integer or real operand
+func number(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,251 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// headerSearchSize is how far into the file the %PDF- header may start
+	headerSearchSize = 1024
+	// maxResolveDepth bounds chains of references pointing at references
+	maxResolveDepth = 32
+)
+
+var (
+	// ErrNotPDF is returned when the data has no %PDF- header
+	ErrNotPDF = errors.New("data is not a pdf document")
+)
+
+// Document is a parsed PDF file. The whole file is held in memory, so
+// callers are expected to bound its size.
+type Document struct {
+	data    []byte
+	version string
+	xref    map[int]xrefEntry
+	trailer Dict
+
+	objects   map[int]interface{}
+	streams   map[int]*objectStream
+	resolving map[int]bool
+	headers   map[int]int64 // Object offsets found by scanning, built on demand
+}
+
+// objectStream is a decoded object stream holding compressed objects
+type objectStream struct {
+	data    []byte
+	nums    []int
+	offsets []int
+}
+
+// Open reads a PDF document and its cross-reference data. Files with a
+// broken xref are read by scanning for their objects instead.
+func Open(r io.ReaderAt, size int64) (*Document, error) {
+	data := make([]byte, size)
+	if n, err := r.ReadAt(data, 0); err != nil && !(err == io.EOF && int64(n) == size) {
+		return nil, fmt.Errorf("failed to read pdf: %w", err)
+	}
+
+	d := &Document{
+		data:      data,
+		xref:      make(map[int]xrefEntry),
+		trailer:   Dict{},
+		objects:   make(map[int]interface{}),
+		streams:   make(map[int]*objectStream),
+		resolving: make(map[int]bool),
+	}
+
+	header := data
+	if len(header) > headerSearchSize {
+		header = header[:headerSearchSize]
+	}
+	index := bytes.Index(header, []byte("%PDF-"))
+	if index < 0 {
+		return nil, ErrNotPDF
+	}
+	p := &parser{data: data, pos: index + len("%PDF-")}
+	d.version = p.keyword()
+
+	offset, err := d.startXref()
+	if err == nil {
+		err = d.loadXref(offset)
+	}
+	if err != nil || d.trailer["Root"] == nil {
+		if err := d.rebuildXref(); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// resolve follows references, returning nil for missing objects
+func (d *Document) resolve(value interface{}) interface{} {
+	for i := 0; i < maxResolveDepth; i++ {
+		ref, ok := value.(Ref)
+		if !ok {
+			return value
+		}
+		value = d.getObject(ref.Num)
+	}
+	return nil
+}
+
+// getObject loads an object by number, caching the result
+func (d *Document) getObject(num int) interface{} {
+	if value, ok := d.objects[num]; ok {
+		return value
+	}
+	// An object whose stream /Length refers back to itself must not loop
+	if d.resolving[num] {
+		return nil
+	}
+	d.resolving[num] = true
+	defer delete(d.resolving, num)
+
+	var value interface{}
+	found := false
+	if entry, ok := d.xref[num]; ok && !entry.free {
+		if entry.compressed {
+			value, found = d.compressedObject(num, entry)
+		} else {
+			value, found = d.objectAt(num, entry.offset)
+		}
+	}
+
+	// Offsets in the xref are often slightly off in files written by
+	// careless tools, so fall back to where the object header really is
+	if !found {
+		if offset, ok := d.objectHeaders()[num]; ok {
+			value, _ = d.objectAt(num, offset)
+		}
+	}
+
+	d.objects[num] = value
+	return value
+}
+
+// objectAt parses the indirect object at offset, checking its number
+func (d *Document) objectAt(num int, offset int64) (interface{}, bool) {
+	if offset < 0 || offset >= int64(len(d.data)) {
+		return nil, false
+	}
+
+	p := &parser{data: d.data, pos: int(offset), length: d.streamLength}
+	ref, value, err := p.indirect()
+	if err != nil || ref.Num != num {
+		return nil, false
+	}
+	return value, true
+}
+
+// streamLength resolves an indirect stream /Length
+func (d *Document) streamLength(ref Ref) (int64, bool) {
+	length, ok := d.getObject(ref.Num).(int64)
+	return length, ok
+}
+
+// compressedObject reads an object stored inside an object stream
+func (d *Document) compressedObject(num int, entry xrefEntry) (interface{}, bool) {
+	stream, ok := d.getObject(entry.stream).(*Stream)
+	if !ok {
+		return nil, false
+	}
+	objects, err := d.objectStream(entry.stream, stream)
+	if err != nil {
+		return nil, false
+	}
+
+	index := entry.index
+	if index >= len(objects.nums) || objects.nums[index] != num {
+		index = -1
+		for i, n := range objects.nums {
+			if n == num {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, false
+		}
+	}
+
+	p := &parser{data: objects.data, pos: objects.offsets[index]}
+	value, err := p.object()
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// objectStream decodes an object stream and its table of object offsets
+func (d *Document) objectStream(num int, stream *Stream) (*objectStream, error) {
+	if objects, ok := d.streams[num]; ok {
+		return objects, nil
+	}
+
+	data, err := d.decodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	count := d.integer(stream.Dict["N"], -1)
+	first := d.integer(stream.Dict["First"], -1)
+	if count < 0 || first < 0 || first > int64(len(data)) {
+		return nil, fmt.Errorf("object stream %d has an invalid header", num)
+	}
+
+	objects := &objectStream{data: data}
+	p := &parser{data: data[:first]}
+	for i := int64(0); i < count; i++ {
+		object, ok := p.integer()
+		if !ok {
+			break
+		}
+		offset, ok := p.integer()
+		if !ok || first+offset >= int64(len(data)) {
+			break
+		}
+		objects.nums = append(objects.nums, int(object))
+		objects.offsets = append(objects.offsets, int(first+offset))
+	}
+
+	d.streams[num] = objects
+	return objects, nil
+}
+
+// objectHeaders maps object numbers to the offset of their last
+// "num gen obj" header in the file
+func (d *Document) objectHeaders() map[int]int64 {
+	if d.headers != nil {
+		return d.headers
+	}
+
+	d.headers = make(map[int]int64)
+	for _, match := range objectHeader.FindAllSubmatchIndex(d.data, -1) {
+		// The object number must start at a token boundary
+		if match[0] > 0 && !isDelimiter(d.data[match[0]-1]) {
+			continue
+		}
+		num, err := strconv.Atoi(string(d.data[match[2]:match[3]]))
+		if err != nil {
+			continue
+		}
+		d.headers[num] = int64(match[0])
+	}
+	return d.headers
+}
+
+// integer resolves a numeric object, returning fallback for anything else
+func (d *Document) integer(value interface{}, fallback int64) int64 {
+	switch number := d.resolve(value).(type) {
+	case int64:
+		return number
+	case float64:
+		return int64(number)
+	}
+	return fallback
+}
@@ -0,0 +1,254 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openFixture opens a PDF from testdata
+func openFixture(t *testing.T, name string) *Document {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	doc, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Open(%s): %v", name, err)
+	}
+	return doc
+}
+
+func TestMetadata(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    Metadata
+		hasXMP  bool
+	}{
+		{
+			fixture: "classic.pdf",
+			want: Metadata{
+				Version:      "1.4",
+				PageCount:    2,
+				Title:        "Test Document",
+				Author:       "Jane Roe",
+				Subject:      "Fixtures",
+				Keywords:     "test, pdf",
+				Creator:      "Hand",
+				Producer:     "pdfgen",
+				CreationDate: time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600)),
+				ModDate:      time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+			},
+		},
+		{
+			// The appended section replaces the Info dictionary and
+			// inherits everything else through /Prev
+			fixture: "incremental.pdf",
+			want: Metadata{
+				Version:   "1.4",
+				PageCount: 2,
+				Title:     "Updated Title",
+				Author:    "Jane Roe",
+			},
+		},
+		{
+			// startxref points into the middle of the table, so the
+			// objects are found by scanning
+			fixture: "broken-xref.pdf",
+			want: Metadata{
+				Version:      "1.4",
+				PageCount:    2,
+				Title:        "Test Document",
+				Author:       "Jane Roe",
+				Subject:      "Fixtures",
+				Keywords:     "test, pdf",
+				Creator:      "Hand",
+				Producer:     "pdfgen",
+				CreationDate: time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600)),
+				ModDate:      time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+			},
+		},
+		{
+			// An xref stream, the Info dictionary inside an object stream
+			// with a UTF-16 title, and a page tree without /Count
+			fixture: "xref-stream.pdf",
+			want: Metadata{
+				Version:   "1.5",
+				PageCount: 2,
+				Title:     "Titre été 2",
+				Author:    "Stream Author",
+			},
+		},
+		{
+			fixture: "encrypted.pdf",
+			want: Metadata{
+				Version:   "1.4",
+				PageCount: 2,
+				Encrypted: true,
+			},
+		},
+		{
+			fixture: "linearized.pdf",
+			want: Metadata{
+				Version:    "1.7",
+				PageCount:  1,
+				Linearized: true,
+			},
+		},
+		{
+			// Without an Info dictionary everything comes from XMP
+			fixture: "xmp.pdf",
+			want: Metadata{
+				Version:      "1.6",
+				PageCount:    1,
+				Title:        "XMP Title",
+				Author:       "Ann; Bob",
+				Creator:      "XMP Tool",
+				Producer:     "XMP Producer",
+				CreationDate: time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC),
+			},
+			hasXMP: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got := openFixture(t, tt.fixture).Metadata()
+
+			if (got.XMP != nil) != tt.hasXMP {
+				t.Errorf("XMP = %v, want present %v", got.XMP, tt.hasXMP)
+			}
+			got.XMP = nil
+
+			if !got.CreationDate.Equal(tt.want.CreationDate) {
+				t.Errorf("CreationDate = %v, want %v", got.CreationDate, tt.want.CreationDate)
+			}
+			if !got.ModDate.Equal(tt.want.ModDate) {
+				t.Errorf("ModDate = %v, want %v", got.ModDate, tt.want.ModDate)
+			}
+			got.CreationDate, got.ModDate = tt.want.CreationDate, tt.want.ModDate
+
+			if *got != tt.want {
+				t.Errorf("Metadata() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinearizedAfterUpdate(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "linearized.pdf"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	// Appending anything changes the length, which undoes linearization
+	data = append(data, "% appended\n"...)
+	doc, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if doc.Metadata().Linearized {
+		t.Error("Linearized = true after the file grew")
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		fixture string
+		limit   int
+		want    string
+	}{
+		{fixture: "classic.pdf", limit: 1000, want: "Hello World\n\nSecond page"},
+		{fixture: "classic.pdf", limit: 5, want: "Hello"},
+		{fixture: "xref-stream.pdf", limit: 1000, want: "Compressed text"},
+		{fixture: "xmp.pdf", limit: 1000, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got, err := openFixture(t, tt.fixture).Text(tt.limit)
+			if err != nil {
+				t.Fatalf("Text: %v", err)
+			}
+			if !strings.HasPrefix(got, tt.want) || len(got) > tt.limit {
+				t.Errorf("Text(%d) = %q, want %q", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextEncrypted(t *testing.T) {
+	if _, err := openFixture(t, "encrypted.pdf").Text(1000); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Text of an encrypted document returned %v, want ErrEncrypted", err)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{name: "not a pdf", data: "PK\x03\x04 a zip file", want: ErrNotPDF},
+		{name: "empty", data: "", want: ErrNotPDF},
+		{name: "no catalog", data: "%PDF-1.4\n1 0 obj\n<< /Type /Page >>\nendobj\n%%EOF\n", want: ErrNoXref},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(strings.NewReader(tt.data), int64(len(tt.data)))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Open returned %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{in: "D:20240102030405Z", want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), ok: true},
+		{in: "D:20240102030405-05'30'", want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", -(5*3600+30*60))), ok: true},
+		{in: "D:2024", want: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ok: true},
+		{in: "20240102", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), ok: true},
+		{in: "D:20241302", ok: false},
+		{in: "yesterday", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseDate(tt.in)
+		if ok != tt.ok || (ok && !got.Equal(tt.want)) {
+			t.Errorf("parseDate(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// FuzzOpen checks that no input makes reading a document panic or hang
+func FuzzOpen(f *testing.F) {
+	fixtures, _ := filepath.Glob(filepath.Join("testdata", "*.pdf"))
+	for _, fixture := range fixtures {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			f.Fatalf("read fixture: %v", err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := Open(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+		doc.Metadata()
+		doc.Text(4096)
+	})
+}
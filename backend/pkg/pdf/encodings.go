@@ -0,0 +1,83 @@
+package pdf
+
+// winAnsiEncoding holds the WinAnsiEncoding characters that differ from
+// ISO Latin-1
+var winAnsiEncoding = map[int]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†',
+	0x87: '‡', 0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ',
+	0x8e: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•',
+	0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9a: 'š', 0x9b: '›',
+	0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+}
+
+// standardEncodingHigh holds the upper half of StandardEncoding
+var standardEncodingHigh = map[int]rune{
+	0xa1: '¡', 0xa2: '¢', 0xa3: '£', 0xa4: '⁄', 0xa5: '¥', 0xa6: 'ƒ',
+	0xa7: '§', 0xa8: '¤', 0xa9: '\'', 0xaa: '“', 0xab: '«', 0xac: '‹',
+	0xad: '›', 0xae: 'ﬁ', 0xaf: 'ﬂ', 0xb1: '–', 0xb2: '†', 0xb3: '‡',
+	0xb4: '·', 0xb6: '¶', 0xb7: '•', 0xb8: '‚', 0xb9: '„', 0xba: '”',
+	0xbb: '»', 0xbc: '…', 0xbd: '‰', 0xbf: '¿', 0xc1: '`', 0xc2: '´',
+	0xc3: 'ˆ', 0xc4: '˜', 0xc5: '¯', 0xc6: '˘', 0xc7: '˙', 0xc8: '¨',
+	0xca: '˚', 0xcb: '¸', 0xcd: '˝', 0xce: '˛', 0xcf: 'ˇ', 0xd0: '—',
+	0xe1: 'Æ', 0xe3: 'ª', 0xe8: 'Ł', 0xe9: 'Ø', 0xea: 'Œ', 0xeb: 'º',
+	0xf1: 'æ', 0xf5: 'ı', 0xf8: 'ł', 0xf9: 'ø', 0xfa: 'œ', 0xfb: 'ß',
+}
+
+// macRomanHigh is the upper half of MacRomanEncoding, from 0x80
+const macRomanHigh = "ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü" +
+	"†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø" +
+	"¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ" +
+	"‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ"
+
+// latinGlyphNames names the printable ASCII characters from 0x20 and the
+// Latin-1 characters from 0xa0, in code order
+var latinGlyphNames = [2][]string{
+	{
+		"space", "exclam", "quotedbl", "numbersign", "dollar", "percent",
+		"ampersand", "quotesingle", "parenleft", "parenright", "asterisk",
+		"plus", "comma", "hyphen", "period", "slash", "zero", "one", "two",
+		"three", "four", "five", "six", "seven", "eight", "nine", "colon",
+		"semicolon", "less", "equal", "greater", "question", "at", "A", "B",
+		"C", "D", "E", "F", "G", "H", "I", "J", "K", "L", "M", "N", "O", "P",
+		"Q", "R", "S", "T", "U", "V", "W", "X", "Y", "Z", "bracketleft",
+		"backslash", "bracketright", "asciicircum", "underscore", "grave",
+		"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n",
+		"o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z",
+		"braceleft", "bar", "braceright", "asciitilde",
+	},
+	{
+		"nbspace", "exclamdown", "cent", "sterling", "currency", "yen",
+		"brokenbar", "section", "dieresis", "copyright", "ordfeminine",
+		"guillemotleft", "logicalnot", "sfthyphen", "registered", "macron",
+		"degree", "plusminus", "twosuperior", "threesuperior", "acute", "mu",
+		"paragraph", "periodcentered", "cedilla", "onesuperior",
+		"ordmasculine", "guillemotright", "onequarter", "onehalf",
+		"threequarters", "questiondown", "Agrave", "Aacute", "Acircumflex",
+		"Atilde", "Adieresis", "Aring", "AE", "Ccedilla", "Egrave", "Eacute",
+		"Ecircumflex", "Edieresis", "Igrave", "Iacute", "Icircumflex",
+		"Idieresis", "Eth", "Ntilde", "Ograve", "Oacute", "Ocircumflex",
+		"Otilde", "Odieresis", "multiply", "Oslash", "Ugrave", "Uacute",
+		"Ucircumflex", "Udieresis", "Yacute", "Thorn", "germandbls", "agrave",
+		"aacute", "acircumflex", "atilde", "adieresis", "aring", "ae",
+		"ccedilla", "egrave", "eacute", "ecircumflex", "edieresis", "igrave",
+		"iacute", "icircumflex", "idieresis", "eth", "ntilde", "ograve",
+		"oacute", "ocircumflex", "otilde", "odieresis", "divide", "oslash",
+		"ugrave", "uacute", "ucircumflex", "udieresis", "yacute", "thorn",
+		"ydieresis",
+	},
+}
+
+// glyphNames maps the other glyph names to characters
+var glyphNames = map[string]rune{
+	"quoteleft": '‘', "quoteright": '’', "quotedblleft": '“',
+	"quotedblright": '”', "quotesinglbase": '‚', "quotedblbase": '„',
+	"endash": '–', "emdash": '—', "bullet": '•', "ellipsis": '…',
+	"dagger": '†', "daggerdbl": '‡', "perthousand": '‰',
+	"guilsinglleft": '‹', "guilsinglright": '›', "fi": 'ﬁ', "fl": 'ﬂ',
+	"ff": 'ﬀ', "ffi": 'ﬃ', "ffl": 'ﬄ', "trademark": '™', "minus": '−',
+	"fraction": '⁄', "florin": 'ƒ', "OE": 'Œ', "oe": 'œ', "Scaron": 'Š',
+	"scaron": 'š', "Zcaron": 'Ž', "zcaron": 'ž', "Ydieresis": 'Ÿ',
+	"dotlessi": 'ı', "Lslash": 'Ł', "lslash": 'ł', "circumflex": 'ˆ',
+	"tilde": '˜', "breve": '˘', "dotaccent": '˙', "ring": '˚',
+	"ogonek": '˛', "caron": 'ˇ', "hungarumlaut": '˝', "Euro": '€',
+}
@@ -0,0 +1,195 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxDecodedSize caps how large a single decoded stream may grow
+const maxDecodedSize = 64 << 20
+
+// decodeStream applies the filters of a stream to its data. Only the
+// filters used for metadata, cross-reference and object streams are
+// supported.
+func (d *Document) decodeStream(stream *Stream) ([]byte, error) {
+	var filters []Name
+	var params []Dict
+
+	switch filter := d.resolve(stream.Dict["Filter"]).(type) {
+	case Name:
+		filters = []Name{filter}
+	case Array:
+		for _, entry := range filter {
+			if name, ok := d.resolve(entry).(Name); ok {
+				filters = append(filters, name)
+			}
+		}
+	}
+
+	switch param := d.resolve(stream.Dict["DecodeParms"]).(type) {
+	case Dict:
+		params = []Dict{param}
+	case Array:
+		for _, entry := range param {
+			dict, _ := d.resolve(entry).(Dict)
+			params = append(params, dict)
+		}
+	}
+
+	data := stream.Data
+	for i, filter := range filters {
+		var param Dict
+		if i < len(params) {
+			param = params[i]
+		}
+
+		var err error
+		switch filter {
+		case "FlateDecode", "Fl":
+			data, err = flateDecode(data)
+			if err == nil {
+				data, err = d.unpredict(data, param)
+			}
+		case "ASCIIHexDecode", "AHx":
+			data, err = asciiHexDecode(data)
+		case "ASCII85Decode", "A85":
+			data, err = ascii85Decode(data)
+		case "Crypt":
+			// Only the identity crypt filter can be read without a key
+		default:
+			err = fmt.Errorf("unsupported filter %s", filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// flateDecode inflates zlib data. Truncated or damaged streams keep
+// whatever could be inflated, as readers commonly do.
+func flateDecode(data []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate stream: %w", err)
+	}
+	defer reader.Close()
+
+	var out bytes.Buffer
+	_, err = io.Copy(&out, io.LimitReader(reader, maxDecodedSize))
+	if err != nil && out.Len() == 0 {
+		return nil, fmt.Errorf("failed to inflate stream: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// unpredict reverses the PNG predictors used by cross-reference streams
+func (d *Document) unpredict(data []byte, param Dict) ([]byte, error) {
+	predictor := d.integer(param["Predictor"], 1)
+	if predictor == 1 {
+		return data, nil
+	}
+	if predictor < 10 {
+		return nil, fmt.Errorf("unsupported predictor %d", predictor)
+	}
+
+	colors := d.integer(param["Colors"], 1)
+	bitsPerComponent := d.integer(param["BitsPerComponent"], 8)
+	columns := d.integer(param["Columns"], 1)
+	if colors < 1 || bitsPerComponent < 1 || columns < 1 {
+		return nil, errors.New("invalid predictor parameters")
+	}
+
+	bytesPerPixel := int((colors*bitsPerComponent + 7) / 8)
+	rowLength := int((colors*bitsPerComponent*columns + 7) / 8)
+
+	out := make([]byte, 0, len(data))
+	previous := make([]byte, rowLength)
+	for len(data) > 0 {
+		kind := data[0]
+		data = data[1:]
+
+		row := make([]byte, rowLength)
+		n := copy(row, data)
+		data = data[n:]
+
+		for i := 0; i < rowLength; i++ {
+			var left, upperLeft byte
+			if i >= bytesPerPixel {
+				left = row[i-bytesPerPixel]
+				upperLeft = previous[i-bytesPerPixel]
+			}
+			up := previous[i]
+
+			switch kind {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upperLeft)
+			default:
+				return nil, fmt.Errorf("invalid PNG filter type %d", kind)
+			}
+		}
+
+		out = append(out, row[:n]...)
+		previous = row
+	}
+
+	return out, nil
+}
+
+// paeth is the PNG Paeth predictor function
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+// abs returns the absolute value of v
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// asciiHexDecode decodes ASCIIHexDecode data up to its > terminator
+func asciiHexDecode(data []byte) ([]byte, error) {
+	p := &parser{data: append(append([]byte{'<'}, data...), '>')}
+	if end := bytes.IndexByte(data, '>'); end >= 0 {
+		p.data = append([]byte{'<'}, data[:end+1]...)
+	}
+	return p.hexString()
+}
+
+// ascii85Decode decodes ASCII85Decode data up to its ~> terminator
+func ascii85Decode(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if end := bytes.Index(data, []byte("~>")); end >= 0 {
+		data = data[:end]
+	}
+
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ASCII85 stream: %w", err)
+	}
+	return out[:n], nil
+}
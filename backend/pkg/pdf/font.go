@@ -0,0 +1,277 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// font maps the character codes of shown strings to text
+type font struct {
+	composite bool
+	codespace []codespaceRange
+
+	// From a ToUnicode CMap
+	chars  map[uint32]string
+	ranges []cmapRange
+
+	// From the encoding of a simple font, zero where unknown
+	encoding [256]rune
+}
+
+// codespaceRange is a range of codes of one byte length
+type codespaceRange struct {
+	length int
+	lo, hi uint32
+}
+
+// cmapRange maps consecutive codes to consecutive text, or to the
+// listed strings when dst is nil
+type cmapRange struct {
+	lo, hi uint32
+	dst    []uint16
+	list   []string
+}
+
+// newFont reads the encoding and ToUnicode map of a font dictionary
+func (d *Document) newFont(dict Dict) *font {
+	f := &font{composite: dict["Subtype"] == Name("Type0")}
+
+	if stream, ok := d.resolve(dict["ToUnicode"]).(*Stream); ok {
+		if data, err := d.decodeStream(stream); err == nil {
+			f.parseCMap(data)
+		}
+	}
+
+	if !f.composite {
+		f.encoding = d.simpleEncoding(dict)
+	}
+	return f
+}
+
+// simpleEncoding builds the code to character table of a simple font from
+// its base encoding and differences
+func (d *Document) simpleEncoding(dict Dict) [256]rune {
+	base := Name("StandardEncoding")
+	if dict["Subtype"] == Name("TrueType") {
+		base = "WinAnsiEncoding"
+	}
+
+	var differences Array
+	switch encoding := d.resolve(dict["Encoding"]).(type) {
+	case Name:
+		base = encoding
+	case Dict:
+		if name, ok := d.resolve(encoding["BaseEncoding"]).(Name); ok {
+			base = name
+		}
+		differences, _ = d.resolve(encoding["Differences"]).(Array)
+	}
+
+	var table [256]rune
+	for code := 0x20; code < 0x7f; code++ {
+		table[code] = rune(code)
+	}
+	switch base {
+	case "WinAnsiEncoding":
+		for code := 0xa0; code <= 0xff; code++ {
+			table[code] = rune(code)
+		}
+		for code, r := range winAnsiEncoding {
+			table[code] = r
+		}
+	case "MacRomanEncoding":
+		for i, r := range []rune(macRomanHigh) {
+			table[0x80+i] = r
+		}
+	default:
+		table['\''] = '’'
+		table['`'] = '‘'
+		for code, r := range standardEncodingHigh {
+			table[code] = r
+		}
+	}
+
+	code := 0
+	for _, item := range differences {
+		switch v := item.(type) {
+		case int64:
+			code = int(v)
+		case Name:
+			if code >= 0 && code < 256 {
+				if r, ok := glyphRune(string(v)); ok {
+					table[code] = r
+				}
+			}
+			code++
+		}
+	}
+
+	return table
+}
+
+// parseCMap reads the codespace ranges and bfchar and bfrange mappings of
+// a ToUnicode CMap
+func (f *font) parseCMap(data []byte) {
+	f.chars = make(map[uint32]string)
+
+	scanContent(data, func(op string, args []interface{}) bool {
+		switch op {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(args); i += 2 {
+				lo, ok1 := args[i].(String)
+				hi, ok2 := args[i+1].(String)
+				if ok1 && ok2 && len(lo) == len(hi) && len(lo) > 0 && len(lo) <= 4 {
+					f.codespace = append(f.codespace, codespaceRange{
+						length: len(lo),
+						lo:     codeValue(lo),
+						hi:     codeValue(hi),
+					})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(args); i += 2 {
+				src, ok1 := args[i].(String)
+				dst, ok2 := args[i+1].(String)
+				if ok1 && ok2 {
+					f.chars[codeValue(src)] = decodeUTF16(dst, true)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(args); i += 3 {
+				lo, ok1 := args[i].(String)
+				hi, ok2 := args[i+1].(String)
+				if !ok1 || !ok2 {
+					continue
+				}
+				r := cmapRange{lo: codeValue(lo), hi: codeValue(hi)}
+				switch dst := args[i+2].(type) {
+				case String:
+					for j := 0; j+1 < len(dst); j += 2 {
+						r.dst = append(r.dst, uint16(dst[j])<<8|uint16(dst[j+1]))
+					}
+				case Array:
+					for _, item := range dst {
+						s, _ := item.(String)
+						r.list = append(r.list, decodeUTF16(s, true))
+					}
+				}
+				if r.hi >= r.lo && (len(r.dst) > 0 || len(r.list) > 0) {
+					f.ranges = append(f.ranges, r)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// decode converts the codes of a shown string to text
+func (f *font) decode(s []byte) string {
+	var out strings.Builder
+	for len(s) > 0 {
+		length := f.codeLength(s)
+		code := codeValue(s[:length])
+		s = s[length:]
+
+		if text, ok := f.lookup(code); ok {
+			out.WriteString(text)
+		} else if !f.composite && f.encoding[code] != 0 {
+			out.WriteRune(f.encoding[code])
+		}
+	}
+	return out.String()
+}
+
+// codeLength finds how many bytes the next code takes, using the codespace
+// ranges when the CMap declares them
+func (f *font) codeLength(s []byte) int {
+	for length := 1; length <= 4 && length <= len(s); length++ {
+		code := codeValue(s[:length])
+		for _, r := range f.codespace {
+			if r.length == length && code >= r.lo && code <= r.hi {
+				return length
+			}
+		}
+	}
+	if f.composite && len(s) >= 2 {
+		return 2
+	}
+	return 1
+}
+
+// lookup maps a code through the ToUnicode CMap
+func (f *font) lookup(code uint32) (string, bool) {
+	if text, ok := f.chars[code]; ok {
+		return text, true
+	}
+	for _, r := range f.ranges {
+		if code < r.lo || code > r.hi {
+			continue
+		}
+		offset := code - r.lo
+		if r.list != nil {
+			if int(offset) < len(r.list) {
+				return r.list[offset], true
+			}
+			return "", false
+		}
+		// The offset is added to the last code unit of the destination
+		units := append([]uint16(nil), r.dst...)
+		units[len(units)-1] += uint16(offset)
+		b := make([]byte, 0, len(units)*2)
+		for _, unit := range units {
+			b = append(b, byte(unit>>8), byte(unit))
+		}
+		return decodeUTF16(b, true), true
+	}
+	return "", false
+}
+
+// codeValue reads a big endian character code
+func codeValue(b []byte) uint32 {
+	var code uint32
+	for _, c := range b {
+		code = code<<8 | uint32(c)
+	}
+	return code
+}
+
+// glyphRune maps a glyph name from an encoding's differences to its
+// character, following the conventions of the Adobe Glyph List for names
+// that are not in the table
+func glyphRune(name string) (rune, bool) {
+	// Variants such as "a.sc" or "one.oldstyle" stand for the base glyph
+	if base, _, found := strings.Cut(name, "."); found && base != "" {
+		name = base
+	}
+
+	if r, ok := glyphNames[name]; ok {
+		return r, true
+	}
+	for i, first := range []rune{0x20, 0xa0} {
+		for j, latin := range latinGlyphNames[i] {
+			if latin == name {
+				return first + rune(j), true
+			}
+		}
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		return r, true
+	}
+
+	var hexDigits string
+	switch {
+	case strings.HasPrefix(name, "uni") && len(name) >= 7:
+		hexDigits = name[3:7]
+	case strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7:
+		hexDigits = name[1:]
+	default:
+		return 0, false
+	}
+	value, err := strconv.ParseUint(hexDigits, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(value)) {
+		return 0, false
+	}
+	return rune(value), true
+}
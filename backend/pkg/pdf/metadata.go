@@ -0,0 +1,163 @@
+package pdf
+
+import (
+	"strings"
+	"time"
+)
+
+// maxPageTreeDepth bounds the walk of a page tree without a usable /Count
+const maxPageTreeDepth = 64
+
+// Metadata holds the document information of a PDF
+type Metadata struct {
+	Version      string
+	PageCount    int
+	Encrypted    bool
+	Linearized   bool
+	Title        string
+	Author       string
+	Subject      string
+	Keywords     string
+	Creator      string
+	Producer     string
+	CreationDate time.Time
+	ModDate      time.Time
+	XMP          *XMP
+}
+
+// Metadata collects the document information dictionary, the XMP packet of
+// the catalog and structural details. Values missing from the information
+// dictionary are taken from XMP, which replaces it as of PDF 2.0. Strings of
+// encrypted documents can't be read without their key and are left empty.
+func (d *Document) Metadata() *Metadata {
+	meta := &Metadata{
+		Version:    d.version,
+		Encrypted:  d.trailer["Encrypt"] != nil,
+		Linearized: d.linearized(),
+	}
+
+	catalog, _ := d.resolve(d.trailer["Root"]).(Dict)
+
+	// The catalog may override the header version after an update
+	if version, ok := d.resolve(catalog["Version"]).(Name); ok && string(version) > meta.Version {
+		meta.Version = string(version)
+	}
+
+	if pages, ok := d.resolve(catalog["Pages"]).(Dict); ok {
+		meta.PageCount = int(d.integer(pages["Count"], -1))
+		if meta.PageCount < 0 {
+			meta.PageCount = d.countPages(pages, make(map[int]bool), 0)
+		}
+	}
+
+	if meta.Encrypted {
+		return meta
+	}
+
+	if info, ok := d.resolve(d.trailer["Info"]).(Dict); ok {
+		meta.Title = d.text(info["Title"])
+		meta.Author = d.text(info["Author"])
+		meta.Subject = d.text(info["Subject"])
+		meta.Keywords = d.text(info["Keywords"])
+		meta.Creator = d.text(info["Creator"])
+		meta.Producer = d.text(info["Producer"])
+		meta.CreationDate, _ = parseDate(d.text(info["CreationDate"]))
+		meta.ModDate, _ = parseDate(d.text(info["ModDate"]))
+	}
+
+	if stream, ok := d.resolve(catalog["Metadata"]).(*Stream); ok {
+		if data, err := d.decodeStream(stream); err == nil {
+			meta.XMP, _ = parseXMP(data)
+		}
+	}
+
+	if xmp := meta.XMP; xmp != nil {
+		setDefault(&meta.Title, xmp.Title)
+		setDefault(&meta.Author, strings.Join(xmp.Creators, "; "))
+		setDefault(&meta.Subject, xmp.Description)
+		setDefault(&meta.Keywords, xmp.Keywords)
+		setDefault(&meta.Creator, xmp.CreatorTool)
+		setDefault(&meta.Producer, xmp.Producer)
+		if meta.CreationDate.IsZero() {
+			meta.CreationDate = xmp.CreateDate
+		}
+		if meta.ModDate.IsZero() {
+			meta.ModDate = xmp.ModifyDate
+		}
+	}
+
+	return meta
+}
+
+// text resolves a text string object
+func (d *Document) text(value interface{}) string {
+	if s, ok := d.resolve(value).(String); ok {
+		return decodeText(s)
+	}
+	return ""
+}
+
+// setDefault sets an empty string field to value
+func setDefault(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// countPages counts the leaves of a page tree node, skipping kids that
+// were already visited so a cyclic tree can't loop
+func (d *Document) countPages(node Dict, visited map[int]bool, depth int) int {
+	kids, ok := d.resolve(node["Kids"]).(Array)
+	if !ok {
+		if node["Type"] == Name("Page") {
+			return 1
+		}
+		return 0
+	}
+	if depth > maxPageTreeDepth {
+		return 0
+	}
+
+	count := 0
+	for _, kid := range kids {
+		if ref, ok := kid.(Ref); ok {
+			if visited[ref.Num] {
+				continue
+			}
+			visited[ref.Num] = true
+		}
+		if child, ok := d.resolve(kid).(Dict); ok {
+			count += d.countPages(child, visited, depth+1)
+		}
+	}
+	return count
+}
+
+// linearized reports whether the file is linearized for fast web view. The
+// linearization dictionary must be the first object in the file, and its
+// /L must still match the file length since an incremental update undoes
+// the linearization.
+func (d *Document) linearized() bool {
+	head := d.data
+	if len(head) > headerSearchSize {
+		head = head[:headerSearchSize]
+	}
+
+	match := objectHeader.FindIndex(head)
+	if match == nil {
+		return false
+	}
+
+	p := &parser{data: d.data, pos: match[0]}
+	_, value, err := p.indirect()
+	if err != nil {
+		return false
+	}
+
+	dict, ok := value.(Dict)
+	if !ok || dict["Linearized"] == nil {
+		return false
+	}
+	length, ok := dict["L"].(int64)
+	return ok && length == int64(len(d.data))
+}
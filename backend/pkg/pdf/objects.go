@@ -0,0 +1,479 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Objects are represented with plain Go values: nil, bool, int64, float64,
+// String, Name, Array, Dict, Ref and *Stream.
+type (
+	// Name is a PDF name object without its leading slash
+	Name string
+	// String holds the raw bytes of a literal or hexadecimal string
+	String []byte
+	// Array is a PDF array
+	Array []interface{}
+	// Dict is a PDF dictionary
+	Dict map[Name]interface{}
+)
+
+// Ref is an indirect reference to an object
+type Ref struct {
+	Num int
+	Gen int
+}
+
+// Stream is a stream object with its still encoded content
+type Stream struct {
+	Dict Dict
+	Data []byte
+}
+
+var (
+	errUnexpectedEOF = errors.New("unexpected end of data")
+)
+
+// parser reads objects from PDF data starting at pos
+type parser struct {
+	data []byte
+	pos  int
+
+	// length resolves an indirect stream /Length, it may be nil
+	length func(ref Ref) (int64, bool)
+}
+
+// isWhitespace reports whether c is PDF whitespace
+func isWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+// isDelimiter reports whether c ends a name, number or keyword
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return isWhitespace(c)
+}
+
+// skipSpace moves past whitespace and comments
+func (p *parser) skipSpace() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '%' {
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+			continue
+		}
+		if !isWhitespace(c) {
+			return
+		}
+		p.pos++
+	}
+}
+
+// keyword reads a bare word such as obj, stream or true
+func (p *parser) keyword() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.data) && !isDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// hasKeyword reports whether the next word is kw without consuming anything else
+func (p *parser) hasKeyword(kw string) bool {
+	saved := p.pos
+	if p.keyword() == kw {
+		return true
+	}
+	p.pos = saved
+	return false
+}
+
+// integer reads an unsigned or signed integer
+func (p *parser) integer() (int64, bool) {
+	saved := p.pos
+	p.skipSpace()
+	start := p.pos
+	if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+		p.pos++
+	}
+	for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+	value, err := strconv.ParseInt(string(p.data[start:p.pos]), 10, 64)
+	if err != nil || (p.pos < len(p.data) && !isDelimiter(p.data[p.pos])) {
+		p.pos = saved
+		return 0, false
+	}
+	return value, true
+}
+
+// object reads the next direct object
+func (p *parser) object() (interface{}, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, errUnexpectedEOF
+	}
+
+	c := p.data[p.pos]
+	switch {
+	case c == '/':
+		return p.name(), nil
+	case c == '(':
+		return p.literalString()
+	case c == '<':
+		if p.pos+1 < len(p.data) && p.data[p.pos+1] == '<' {
+			return p.dict()
+		}
+		return p.hexString()
+	case c == '[':
+		return p.array()
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	}
+
+	switch word := p.keyword(); word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	case "":
+		return nil, fmt.Errorf("unexpected %q at offset %d", c, p.pos)
+	default:
+		return nil, fmt.Errorf("unexpected keyword %q at offset %d", word, p.pos)
+	}
+}
+
+// name reads a name object, decoding #xx escapes
+func (p *parser) name() Name {
+	p.pos++ // Skip the slash
+	var b bytes.Buffer
+	for p.pos < len(p.data) && !isDelimiter(p.data[p.pos]) {
+		c := p.data[p.pos]
+		if c == '#' && p.pos+2 < len(p.data) {
+			if value, err := strconv.ParseUint(string(p.data[p.pos+1:p.pos+3]), 16, 8); err == nil {
+				b.WriteByte(byte(value))
+				p.pos += 3
+				continue
+			}
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+	return Name(b.String())
+}
+
+// number reads an integer, a real or an indirect reference
+func (p *parser) number() (interface{}, error) {
+	start := p.pos
+	if p.data[p.pos] == '+' || p.data[p.pos] == '-' {
+		p.pos++
+	}
+	isReal := false
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '.' {
+			isReal = true
+		} else if c < '0' || c > '9' {
+			break
+		}
+		p.pos++
+	}
+	text := string(p.data[start:p.pos])
+
+	if isReal {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", text, start)
+		}
+		return value, nil
+	}
+
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		// Out of range integers are treated as reals
+		real, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", text, start)
+		}
+		return real, nil
+	}
+
+	// "num gen R" is a reference
+	saved := p.pos
+	if gen, ok := p.integer(); ok && gen >= 0 && value >= 0 {
+		if p.hasKeyword("R") {
+			return Ref{Num: int(value), Gen: int(gen)}, nil
+		}
+	}
+	p.pos = saved
+
+	return value, nil
+}
+
+// literalString reads a (string) with escapes and nested parentheses
+func (p *parser) literalString() (String, error) {
+	p.pos++ // Skip the opening parenthesis
+	var b bytes.Buffer
+	depth := 1
+
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return String(b.Bytes()), nil
+			}
+		case '\r':
+			// End of line markers read as a single newline
+			if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+				p.pos++
+			}
+			c = '\n'
+		case '\\':
+			if p.pos >= len(p.data) {
+				return nil, errUnexpectedEOF
+			}
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				// A backslash before a line break continues the string
+				if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+					p.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for i := 0; i < 2 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+						value = value*8 + int(p.data[p.pos]-'0')
+						p.pos++
+					}
+					c = byte(value)
+				} else {
+					c = e
+				}
+			}
+		}
+		b.WriteByte(c)
+	}
+
+	return nil, errUnexpectedEOF
+}
+
+// hexString reads a <hex string>, padding an odd final digit with zero
+func (p *parser) hexString() (String, error) {
+	p.pos++ // Skip the opening angle bracket
+	var b bytes.Buffer
+	high, haveHigh := byte(0), false
+
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+
+		if c == '>' {
+			if haveHigh {
+				b.WriteByte(high << 4)
+			}
+			return String(b.Bytes()), nil
+		}
+		if isWhitespace(c) {
+			continue
+		}
+
+		value, ok := hexValue(c)
+		if !ok {
+			return nil, fmt.Errorf("invalid hex digit %q at offset %d", c, p.pos-1)
+		}
+		if haveHigh {
+			b.WriteByte(high<<4 | value)
+			haveHigh = false
+		} else {
+			high, haveHigh = value, true
+		}
+	}
+
+	return nil, errUnexpectedEOF
+}
+
+// hexValue returns the value of a hexadecimal digit
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// array reads an [array]
+func (p *parser) array() (Array, error) {
+	p.pos++ // Skip the opening bracket
+	array := Array{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, errUnexpectedEOF
+		}
+		if p.data[p.pos] == ']' {
+			p.pos++
+			return array, nil
+		}
+
+		value, err := p.object()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+	}
+}
+
+// dict reads a <<dictionary>>
+func (p *parser) dict() (Dict, error) {
+	p.pos += 2 // Skip the opening angle brackets
+	dict := Dict{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, errUnexpectedEOF
+		}
+		if p.data[p.pos] == '>' {
+			if p.pos+1 < len(p.data) && p.data[p.pos+1] == '>' {
+				p.pos += 2
+				return dict, nil
+			}
+			return nil, fmt.Errorf("unexpected '>' at offset %d", p.pos)
+		}
+
+		if p.data[p.pos] != '/' {
+			return nil, fmt.Errorf("expected name as dictionary key at offset %d", p.pos)
+		}
+		key := p.name()
+
+		value, err := p.object()
+		if err != nil {
+			return nil, err
+		}
+		// A null value is the same as a missing entry
+		if value != nil {
+			dict[key] = value
+		}
+	}
+}
+
+// indirect reads "num gen obj ... endobj" at the current position
+func (p *parser) indirect() (Ref, interface{}, error) {
+	num, ok := p.integer()
+	if !ok {
+		return Ref{}, nil, fmt.Errorf("expected object number at offset %d", p.pos)
+	}
+	gen, ok := p.integer()
+	if !ok {
+		return Ref{}, nil, fmt.Errorf("expected generation number at offset %d", p.pos)
+	}
+	if !p.hasKeyword("obj") {
+		return Ref{}, nil, fmt.Errorf("expected obj keyword at offset %d", p.pos)
+	}
+	ref := Ref{Num: int(num), Gen: int(gen)}
+
+	value, err := p.object()
+	if err != nil {
+		return ref, nil, err
+	}
+
+	dict, isDict := value.(Dict)
+	if !isDict || !p.hasKeyword("stream") {
+		return ref, value, nil
+	}
+
+	data, err := p.streamData(dict)
+	if err != nil {
+		return ref, nil, err
+	}
+	return ref, &Stream{Dict: dict, Data: data}, nil
+}
+
+// streamData reads the content following a stream keyword. A /Length that
+// doesn't land on endstream is common in damaged files, in which case the
+// data runs up to the next endstream.
+func (p *parser) streamData(dict Dict) ([]byte, error) {
+	// The keyword is followed by CRLF or LF, some writers use a bare CR
+	if p.pos < len(p.data) && p.data[p.pos] == '\r' {
+		p.pos++
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+		p.pos++
+	}
+	start := p.pos
+
+	length := int64(-1)
+	switch value := dict["Length"].(type) {
+	case int64:
+		length = value
+	case Ref:
+		if p.length != nil {
+			if resolved, ok := p.length(value); ok {
+				length = resolved
+			}
+		}
+	}
+
+	if length >= 0 && int64(start)+length <= int64(len(p.data)) {
+		end := start + int(length)
+		check := &parser{data: p.data, pos: end}
+		if check.hasKeyword("endstream") {
+			p.pos = check.pos
+			return p.data[start:end], nil
+		}
+	}
+
+	index := bytes.Index(p.data[start:], []byte("endstream"))
+	if index < 0 {
+		return nil, errors.New("stream has no endstream")
+	}
+	end := start + index
+	p.pos = end + len("endstream")
+
+	// Drop the end of line marker that precedes endstream
+	if end > start && p.data[end-1] == '\n' {
+		end--
+	}
+	if end > start && p.data[end-1] == '\r' {
+		end--
+	}
+	return p.data[start:end], nil
+}
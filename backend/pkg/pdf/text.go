@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// pdfDocEncoding holds the PDFDocEncoding characters that differ from
+// ISO Latin-1. Every other byte maps to the code point of the same value.
+var pdfDocEncoding = map[byte]rune{
+	0x18: '˘', 0x19: 'ˇ', 0x1a: 'ˆ', 0x1b: '˙',
+	0x1c: '˝', 0x1d: '˛', 0x1e: '˚', 0x1f: '˜',
+	0x80: '•', 0x81: '†', 0x82: '‡', 0x83: '…',
+	0x84: '—', 0x85: '–', 0x86: 'ƒ', 0x87: '⁄',
+	0x88: '‹', 0x89: '›', 0x8a: '−', 0x8b: '‰',
+	0x8c: '„', 0x8d: '“', 0x8e: '”', 0x8f: '‘',
+	0x90: '’', 0x91: '‚', 0x92: '™', 0x93: 'ﬁ',
+	0x94: 'ﬂ', 0x95: 'Ł', 0x96: 'Œ', 0x97: 'Š',
+	0x98: 'Ÿ', 0x99: 'Ž', 0x9a: 'ı', 0x9b: 'ł',
+	0x9c: 'œ', 0x9d: 'š', 0x9e: 'ž', 0xa0: '€',
+}
+
+// decodeText decodes a PDF text string, which is UTF-16BE or UTF-8 when it
+// starts with a byte order mark and PDFDocEncoding otherwise
+func decodeText(b []byte) string {
+	var text string
+	switch {
+	case len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff:
+		text = decodeUTF16(b[2:], true)
+	case len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe:
+		// Not allowed by the specification but written by some tools
+		text = decodeUTF16(b[2:], false)
+	case len(b) >= 3 && b[0] == 0xef && b[1] == 0xbb && b[2] == 0xbf:
+		text = strings.ToValidUTF8(string(b[3:]), "�")
+	default:
+		var s strings.Builder
+		for _, c := range b {
+			if r, ok := pdfDocEncoding[c]; ok {
+				s.WriteRune(r)
+			} else {
+				s.WriteRune(rune(c))
+			}
+		}
+		text = s.String()
+	}
+
+	return strings.TrimSpace(strings.TrimRight(text, "\x00"))
+}
+
+// decodeUTF16 decodes UTF-16 code units, dropping a trailing odd byte
+func decodeUTF16(b []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		} else {
+			units = append(units, uint16(b[i+1])<<8|uint16(b[i]))
+		}
+	}
+
+	runes := utf16.Decode(units)
+	buf := make([]byte, 0, len(runes))
+	for _, r := range runes {
+		buf = utf8.AppendRune(buf, r)
+	}
+	return string(buf)
+}
+
+// parseDate parses a PDF date of the form D:YYYYMMDDHHmmSSOHH'mm', where
+// everything after the year is optional
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+
+	// Year, month, day, hour, minute and second
+	fields := []int{0, 1, 1, 0, 0, 0}
+	widths := []int{4, 2, 2, 2, 2, 2}
+	i := 0
+	for f, width := range widths {
+		if i+width > len(s) || !isDigits(s[i:i+width]) {
+			if f == 0 {
+				return time.Time{}, false
+			}
+			break
+		}
+		fields[f], _ = strconv.Atoi(s[i : i+width])
+		i += width
+	}
+
+	if fields[1] < 1 || fields[1] > 12 || fields[2] < 1 || fields[2] > 31 ||
+		fields[3] > 23 || fields[4] > 59 || fields[5] > 60 {
+		return time.Time{}, false
+	}
+
+	location := time.UTC
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		sign := 1
+		if s[i] == '-' {
+			sign = -1
+		}
+		zone := strings.ReplaceAll(s[i+1:], "'", "")
+		var hours, minutes int
+		if len(zone) >= 2 && isDigits(zone[:2]) {
+			hours, _ = strconv.Atoi(zone[:2])
+		}
+		if len(zone) >= 4 && isDigits(zone[2:4]) {
+			minutes, _ = strconv.Atoi(zone[2:4])
+		}
+		location = time.FixedZone("", sign*(hours*3600+minutes*60))
+	}
+
+	return time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, location), true
+}
+
+// isDigits reports whether s is made of ASCII digits only
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
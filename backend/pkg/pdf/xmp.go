@@ -0,0 +1,183 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Namespaces of the XMP properties that are read
+const (
+	nsRDF = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsDC  = "http://purl.org/dc/elements/1.1/"
+	nsXMP = "http://ns.adobe.com/xap/1.0/"
+	nsPDF = "http://ns.adobe.com/pdf/1.3/"
+)
+
+// xmpDateLayouts are the ISO 8601 forms XMP dates are written in
+var xmpDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// XMP holds the commonly used properties of an XMP metadata packet
+type XMP struct {
+	Title       string
+	Creators    []string
+	Description string
+	Subjects    []string
+	Keywords    string
+	Producer    string
+	CreatorTool string
+	CreateDate  time.Time
+	ModifyDate  time.Time
+}
+
+// parseXMP reads the Dublin Core, XMP basic and Adobe PDF properties of an
+// XMP packet. Properties may be written as attributes of rdf:Description or
+// as elements holding plain text or an rdf:Alt, rdf:Seq or rdf:Bag.
+func parseXMP(data []byte) (*XMP, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	values := make(map[xml.Name][]string)
+	var property xml.Name // The property element being read, if any
+	var depth int
+	var text strings.Builder
+	var items []string
+	var isDefault bool // Whether the current rdf:li is the x-default language
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse xmp: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if property.Local != "" {
+				depth++
+				if t.Name.Space == nsRDF && t.Name.Local == "li" {
+					text.Reset()
+					isDefault = false
+					for _, attr := range t.Attr {
+						if attr.Name.Local == "lang" && attr.Value == "x-default" {
+							isDefault = true
+						}
+					}
+				}
+				continue
+			}
+
+			if t.Name.Space == nsRDF && t.Name.Local == "Description" {
+				for _, attr := range t.Attr {
+					if isXMPProperty(attr.Name) {
+						values[attr.Name] = append(values[attr.Name], strings.TrimSpace(attr.Value))
+					}
+				}
+				continue
+			}
+
+			if isXMPProperty(t.Name) {
+				property, depth, items = t.Name, 0, nil
+				text.Reset()
+			}
+
+		case xml.CharData:
+			if property.Local != "" {
+				text.Write(t)
+			}
+
+		case xml.EndElement:
+			if property.Local == "" {
+				continue
+			}
+
+			if depth > 0 {
+				depth--
+				if t.Name.Space == nsRDF && t.Name.Local == "li" {
+					item := strings.TrimSpace(text.String())
+					switch {
+					case item == "":
+					case isDefault:
+						items = append([]string{item}, items...)
+					default:
+						items = append(items, item)
+					}
+					text.Reset()
+				}
+				continue
+			}
+
+			if len(items) == 0 {
+				if value := strings.TrimSpace(text.String()); value != "" {
+					items = []string{value}
+				}
+			}
+			values[property] = append(values[property], items...)
+			property = xml.Name{}
+		}
+	}
+
+	first := func(space, local string) string {
+		if list := values[xml.Name{Space: space, Local: local}]; len(list) > 0 {
+			return list[0]
+		}
+		return ""
+	}
+
+	return &XMP{
+		Title:       first(nsDC, "title"),
+		Creators:    values[xml.Name{Space: nsDC, Local: "creator"}],
+		Description: first(nsDC, "description"),
+		Subjects:    values[xml.Name{Space: nsDC, Local: "subject"}],
+		Keywords:    first(nsPDF, "Keywords"),
+		Producer:    first(nsPDF, "Producer"),
+		CreatorTool: first(nsXMP, "CreatorTool"),
+		CreateDate:  parseXMPDate(first(nsXMP, "CreateDate")),
+		ModifyDate:  parseXMPDate(first(nsXMP, "ModifyDate")),
+	}, nil
+}
+
+// isXMPProperty reports whether name is one of the properties XMP collects
+func isXMPProperty(name xml.Name) bool {
+	switch name.Space {
+	case nsDC:
+		switch name.Local {
+		case "title", "creator", "description", "subject":
+			return true
+		}
+	case nsPDF:
+		return name.Local == "Keywords" || name.Local == "Producer"
+	case nsXMP:
+		switch name.Local {
+		case "CreatorTool", "CreateDate", "ModifyDate":
+			return true
+		}
+	}
+	return false
+}
+
+// parseXMPDate parses an XMP date, returning the zero time if it can't
+func parseXMPDate(s string) time.Time {
+	for _, layout := range xmpDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
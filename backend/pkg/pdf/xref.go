@@ -0,0 +1,319 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// xrefEntry locates an object either at a file offset or inside an object stream
+type xrefEntry struct {
+	offset     int64
+	stream     int // Object stream number for compressed objects
+	index      int // Position within the object stream
+	compressed bool
+	free       bool
+}
+
+var (
+	// ErrNoXref is returned when neither the cross-reference data nor a
+	// scan of the file turns up the document catalog
+	ErrNoXref = errors.New("pdf has no readable cross-reference table")
+
+	// objectHeader finds "num gen obj" when rebuilding a damaged xref
+	objectHeader = regexp.MustCompile(`(\d+)[ \t\r\n\f\x00]+(\d+)[ \t\r\n\f\x00]+obj\b`)
+)
+
+// startXref returns the offset named by the last startxref keyword
+func (d *Document) startXref() (int64, error) {
+	index := bytes.LastIndex(d.data, []byte("startxref"))
+	if index < 0 {
+		return 0, errors.New("pdf has no startxref")
+	}
+
+	p := &parser{data: d.data, pos: index + len("startxref")}
+	offset, ok := p.integer()
+	if !ok || offset < 0 || offset >= int64(len(d.data)) {
+		return 0, fmt.Errorf("invalid startxref offset")
+	}
+	return offset, nil
+}
+
+// loadXref reads the cross-reference sections starting at offset and every
+// section before it. Incremental updates append a new section that points
+// back to the previous one with /Prev, and the newest entry for an object
+// wins, so sections are read newest first and existing entries are kept.
+func (d *Document) loadXref(offset int64) error {
+	visited := make(map[int64]bool)
+	pending := []int64{offset}
+
+	for len(pending) > 0 {
+		offset, pending = pending[0], pending[1:]
+		if visited[offset] {
+			continue
+		}
+		visited[offset] = true
+
+		trailer, err := d.readXrefSection(offset)
+		if err != nil {
+			// A damaged older section only loses entries that the object
+			// header scan in getObject can still find
+			if len(visited) > 1 {
+				return nil
+			}
+			return err
+		}
+
+		// Keys the newer trailers don't repeat are taken from older ones
+		for key, value := range trailer {
+			if _, exists := d.trailer[key]; !exists {
+				d.trailer[key] = value
+			}
+		}
+
+		// Hybrid files keep the entries of compressed objects in a stream
+		// named by the table's trailer, which takes precedence over /Prev
+		var next []int64
+		if stm, ok := trailer["XRefStm"].(int64); ok {
+			next = append(next, stm)
+		}
+		if prev, ok := trailer["Prev"].(int64); ok {
+			next = append(next, prev)
+		}
+		pending = append(next, pending...)
+	}
+
+	return nil
+}
+
+// readXrefSection reads a classic xref table or an xref stream at offset
+// and returns its trailer dictionary
+func (d *Document) readXrefSection(offset int64) (Dict, error) {
+	if offset < 0 || offset >= int64(len(d.data)) {
+		return nil, fmt.Errorf("xref offset %d is out of range", offset)
+	}
+
+	p := &parser{data: d.data, pos: int(offset)}
+	if p.hasKeyword("xref") {
+		return d.readXrefTable(p)
+	}
+	return d.readXrefStream(p)
+}
+
+// readXrefTable reads the subsections of a classic xref table and the
+// trailer that follows it
+func (d *Document) readXrefTable(p *parser) (Dict, error) {
+	for {
+		if p.hasKeyword("trailer") {
+			value, err := p.object()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse trailer: %w", err)
+			}
+			trailer, ok := value.(Dict)
+			if !ok {
+				return nil, errors.New("trailer is not a dictionary")
+			}
+			return trailer, nil
+		}
+
+		start, ok := p.integer()
+		if !ok {
+			return nil, fmt.Errorf("invalid xref subsection at offset %d", p.pos)
+		}
+		count, ok := p.integer()
+		if !ok || start < 0 || count < 0 {
+			return nil, fmt.Errorf("invalid xref subsection at offset %d", p.pos)
+		}
+
+		for i := int64(0); i < count; i++ {
+			offset, ok := p.integer()
+			if !ok {
+				return nil, fmt.Errorf("invalid xref entry at offset %d", p.pos)
+			}
+			if _, ok := p.integer(); !ok {
+				return nil, fmt.Errorf("invalid xref entry at offset %d", p.pos)
+			}
+
+			var entry xrefEntry
+			switch p.keyword() {
+			case "n":
+				entry = xrefEntry{offset: offset}
+			case "f":
+				entry = xrefEntry{free: true}
+			default:
+				return nil, fmt.Errorf("invalid xref entry at offset %d", p.pos)
+			}
+			d.addEntry(int(start+i), entry)
+		}
+	}
+}
+
+// readXrefStream reads a PDF 1.5 cross-reference stream, whose dictionary
+// doubles as the trailer
+func (d *Document) readXrefStream(p *parser) (Dict, error) {
+	_, value, err := p.indirect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse xref stream: %w", err)
+	}
+	stream, ok := value.(*Stream)
+	if !ok || stream.Dict["Type"] != Name("XRef") {
+		return nil, errors.New("xref offset does not point to an xref table or stream")
+	}
+
+	data, err := d.decodeStream(stream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode xref stream: %w", err)
+	}
+
+	widths, _ := stream.Dict["W"].(Array)
+	if len(widths) != 3 {
+		return nil, errors.New("xref stream has an invalid /W")
+	}
+	var w [3]int
+	for i := range w {
+		w[i] = int(d.integer(widths[i], -1))
+		if w[i] < 0 || w[i] > 8 {
+			return nil, errors.New("xref stream has an invalid /W")
+		}
+	}
+	entrySize := w[0] + w[1] + w[2]
+	if entrySize == 0 {
+		return nil, errors.New("xref stream has an invalid /W")
+	}
+
+	index, _ := stream.Dict["Index"].(Array)
+	if index == nil {
+		index = Array{int64(0), stream.Dict["Size"]}
+	}
+
+	for i := 0; i+1 < len(index); i += 2 {
+		start := d.integer(index[i], -1)
+		count := d.integer(index[i+1], -1)
+		if start < 0 || count < 0 {
+			return nil, errors.New("xref stream has an invalid /Index")
+		}
+
+		for j := int64(0); j < count && len(data) >= entrySize; j++ {
+			kind := int64(1) // The type defaults to 1 when its width is zero
+			if w[0] > 0 {
+				kind = readUint(data[:w[0]])
+			}
+			field2 := readUint(data[w[0] : w[0]+w[1]])
+			field3 := readUint(data[w[0]+w[1] : entrySize])
+			data = data[entrySize:]
+
+			switch kind {
+			case 0:
+				d.addEntry(int(start+j), xrefEntry{free: true})
+			case 1:
+				d.addEntry(int(start+j), xrefEntry{offset: field2})
+			case 2:
+				d.addEntry(int(start+j), xrefEntry{stream: int(field2), index: int(field3), compressed: true})
+			}
+		}
+	}
+
+	return stream.Dict, nil
+}
+
+// readUint reads a big-endian unsigned integer of up to eight bytes
+func readUint(b []byte) int64 {
+	var value int64
+	for _, c := range b {
+		value = value<<8 | int64(c)
+	}
+	return value
+}
+
+// addEntry records an xref entry unless a newer section already did
+func (d *Document) addEntry(num int, entry xrefEntry) {
+	if _, exists := d.xref[num]; !exists {
+		d.xref[num] = entry
+	}
+}
+
+// rebuildXref scans the whole file for object headers, for files whose
+// cross-reference data is missing or points to the wrong offsets. Later
+// definitions of an object replace earlier ones, as appended updates do.
+func (d *Document) rebuildXref() error {
+	d.xref = make(map[int]xrefEntry)
+	d.trailer = Dict{}
+	d.objects = make(map[int]interface{})
+	d.streams = make(map[int]*objectStream)
+
+	var streams []int
+	for num, offset := range d.objectHeaders() {
+		d.xref[num] = xrefEntry{offset: offset}
+		streams = append(streams, num)
+	}
+	sort.Ints(streams)
+
+	// Trailers of classic tables and xref stream dictionaries both name
+	// the catalog, the last one in the file being the newest
+	for index := bytes.LastIndex(d.data, []byte("trailer")); index >= 0; index = bytes.LastIndex(d.data[:index], []byte("trailer")) {
+		p := &parser{data: d.data, pos: index + len("trailer")}
+		if value, err := p.object(); err == nil {
+			if trailer, ok := value.(Dict); ok {
+				d.mergeTrailer(trailer)
+				break
+			}
+		}
+	}
+
+	for _, num := range streams {
+		stream, ok := d.getObject(num).(*Stream)
+		if !ok {
+			continue
+		}
+		switch stream.Dict["Type"] {
+		case Name("XRef"):
+			d.mergeTrailer(stream.Dict)
+		case Name("ObjStm"):
+			d.indexObjectStream(num, stream)
+		}
+	}
+
+	// Without any trailer the catalog can still be found by its type
+	if _, ok := d.trailer["Root"]; !ok {
+		for num := range d.xref {
+			if dict, ok := d.getObject(num).(Dict); ok && dict["Type"] == Name("Catalog") {
+				d.trailer["Root"] = Ref{Num: num}
+				break
+			}
+		}
+	}
+
+	if _, ok := d.trailer["Root"]; !ok {
+		return ErrNoXref
+	}
+	return nil
+}
+
+// mergeTrailer copies the document level keys of a trailer that are not
+// known yet
+func (d *Document) mergeTrailer(trailer Dict) {
+	for _, key := range []Name{"Root", "Info", "Encrypt", "ID"} {
+		if _, exists := d.trailer[key]; exists {
+			continue
+		}
+		if value, ok := trailer[key]; ok {
+			d.trailer[key] = value
+		}
+	}
+}
+
+// indexObjectStream adds entries for the objects of an object stream that
+// were not found directly in the file
+func (d *Document) indexObjectStream(num int, stream *Stream) {
+	objects, err := d.objectStream(num, stream)
+	if err != nil {
+		return
+	}
+	for index, object := range objects.nums {
+		if _, exists := d.xref[object]; !exists {
+			d.xref[object] = xrefEntry{stream: num, index: index, compressed: true}
+		}
+	}
+}
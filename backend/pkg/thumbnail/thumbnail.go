@@ -0,0 +1,190 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	// Register the GIF decoder with image.Decode
+	_ "image/gif"
+)
+
+const (
+	// maxPixels caps the size of images that are decoded, as a small
+	// compressed file can describe a huge image
+	maxPixels = 25 << 20
+	// jpegQuality is used for thumbnails of opaque images
+	jpegQuality = 85
+)
+
+var (
+	// ErrImageTooLarge is returned for images with more than maxPixels pixels
+	ErrImageTooLarge = errors.New("image is too large to thumbnail")
+)
+
+// Decode reads a JPEG, PNG or GIF image after checking its dimensions
+func Decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// Fit scales an image down to fit a square of size pixels, keeping its
+// aspect ratio. Images that already fit are returned as they are.
+func Fit(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	dstWidth, dstHeight := size, size
+	if width > height {
+		dstHeight = max(1, height*size/width)
+	} else {
+		dstWidth = max(1, width*size/height)
+	}
+
+	// Work on premultiplied pixels so transparent areas don't bleed colour
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	return shrinkRows(shrinkColumns(src, dstWidth), dstWidth, height, dstHeight)
+}
+
+// Encode writes a thumbnail as JPEG, or as PNG when it has transparency,
+// and returns the data with its content type
+func Encode(img image.Image) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// Placeholder draws a generic thumbnail for content without a cover: a
+// page of the given colour with its top right corner folded over, on a
+// transparent square of size pixels
+func Placeholder(size int, fill color.RGBA) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	// The flap shows the back of the page, halfway to white
+	flap := color.RGBA{
+		R: uint8((int(fill.R) + 255) / 2),
+		G: uint8((int(fill.G) + 255) / 2),
+		B: uint8((int(fill.B) + 255) / 2),
+		A: fill.A,
+	}
+
+	width := size * 3 / 4
+	left := (size - width) / 2
+	fold := width / 4
+	for y := 0; y < size; y++ {
+		for x := left; x < left+width; x++ {
+			// Distance into the corner the fold takes up
+			dx := x - (left + width - fold)
+			switch {
+			case y >= fold || dx < 0:
+				img.SetRGBA(x, y, fill)
+			case dx <= y:
+				img.SetRGBA(x, y, flap)
+			}
+		}
+	}
+
+	return img
+}
+
+// span is the part of the source axis that one destination pixel covers
+type span struct {
+	first   int
+	weights []float64 // Coverage of each source pixel from first on
+}
+
+// spans divides a source axis of length pixels among size destination
+// pixels. Source pixels straddling an edge are shared by how much of them
+// each side covers, so the average stays exact for any ratio.
+func spans(length int, size int) []span {
+	scale := float64(length) / float64(size)
+	result := make([]span, size)
+	for i := range result {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		s := span{first: int(start)}
+		for j := s.first; j < length && float64(j) < end; j++ {
+			weight := min(end, float64(j+1)) - max(start, float64(j))
+			s.weights = append(s.weights, weight/scale)
+		}
+		result[i] = s
+	}
+	return result
+}
+
+// shrinkColumns averages the RGBA pixels of each row down to width columns
+func shrinkColumns(src *image.RGBA, width int) []float32 {
+	height := src.Rect.Dy()
+	out := make([]float32, width*height*4)
+	columns := spans(src.Rect.Dx(), width)
+
+	for y := 0; y < height; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, s := range columns {
+			var sums [4]float64
+			for k, weight := range s.weights {
+				pixel := row[(s.first+k)*4:]
+				for c := range sums {
+					sums[c] += float64(pixel[c]) * weight
+				}
+			}
+			for c, sum := range sums {
+				out[(y*width+x)*4+c] = float32(sum)
+			}
+		}
+	}
+	return out
+}
+
+// shrinkRows averages the rows of the column-shrunk pixels down to height
+// rows of the final image
+func shrinkRows(src []float32, width int, srcHeight int, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	rows := spans(srcHeight, height)
+
+	for y, s := range rows {
+		for x := 0; x < width; x++ {
+			var sums [4]float64
+			for k, weight := range s.weights {
+				pixel := src[((s.first+k)*width+x)*4:]
+				for c := range sums {
+					sums[c] += float64(pixel[c]) * weight
+				}
+			}
+			for c, sum := range sums {
+				dst.Pix[y*dst.Stride+x*4+c] = uint8(min(255, sum+0.5))
+			}
+		}
+	}
+	return dst
+}
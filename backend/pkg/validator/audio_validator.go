@@ -59,11 +59,16 @@ func IsAudio(file multipart.File) (bool, error) {
 		}
 	}
 
+	return IsAudioMIME(mime), nil
+}
+
+// IsAudioMIME checks if detected content is an allowed audio format
+func IsAudioMIME(mime *mimetype.MIME) bool {
 	// Check if the file's MIME type is in our list of allowed audio types
 	mimeStr := mime.String()
 	for _, allowedType := range allowedAudioMimeTypes {
 		if strings.HasPrefix(mimeStr, allowedType) {
-			return true, nil
+			return true
 		}
 	}
 
@@ -71,31 +76,24 @@ func IsAudio(file multipart.File) (bool, error) {
 	ext := strings.ToLower(mime.Extension())
 	switch ext {
 	case ".mp3", ".wav", ".ogg", ".flac", ".aac", ".m4a", ".webm":
-		return true, nil
+		return true
 	}
 
-	return false, nil
+	return false
 }
 
-// ValidateAudioFile validates a file for audio upload
-func ValidateAudioFile(fileHeader *multipart.FileHeader) error {
+// ValidateAudio validates already opened content of the given size for audio upload
+func ValidateAudio(file multipart.File, size int64) error {
 	// Check if file is empty
-	if fileHeader.Size == 0 {
+	if size == 0 {
 		return ErrEmptyFile
 	}
 
 	// Check file size
-	if fileHeader.Size > MaxAudioSize {
+	if size > MaxAudioSize {
 		return ErrFileTooLarge
 	}
 
-	// Open the file
-	file, err := fileHeader.Open()
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
 	// Validate is audio
 	isAudio, err := IsAudio(file)
 	if err != nil {
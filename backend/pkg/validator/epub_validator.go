@@ -40,33 +40,31 @@ func IsEPUB(file multipart.File) (bool, error) {
 		}
 	}
 
+	return IsEPUBMIME(mime), nil
+}
+
+// IsEPUBMIME checks if detected content is an EPUB
+func IsEPUBMIME(mime *mimetype.MIME) bool {
 	// Check if the file is an EPUB (application/epub+zip)
 	// Some systems might recognize it as "application/octet-stream" or "application/zip"
 	mimeStr := mime.String()
 	return mimeStr == "application/epub+zip" ||
 		strings.HasSuffix(mimeStr, "epub+zip") ||
-		(mimeStr == "application/zip" && strings.HasSuffix(strings.ToLower(mime.Extension()), ".epub")), nil
+		(mimeStr == "application/zip" && strings.HasSuffix(strings.ToLower(mime.Extension()), ".epub"))
 }
 
-// ValidateEPUBFile validates a file for EPUB upload
-func ValidateEPUBFile(fileHeader *multipart.FileHeader) error {
+// ValidateEPUB validates already opened content of the given size for EPUB upload
+func ValidateEPUB(file multipart.File, size int64) error {
 	// Check if file is empty
-	if fileHeader.Size == 0 {
+	if size == 0 {
 		return ErrEmptyFile
 	}
 
 	// Check file size
-	if fileHeader.Size > MaxEPUBSize {
+	if size > MaxEPUBSize {
 		return ErrFileTooLarge
 	}
 
-	// Open the file
-	file, err := fileHeader.Open()
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
 	// Validate is EPUB
 	isEPUB, err := IsEPUB(file)
 	if err != nil {
@@ -67,32 +67,27 @@ func IsPDF(file multipart.File) (bool, error) {
 
 	fmt.Printf("Detected MIME type: %s\n", mime.String())
 
-	// Check if the file is a PDF
+	return IsPDFMIME(mime), nil
+}
+
+// IsPDFMIME checks if detected content is a PDF
+func IsPDFMIME(mime *mimetype.MIME) bool {
 	return mime.String() == "application/pdf" ||
-		strings.HasPrefix(mime.String(), "application/pdf"), nil
+		strings.HasPrefix(mime.String(), "application/pdf")
 }
 
-// ValidatePDFFile validates a file for PDF upload
-func ValidatePDFFile(fileHeader *multipart.FileHeader) error {
+// ValidatePDF validates already opened content of the given size for PDF upload
+func ValidatePDF(file multipart.File, size int64) error {
 	// Check if file is empty
-	if fileHeader.Size == 0 {
+	if size == 0 {
 		return ErrEmptyFile
 	}
 
 	// Check file size
-	if fileHeader.Size > MaxPDFSize {
+	if size > MaxPDFSize {
 		return ErrFileTooLarge
 	}
 
-	// Open the file
-	file, err := fileHeader.Open()
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	fmt.Printf("File name: %s\n", fileHeader.Filename)
-
 	// Validate is PDF
 	isPDF, err := IsPDF(file)
 	if err != nil {